@@ -0,0 +1,21 @@
+package pickaxx
+
+// Allowlist lists the players permitted to join a server.
+type Allowlist struct {
+	Enabled bool     `json:"enabled"` // Only listed players may join.
+	Players []string `json:"players"`
+}
+
+// AllowlistManager is implemented by process managers able to change which
+// players may join a server.
+type AllowlistManager interface {
+
+	// Allowlist returns the players currently allowed to join.
+	Allowlist() (Allowlist, error)
+
+	// Allow permits a player to join.
+	Allow(name string) error
+
+	// Disallow removes a player from the allowlist.
+	Disallow(name string) error
+}
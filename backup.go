@@ -0,0 +1,9 @@
+package pickaxx
+
+// Backuper is implemented by process managers able to back up a server.
+type Backuper interface {
+
+	// Backup archives the working directory of the server (asking a running
+	// server to save the world first), returning the path of the backup.
+	Backup() (string, error)
+}
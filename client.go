@@ -2,8 +2,10 @@ package pickaxx
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/apex/log"
@@ -13,6 +15,15 @@ import (
 const (
 	pingFrequency = time.Second * 10
 	pingTimeout   = time.Second * 5
+
+	// writeTimeout is how long a client has to accept a message.
+	writeTimeout = time.Second * 10
+
+	// heartbeatFrequency is how often the output loop reports it is alive.
+	heartbeatFrequency = time.Second * 5
+
+	// heartbeatTimeout is how long the output loop may go without a heartbeat.
+	heartbeatTimeout = time.Second * 30
 )
 
 type websocketClient struct {
@@ -36,6 +47,11 @@ var _ io.Writer = &ClientManager{}
 
 // ClientManager is a collection of clients
 type ClientManager struct {
+	// last time the output loop was active, in unix nanoseconds; accessed
+	// atomically (first, for 64-bit alignment on ARM), so that health checks
+	// are not held up by a broadcast
+	heartbeat int64
+
 	mutex  sync.Mutex
 	done   chan bool
 	output chan map[string]interface{}
@@ -53,6 +69,7 @@ func (c *ClientManager) initialize() {
 	c.pool = map[string]*websocketClient{}
 	c.output = make(chan map[string]interface{}, 1)
 	c.done = make(chan bool, 1)
+	c.beat()
 
 	go outputLoop(c, c.done)
 }
@@ -70,7 +87,10 @@ func (c *ClientManager) Close() error {
 func (c *ClientManager) AddClient(conn *websocket.Conn) {
 	c.initialize()
 	client := websocketClient{conn}
+
+	c.mutex.Lock()
 	c.pool[conn.RemoteAddr().String()] = &client
+	c.mutex.Unlock()
 
 	// pinger
 	go func() {
@@ -79,7 +99,9 @@ func (c *ClientManager) AddClient(conn *websocket.Conn) {
 
 		for {
 			if err := ping(client.Conn); err != nil {
+				c.mutex.Lock()
 				delete(c.pool, conn.RemoteAddr().String())
+				c.mutex.Unlock()
 				client.Close()
 				return
 			}
@@ -103,9 +125,62 @@ func (c *ClientManager) Write(data []byte) (int, error) {
 	return len(data), nil
 }
 
+// beat records that the output loop is alive.
+func (c *ClientManager) beat() {
+	atomic.StoreInt64(&c.heartbeat, time.Now().UnixNano())
+}
+
+// Healthy returns an error if the output loop has stopped processing messages.
+func (c *ClientManager) Healthy() error {
+	beat := atomic.LoadInt64(&c.heartbeat)
+
+	if beat == 0 {
+		return nil // not initialized
+	}
+
+	if last := time.Unix(0, beat); time.Since(last) > heartbeatTimeout {
+		return fmt.Errorf("output loop inactive since %v", last.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// Ready always returns nil; clients may connect at any time.
+func (c *ClientManager) Ready() error {
+	return nil
+}
+
+// Len returns the number of connected clients.
+func (c *ClientManager) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.pool)
+}
+
+// Collect returns metrics for connected clients.
+func (c *ClientManager) Collect() []Metric {
+	return []Metric{{
+		Name:    "pickaxx_websocket_clients",
+		Help:    "Number of connected websocket clients.",
+		Type:    Gauge,
+		Samples: []Sample{{Value: float64(c.Len())}},
+	}}
+}
+
 func (c *ClientManager) broadcast(data map[string]interface{}) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// clients which do not keep up are dropped, so that a broadcast takes at
+	// most writeTimeout
+	deadline := time.Now().Add(writeTimeout)
+
 	for addr, client := range c.pool {
+		client.SetWriteDeadline(deadline)
+
 		if err := client.WriteJSON(data); err != nil {
+			log.WithField("host", addr).Warn("failed to write to client")
+			client.Close()
 			delete(c.pool, addr)
 		}
 	}
@@ -113,10 +188,16 @@ func (c *ClientManager) broadcast(data map[string]interface{}) error {
 }
 
 func outputLoop(c *ClientManager, done chan bool) {
+	ticker := time.NewTicker(heartbeatFrequency)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case val := <-c.output:
 			c.broadcast(val)
+			c.beat()
+		case <-ticker.C:
+			c.beat()
 		case <-done:
 			done <- true // propogate
 			return
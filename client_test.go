@@ -1,7 +1,9 @@
 package pickaxx
 
 import (
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/gorilla/websocket"
@@ -85,3 +87,28 @@ func TestClientManager(t *testing.T) {
 		})
 	}
 }
+
+func TestClientManagerHealthy(t *testing.T) {
+	m := ClientManager{}
+	assert.NoError(t, m.Healthy(), "healthy until initialized")
+
+	m.initialize()
+	defer m.Close()
+	assert.NoError(t, m.Healthy())
+
+	// not held up by a broadcast in progress
+	m.mutex.Lock()
+	healthy := make(chan error)
+	go func() { healthy <- m.Healthy() }()
+
+	select {
+	case err := <-healthy:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "health check blocked by broadcast")
+	}
+	m.mutex.Unlock()
+
+	atomic.StoreInt64(&m.heartbeat, time.Now().Add(-heartbeatTimeout*2).UnixNano())
+	assert.Error(t, m.Healthy())
+}
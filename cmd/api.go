@@ -0,0 +1,888 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/gin-gonic/gin"
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/minecraft"
+)
+
+// apiVersion is the prefix of all versioned API routes.
+const apiVersion = "/api/v1"
+
+// apiError is the body of every unsuccessful API response.
+type apiError struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    string          `json:"code"`             // Stable, machine-readable identifier (e.g. "not_running").
+	Message string          `json:"message"`          // Human-readable description.
+	Checks  []pickaxx.Check `json:"checks,omitempty"` // Failed checks, if the server could not be started.
+}
+
+// Error codes returned by the API.
+const (
+	codeInvalidRequest = "invalid_request"
+	codeUnauthorized   = "unauthorized"
+	codeNotRunning     = "not_running"
+	codeAlreadyRunning = "already_running"
+	codeNotSupported   = "not_supported"
+	codePreflight      = "preflight_failed"
+	codeDowngrade      = "downgrade"
+	codeNoUpgrade      = "no_upgrade"
+	codeNotFound       = "not_found"
+	codeInternal       = "internal_error"
+)
+
+// serverResource describes the managed server.
+type serverResource struct {
+	State   string                `json:"state"`
+	Running bool                  `json:"running"`
+	Players []string              `json:"players"`
+	Health  *pickaxx.ServerHealth `json:"health,omitempty"`
+	Stats   *pickaxx.ProcessStats `json:"stats,omitempty"`   // Latest resource usage sample.
+	Tick    *pickaxx.TickStats    `json:"tick,omitempty"`    // Latest performance sample.
+	Info    *pickaxx.ServerInfo   `json:"info,omitempty"`    // Status reported by the server (e.g. MOTD), if known.
+	Command []string              `json:"command,omitempty"` // Command line used to start the server.
+	Jar     *pickaxx.JarInfo      `json:"jar,omitempty"`     // Version & flavor of the server jar, if launched from one.
+}
+
+// actionResource acknowledges a requested action.
+type actionResource struct {
+	Message string `json:"message"`
+}
+
+// commandRequest is a console command to submit.
+type commandRequest struct {
+	Command string `json:"command"`
+}
+
+type preflightResource struct {
+	Passed bool            `json:"passed"` // All checks passed.
+	Checks []pickaxx.Check `json:"checks"`
+}
+
+type javaResource struct {
+	Runtimes []pickaxx.JavaRuntime `json:"runtimes"` // Newest first.
+}
+
+type logsResource struct {
+	Lines []string `json:"lines"`
+}
+
+type statsResource struct {
+	Latest  *pickaxx.ProcessStats  `json:"latest"`
+	History []pickaxx.ProcessStats `json:"history"`
+}
+
+type performanceResource struct {
+	Latest  *pickaxx.TickStats  `json:"latest"`
+	History []pickaxx.TickStats `json:"history"`
+}
+
+// allowlistRequest names a player to add to, or remove from, the allowlist.
+type allowlistRequest struct {
+	Name string `json:"name"`
+}
+
+// upgradeRequest names a staged jar (see /uploads) to upgrade the server to.
+type upgradeRequest struct {
+	Key   string `json:"key"`
+	Force bool   `json:"force"` // Allow downgrading to an older version.
+}
+
+// rollbackRequest holds optional parameters for rolling back an upgrade.
+type rollbackRequest struct {
+	RestoreWorld bool `json:"restoreWorld"` // Also restore the world from the backup, losing all play since the upgrade.
+}
+
+// pluginRequest names an installed plugin (or mod).
+type pluginRequest struct {
+	File string `json:"file"` // e.g. "plugins/EssentialsX.jar"
+}
+
+type uploadResource struct {
+	Key string `json:"key"` // Identifies the staged file.
+}
+
+type backupResource struct {
+	Path string `json:"path"` // Where the backup was written.
+}
+
+type deliveriesResource struct {
+	Deliveries []pickaxx.Delivery `json:"deliveries"`
+}
+
+// apiParam is a query parameter accepted by a route.
+type apiParam struct {
+	Name        string
+	Type        string // JSON schema type (e.g. "integer").
+	Description string
+}
+
+// apiRoute describes a versioned API route. Routes are registered, and the
+// OpenAPI document generated, from the same description.
+type apiRoute struct {
+	Method      string
+	Path        string
+	Summary     string
+	Query       []apiParam
+	Request     interface{} // Example of the JSON request body, if any.
+	Upload      bool        // Request is a multipart file upload.
+	Status      int         // Status of a successful response.
+	Response    interface{} // Example of the successful response body.
+	ErrorStatus []int       // Statuses of possible error responses.
+	Handler     gin.HandlerFunc
+}
+
+// apiHandler serves the versioned API.
+type apiHandler struct {
+	*processHandler
+}
+
+// routes returns all versioned API routes.
+func (h *apiHandler) routes() []apiRoute {
+	return []apiRoute{
+		{
+			Method: http.MethodGet, Path: "/server", Summary: "Get server status",
+			Status: http.StatusOK, Response: serverResource{},
+			Handler: h.getServer,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/start", Summary: "Start the server",
+			Status: http.StatusAccepted, Response: actionResource{},
+			ErrorStatus: []int{http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+			Handler:     h.startServer,
+		},
+		{
+			Method: http.MethodGet, Path: "/server/preflight", Summary: "Check that the server can be started",
+			Status: http.StatusOK, Response: preflightResource{},
+			ErrorStatus: []int{http.StatusNotImplemented},
+			Handler:     h.getPreflight,
+		},
+		{
+			Method: http.MethodGet, Path: "/server/java", Summary: "List the Java runtimes installed",
+			Status: http.StatusOK, Response: javaResource{},
+			ErrorStatus: []int{http.StatusNotImplemented},
+			Handler:     h.getJava,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/stop", Summary: "Stop the server",
+			Request: stopRequest{},
+			Status:  http.StatusAccepted, Response: actionResource{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusConflict},
+			Handler:     h.stopServer,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/restart", Summary: "Restart the server, waiting until it is running",
+			Request: stopRequest{},
+			Status:  http.StatusOK, Response: actionResource{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+			Handler:     h.restartServer,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/commands", Summary: "Submit a console command",
+			Request: commandRequest{},
+			Status:  http.StatusAccepted, Response: commandRequest{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusConflict},
+			Handler:     h.submitCommand,
+		},
+		{
+			Method: http.MethodGet, Path: "/server/logs", Summary: "Get recent console output",
+			Query:  []apiParam{{"lines", "integer", "Return only the last N lines"}},
+			Status: http.StatusOK, Response: logsResource{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusInternalServerError},
+			Handler:     h.getLogs,
+		},
+		{
+			Method: http.MethodGet, Path: "/server/stats", Summary: "Get process resource usage",
+			Status: http.StatusOK, Response: statsResource{},
+			ErrorStatus: []int{http.StatusNotImplemented},
+			Handler:     h.getStats,
+		},
+		{
+			Method: http.MethodGet, Path: "/server/performance", Summary: "Get in-game tick performance",
+			Status: http.StatusOK, Response: performanceResource{},
+			ErrorStatus: []int{http.StatusNotImplemented},
+			Handler:     h.getPerformance,
+		},
+		{
+			Method: http.MethodGet, Path: "/server/allowlist", Summary: "Get players allowed to join",
+			Status: http.StatusOK, Response: pickaxx.Allowlist{},
+			ErrorStatus: []int{http.StatusInternalServerError, http.StatusNotImplemented},
+			Handler:     h.getAllowlist,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/allowlist/add", Summary: "Allow a player to join",
+			Request: allowlistRequest{},
+			Status:  http.StatusAccepted, Response: actionResource{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusConflict, http.StatusNotImplemented},
+			Handler:     h.allowPlayer,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/allowlist/remove", Summary: "Remove a player from the allowlist",
+			Request: allowlistRequest{},
+			Status:  http.StatusAccepted, Response: actionResource{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusConflict, http.StatusNotImplemented},
+			Handler:     h.disallowPlayer,
+		},
+		{
+			Method: http.MethodPost, Path: "/uploads", Summary: "Stage a server jar",
+			Upload: true,
+			Status: http.StatusCreated, Response: uploadResource{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusInternalServerError},
+			Handler:     h.upload,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/upgrade", Summary: "Upgrade the server jar to a staged jar, restarting a running server",
+			Request: upgradeRequest{},
+			Status:  http.StatusOK, Response: pickaxx.UpgradeResult{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusConflict, http.StatusNotImplemented, http.StatusInternalServerError},
+			Handler:     h.upgrade,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/rollback", Summary: "Roll back the last upgrade, restarting a running server",
+			Request: rollbackRequest{},
+			Status:  http.StatusOK, Response: pickaxx.UpgradeResult{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusConflict, http.StatusNotImplemented, http.StatusInternalServerError},
+			Handler:     h.rollback,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/backups", Summary: "Back up the server's working directory",
+			Status: http.StatusCreated, Response: backupResource{},
+			ErrorStatus: []int{http.StatusNotImplemented, http.StatusInternalServerError},
+			Handler:     h.createBackup,
+		},
+		{
+			Method: http.MethodGet, Path: "/server/plugins", Summary: "List the plugins and mods installed",
+			Status: http.StatusOK, Response: pickaxx.PluginList{},
+			ErrorStatus: []int{http.StatusNotImplemented, http.StatusInternalServerError},
+			Handler:     h.getPlugins,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/plugins", Summary: "Install a plugin or mod, replacing any installed version",
+			Upload: true,
+			Status: http.StatusCreated, Response: pickaxx.PluginChange{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusNotImplemented, http.StatusInternalServerError},
+			Handler:     h.installPlugin,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/plugins/enable", Summary: "Enable a plugin or mod",
+			Request: pluginRequest{},
+			Status:  http.StatusOK, Response: pickaxx.PluginChange{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusNotImplemented, http.StatusInternalServerError},
+			Handler:     h.enablePlugin,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/plugins/disable", Summary: "Disable a plugin or mod, moving it aside",
+			Request: pluginRequest{},
+			Status:  http.StatusOK, Response: pickaxx.PluginChange{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusNotImplemented, http.StatusInternalServerError},
+			Handler:     h.disablePlugin,
+		},
+		{
+			Method: http.MethodPost, Path: "/server/plugins/remove", Summary: "Delete a plugin or mod",
+			Request: pluginRequest{},
+			Status:  http.StatusOK, Response: pickaxx.PluginChange{},
+			ErrorStatus: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusNotImplemented, http.StatusInternalServerError},
+			Handler:     h.removePlugin,
+		},
+		{
+			Method: http.MethodGet, Path: "/webhooks/deliveries", Summary: "Get recent webhook deliveries",
+			Status: http.StatusOK, Response: deliveriesResource{},
+			Handler: h.getDeliveries,
+		},
+	}
+}
+
+// register adds all routes, and the OpenAPI document, to the router group.
+func (h *apiHandler) register(g *gin.RouterGroup) {
+	routes := h.routes()
+
+	for _, r := range routes {
+		g.Handle(r.Method, r.Path, r.Handler)
+	}
+
+	spec := openAPISpec(apiVersion, routes)
+
+	g.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	})
+}
+
+// abortWithError responds with an error object.
+func abortWithError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, apiError{errorDetail{Code: code, Message: message}})
+}
+
+// abortWithPreflight responds with the checks which prevented the server
+// from starting, if that was the cause of an error. Returns true if it did.
+func abortWithPreflight(c *gin.Context, err error) bool {
+	var pf *pickaxx.PreflightError
+
+	if !errors.As(err, &pf) {
+		return false
+	}
+
+	c.AbortWithStatusJSON(http.StatusUnprocessableEntity, apiError{errorDetail{
+		Code:    codePreflight,
+		Message: "server can not be started",
+		Checks:  pf.Failed,
+	}})
+
+	return true
+}
+
+// bindOptional binds a JSON body, if one was sent.
+func bindOptional(c *gin.Context, obj interface{}) bool {
+	if err := shouldBindOptionalJSON(c, obj); err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+
+	return true
+}
+
+func (h *apiHandler) getServer(c *gin.Context) {
+	c.JSON(http.StatusOK, h.serverStatus())
+}
+
+func (h *apiHandler) startServer(c *gin.Context) {
+	activity, err := h.manager.Start()
+
+	switch {
+	case errors.Is(err, pickaxx.ErrProcessExists):
+		abortWithError(c, http.StatusConflict, codeAlreadyRunning, "server already running")
+		return
+	case abortWithPreflight(c, err):
+		return
+	case err != nil:
+		log.WithError(err).Error("failed to start server")
+		abortWithError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+
+	h.monitor(activity)
+	c.JSON(http.StatusAccepted, actionResource{"server starting"})
+}
+
+func (h *apiHandler) stopServer(c *gin.Context) {
+	var req stopRequest
+
+	if !bindOptional(c, &req) {
+		return
+	}
+
+	if err := h.manager.Stop(req.options()...); err != nil {
+		abortWithError(c, http.StatusConflict, codeNotRunning, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusAccepted, actionResource{"server stopping"})
+}
+
+func (h *apiHandler) restartServer(c *gin.Context) {
+	var req stopRequest
+
+	if !bindOptional(c, &req) {
+		return
+	}
+
+	if !h.manager.Running() {
+		abortWithError(c, http.StatusConflict, codeNotRunning, "server not running")
+		return
+	}
+
+	if err := h.manager.Restart(req.options()...); err != nil {
+		log.WithError(err).Error("restart failed")
+
+		if !abortWithPreflight(c, err) {
+			abortWithError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, actionResource{"server restarted"})
+}
+
+func (h *apiHandler) getPreflight(c *gin.Context) {
+	preflighter, ok := h.manager.(pickaxx.Preflighter)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "preflight checks not supported")
+		return
+	}
+
+	checks := preflighter.Preflight()
+	c.JSON(http.StatusOK, preflightResource{len(pickaxx.FailedChecks(checks)) == 0, checks})
+}
+
+func (h *apiHandler) getJava(c *gin.Context) {
+	lister, ok := h.manager.(pickaxx.JavaLister)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "java runtimes not supported")
+		return
+	}
+
+	c.JSON(http.StatusOK, javaResource{lister.JavaRuntimes()})
+}
+
+func (h *apiHandler) submitCommand(c *gin.Context) {
+	var req commandRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Command) == "" {
+		abortWithError(c, http.StatusBadRequest, codeInvalidRequest, "command is required")
+		return
+	}
+
+	if !h.manager.Running() {
+		abortWithError(c, http.StatusConflict, codeNotRunning, "server not running")
+		return
+	}
+
+	log.WithField("cmd", req.Command).Info("executing command")
+
+	if err := h.manager.Submit(req.Command); err != nil {
+		abortWithError(c, http.StatusConflict, codeNotRunning, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusAccepted, req)
+}
+
+func (h *apiHandler) getLogs(c *gin.Context) {
+	n := -1
+
+	if q := c.Query("lines"); q != "" {
+		var err error
+		if n, err = strconv.Atoi(q); err != nil || n < 0 {
+			abortWithError(c, http.StatusBadRequest, codeInvalidRequest, "lines must be a positive integer")
+			return
+		}
+	}
+
+	lines, err := h.recentLogs(n)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternal, "unable to read log")
+		return
+	}
+
+	c.JSON(http.StatusOK, logsResource{lines})
+}
+
+func (h *apiHandler) getStats(c *gin.Context) {
+	reporter, ok := h.manager.(pickaxx.StatsReporter)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "stats not supported")
+		return
+	}
+
+	res := statsResource{History: reporter.Stats()}
+
+	if len(res.History) > 0 {
+		res.Latest = &res.History[len(res.History)-1]
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *apiHandler) getPerformance(c *gin.Context) {
+	reporter, ok := h.manager.(pickaxx.PerformanceReporter)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "performance not supported")
+		return
+	}
+
+	res := performanceResource{History: reporter.Performance()}
+
+	if len(res.History) > 0 {
+		res.Latest = &res.History[len(res.History)-1]
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *apiHandler) getAllowlist(c *gin.Context) {
+	manager, ok := h.manager.(pickaxx.AllowlistManager)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "allowlist not supported")
+		return
+	}
+
+	list, err := manager.Allowlist()
+
+	switch {
+	case errors.Is(err, pickaxx.ErrNotSupported):
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "allowlist not supported")
+		return
+	case err != nil:
+		abortWithError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *apiHandler) allowPlayer(c *gin.Context) {
+	h.changeAllowlist(c, pickaxx.AllowlistManager.Allow, "player allowed")
+}
+
+func (h *apiHandler) disallowPlayer(c *gin.Context) {
+	h.changeAllowlist(c, pickaxx.AllowlistManager.Disallow, "player removed")
+}
+
+// changeAllowlist applies a change to the allowlist, for the player named in the request.
+func (h *apiHandler) changeAllowlist(c *gin.Context, change func(pickaxx.AllowlistManager, string) error, message string) {
+	manager, ok := h.manager.(pickaxx.AllowlistManager)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "allowlist not supported")
+		return
+	}
+
+	var req allowlistRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		abortWithError(c, http.StatusBadRequest, codeInvalidRequest, "name is required")
+		return
+	}
+
+	if !h.manager.Running() {
+		abortWithError(c, http.StatusConflict, codeNotRunning, "server not running")
+		return
+	}
+
+	err := change(manager, req.Name)
+
+	switch {
+	case errors.Is(err, pickaxx.ErrNotSupported):
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "allowlist not supported")
+		return
+	case errors.Is(err, minecraft.ErrInvalidPlayer):
+		abortWithError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	case err != nil:
+		abortWithError(c, http.StatusConflict, codeNotRunning, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusAccepted, actionResource{message})
+}
+
+func (h *apiHandler) upload(c *gin.Context) {
+	file, err := c.FormFile("file")
+
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidRequest, "file not received")
+		return
+	}
+
+	key, err := uploads.Stage(c, file)
+
+	switch {
+	case errors.Is(err, errUnsupportedFile):
+		abortWithError(c, http.StatusUnsupportedMediaType, codeInvalidRequest, err.Error())
+		return
+	case err != nil:
+		abortWithError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, uploadResource{key})
+}
+
+func (h *apiHandler) upgrade(c *gin.Context) {
+	upgrader, ok := h.manager.(pickaxx.Upgrader)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "upgrades not supported")
+		return
+	}
+
+	var req upgradeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.Key == "" {
+		abortWithError(c, http.StatusBadRequest, codeInvalidRequest, "key is required")
+		return
+	}
+
+	path, err := uploads.Path(req.Key)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	res, err := upgrader.Upgrade(path, req.Force)
+
+	if !abortWithUpgradeError(c, err) {
+		os.Remove(path)
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+func (h *apiHandler) rollback(c *gin.Context) {
+	upgrader, ok := h.manager.(pickaxx.Upgrader)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "upgrades not supported")
+		return
+	}
+
+	var req rollbackRequest
+
+	if !bindOptional(c, &req) {
+		return
+	}
+
+	res, err := upgrader.Rollback(req.RestoreWorld)
+
+	if !abortWithUpgradeError(c, err) {
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+func (h *apiHandler) createBackup(c *gin.Context) {
+	backuper, ok := h.manager.(pickaxx.Backuper)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "backups not supported")
+		return
+	}
+
+	path, err := backuper.Backup()
+	if err != nil {
+		log.WithError(err).Error("backup failed")
+		abortWithError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, backupResource{path})
+}
+
+func (h *apiHandler) getPlugins(c *gin.Context) {
+	plugins, ok := h.manager.(pickaxx.PluginManager)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "plugins not supported")
+		return
+	}
+
+	list, err := plugins.Plugins()
+
+	if !abortWithPluginError(c, err) {
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+func (h *apiHandler) installPlugin(c *gin.Context) {
+	plugins, ok := h.manager.(pickaxx.PluginManager)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "plugins not supported")
+		return
+	}
+
+	file, err := c.FormFile("file")
+
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidRequest, "file not received")
+		return
+	}
+
+	key, err := uploads.Stage(c, file)
+
+	switch {
+	case errors.Is(err, errUnsupportedFile):
+		abortWithError(c, http.StatusUnsupportedMediaType, codeInvalidRequest, err.Error())
+		return
+	case err != nil:
+		abortWithError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+
+	path, err := uploads.Path(key)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+	defer os.Remove(path)
+
+	res, err := plugins.InstallPlugin(path, filepath.Base(file.Filename))
+
+	if !abortWithPluginError(c, err) {
+		c.JSON(http.StatusCreated, res)
+	}
+}
+
+func (h *apiHandler) enablePlugin(c *gin.Context) {
+	h.changePlugin(c, func(p pickaxx.PluginManager, file string) (*pickaxx.PluginChange, error) {
+		return p.EnablePlugin(file, true)
+	})
+}
+
+func (h *apiHandler) disablePlugin(c *gin.Context) {
+	h.changePlugin(c, func(p pickaxx.PluginManager, file string) (*pickaxx.PluginChange, error) {
+		return p.EnablePlugin(file, false)
+	})
+}
+
+func (h *apiHandler) removePlugin(c *gin.Context) {
+	h.changePlugin(c, pickaxx.PluginManager.RemovePlugin)
+}
+
+// changePlugin applies a change to the plugin named by the request.
+func (h *apiHandler) changePlugin(c *gin.Context, change func(pickaxx.PluginManager, string) (*pickaxx.PluginChange, error)) {
+	plugins, ok := h.manager.(pickaxx.PluginManager)
+
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "plugins not supported")
+		return
+	}
+
+	var req pluginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.File == "" {
+		abortWithError(c, http.StatusBadRequest, codeInvalidRequest, "file is required")
+		return
+	}
+
+	res, err := change(plugins, req.File)
+
+	if !abortWithPluginError(c, err) {
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// abortWithPluginError responds with an error for a failed change to
+// plugins, returning false if there was no error.
+func abortWithPluginError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, pickaxx.ErrPluginNotFound):
+		abortWithError(c, http.StatusNotFound, codeNotFound, err.Error())
+	case errors.Is(err, pickaxx.ErrNotSupported):
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "plugins not supported")
+	case errors.Is(err, minecraft.ErrInvalidPlugin):
+		abortWithError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+	default:
+		log.WithError(err).Error("unable to change plugins")
+		abortWithError(c, http.StatusInternalServerError, codeInternal, err.Error())
+	}
+	return true
+}
+
+// abortWithUpgradeError responds with an error for a failed upgrade or
+// rollback, returning false if there was no error.
+func abortWithUpgradeError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, pickaxx.ErrNotSupported):
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, "upgrades not supported")
+	case errors.Is(err, minecraft.ErrNotJar):
+		abortWithError(c, http.StatusNotImplemented, codeNotSupported, err.Error())
+	case errors.Is(err, minecraft.ErrInvalidJar):
+		abortWithError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+	case errors.Is(err, pickaxx.ErrDowngrade):
+		abortWithError(c, http.StatusConflict, codeDowngrade, err.Error())
+	case errors.Is(err, pickaxx.ErrNoUpgrade):
+		abortWithError(c, http.StatusConflict, codeNoUpgrade, err.Error())
+	default:
+		log.WithError(err).Error("upgrade failed")
+		abortWithError(c, http.StatusInternalServerError, codeInternal, err.Error())
+	}
+	return true
+}
+
+func (h *apiHandler) getDeliveries(c *gin.Context) {
+	c.JSON(http.StatusOK, deliveriesResource{h.webhooks.Deliveries()})
+}
+
+// serverStatus summarizes the managed server.
+func (h *processHandler) serverStatus() serverResource {
+	var (
+		manager = h.manager
+		res     = serverResource{Running: manager.Running(), Players: []string{}}
+	)
+
+	if reporter, ok := manager.(pickaxx.HealthReporter); ok {
+		health := reporter.Health()
+		res.State = health.State
+		res.Health = &health
+	}
+
+	if lister, ok := manager.(pickaxx.PlayerLister); ok {
+		res.Players = lister.Players()
+	}
+
+	if reporter, ok := manager.(pickaxx.StatsReporter); ok {
+		if stats := reporter.Stats(); len(stats) > 0 {
+			res.Stats = &stats[len(stats)-1]
+		}
+	}
+
+	if reporter, ok := manager.(pickaxx.PerformanceReporter); ok {
+		if perf := reporter.Performance(); len(perf) > 0 {
+			res.Tick = &perf[len(perf)-1]
+		}
+	}
+
+	if reporter, ok := manager.(pickaxx.CommandReporter); ok {
+		res.Command, _ = reporter.CommandLine()
+	}
+
+	if reporter, ok := manager.(pickaxx.JarReporter); ok {
+		res.Jar, _ = reporter.JarInfo()
+	}
+
+	if reporter, ok := manager.(pickaxx.InfoReporter); ok {
+		res.Info = reporter.Info()
+
+		// queries list every player online, including any who joined before pickaxx reattached
+		if res.Info != nil && len(res.Info.PlayerNames) > 0 {
+			res.Players = res.Info.PlayerNames
+		}
+	}
+
+	return res
+}
+
+// recentLogs returns the last n lines of console output (or all lines, if n < 0).
+func (h *processHandler) recentLogs(n int) ([]string, error) {
+	lines := []string{}
+
+	path := h.logPath()
+	if path == "" {
+		return lines, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if text := strings.TrimSuffix(string(content), "\n"); text != "" {
+		lines = strings.Split(text, "\n")
+	}
+
+	if n >= 0 && n < len(lines) {
+		lines = lines[len(lines)-n:]
+	}
+
+	return lines, nil
+}
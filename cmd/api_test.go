@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubManager struct {
+	running bool
+}
+
+func (m *stubManager) Start() (<-chan pickaxx.Data, error) {
+	if m.running {
+		return nil, pickaxx.ErrProcessExists
+	}
+	m.running = true
+	return make(chan pickaxx.Data), nil
+}
+
+func (m *stubManager) Stop(opts ...pickaxx.StopOption) error {
+	m.running = false
+	return nil
+}
+
+func (m *stubManager) Restart(opts ...pickaxx.StopOption) error { return nil }
+func (m *stubManager) Running() bool                            { return m.running }
+func (m *stubManager) Submit(command string) error              { return nil }
+
+func newTestAPI(running bool) (*gin.Engine, *apiHandler) {
+	gin.SetMode(gin.TestMode)
+
+	h := &apiHandler{&processHandler{
+		manager:  &stubManager{running: running},
+		writer:   &pickaxx.ClientManager{},
+		webhooks: pickaxx.NewWebhookDispatcher(nil),
+	}}
+
+	e := gin.New()
+	h.register(e.Group(apiVersion))
+
+	return e, h
+}
+
+func TestOpenAPIDocumentsAllRoutes(t *testing.T) {
+	e, h := newTestAPI(false)
+
+	spec := openAPISpec(apiVersion, h.routes())
+	paths := spec["paths"].(map[string]map[string]interface{})
+
+	for _, r := range e.Routes() {
+		if r.Path == apiVersion+"/openapi.json" {
+			continue
+		}
+
+		op, ok := paths[r.Path][strings.ToLower(r.Method)]
+		require.True(t, ok, "%s %s not documented", r.Method, r.Path)
+
+		responses := op.(map[string]interface{})["responses"].(map[string]interface{})
+		assert.Contains(t, responses, "401")
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	_, h := newTestAPI(false)
+
+	raw, err := json.Marshal(openAPISpec(apiVersion, h.routes()))
+	require.NoError(t, err)
+
+	var spec struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &spec))
+
+	server := spec.Components.Schemas["Server"]
+	assert.Equal(t, "boolean", server.Properties["running"]["type"])
+	assert.Equal(t, "#/components/schemas/ProcessStats", server.Properties["stats"]["$ref"])
+	assert.Equal(t, "array", server.Properties["players"]["type"])
+
+	errorSchema := spec.Components.Schemas["Error"]
+	assert.Equal(t, "#/components/schemas/ErrorDetail", errorSchema.Properties["error"]["$ref"])
+}
+
+func TestAPIResponses(t *testing.T) {
+	tests := []struct {
+		running bool
+		method  string
+		path    string
+		body    string
+		status  int
+		code    string
+	}{
+		{false, http.MethodGet, "/server", "", http.StatusOK, ""},
+		{false, http.MethodPost, "/server/start", "", http.StatusAccepted, ""},
+		{true, http.MethodPost, "/server/start", "", http.StatusConflict, codeAlreadyRunning},
+		{true, http.MethodPost, "/server/stop", `{"countdown": 10}`, http.StatusAccepted, ""},
+		{true, http.MethodPost, "/server/stop", `{"countdown": "soon"}`, http.StatusBadRequest, codeInvalidRequest},
+		{false, http.MethodPost, "/server/restart", "", http.StatusConflict, codeNotRunning},
+		{true, http.MethodPost, "/server/commands", `{"command": "list"}`, http.StatusAccepted, ""},
+		{true, http.MethodPost, "/server/commands", `{}`, http.StatusBadRequest, codeInvalidRequest},
+		{false, http.MethodPost, "/server/commands", `{"command": "list"}`, http.StatusConflict, codeNotRunning},
+		{false, http.MethodGet, "/server/logs?lines=x", "", http.StatusBadRequest, codeInvalidRequest},
+		{false, http.MethodGet, "/server/stats", "", http.StatusNotImplemented, codeNotSupported},
+		{false, http.MethodGet, "/server/allowlist", "", http.StatusNotImplemented, codeNotSupported},
+		{false, http.MethodGet, "/server/preflight", "", http.StatusNotImplemented, codeNotSupported},
+		{true, http.MethodPost, "/server/allowlist/add", `{"name": "Steve"}`, http.StatusNotImplemented, codeNotSupported},
+		{false, http.MethodPost, "/uploads", "", http.StatusBadRequest, codeInvalidRequest},
+		{false, http.MethodPost, "/server/backups", "", http.StatusNotImplemented, codeNotSupported},
+		{false, http.MethodGet, "/server/plugins", "", http.StatusNotImplemented, codeNotSupported},
+		{false, http.MethodPost, "/server/plugins/disable", `{"file": "plugins/a.jar"}`, http.StatusNotImplemented, codeNotSupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			e, h := newTestAPI(tt.running)
+
+			req := httptest.NewRequest(tt.method, apiVersion+tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+
+			// every status returned must be documented
+			path := strings.Split(tt.path, "?")[0]
+			documented := false
+			for _, r := range h.routes() {
+				if r.Path == path && r.Method == tt.method {
+					documented = r.Status == rec.Code
+					for _, s := range r.ErrorStatus {
+						documented = documented || s == rec.Code
+					}
+				}
+			}
+			assert.True(t, documented, "status %d not documented", rec.Code)
+
+			if tt.code != "" {
+				var body apiError
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Equal(t, tt.code, body.Error.Code)
+				assert.NotEmpty(t, body.Error.Message)
+			}
+		})
+	}
+}
+
+func TestAPIChunkedBody(t *testing.T) {
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{"", http.StatusAccepted},
+		{`{"countdown": 10}`, http.StatusAccepted},
+		{`{"countdown": "soon"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		e, _ := newTestAPI(true)
+
+		// chunked bodies are of unknown length
+		req := httptest.NewRequest(http.MethodPost, apiVersion+"/server/stop", strings.NewReader(tt.body))
+		req.ContentLength = -1
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, tt.status, rec.Code, tt.body)
+	}
+}
+
+func TestAPILogsWhileStarting(t *testing.T) {
+	e, h := newTestAPI(false)
+
+	// the log file is replaced as the server starts, while logs are read
+	started := make(chan []string)
+	go func() {
+		var paths []string
+		for i := 0; i < 10; i++ {
+			activity := make(chan pickaxx.Data)
+			assert.NoError(t, h.monitor(activity))
+			close(activity)
+			paths = append(paths, h.logPath())
+		}
+		started <- paths
+	}()
+
+	for i := 0; i < 10; i++ {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, apiVersion+"/server/logs", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	for _, path := range <-started {
+		os.Remove(path)
+	}
+}
+
+// preflightManager fails to start, as preflight checks fail.
+type preflightManager struct {
+	stubManager
+}
+
+func (m *preflightManager) Preflight() []pickaxx.Check {
+	return []pickaxx.Check{
+		{Name: "eula", Message: "EULA has not been accepted", Fix: "Set 'eula=true'."},
+		{Name: "port", Passed: true, Message: "tcp port 25565 is free"},
+	}
+}
+
+func (m *preflightManager) Start() (<-chan pickaxx.Data, error) {
+	return nil, &pickaxx.PreflightError{Failed: pickaxx.FailedChecks(m.Preflight())}
+}
+
+func TestAPIPreflight(t *testing.T) {
+	e, h := newTestAPI(false)
+	h.manager = &preflightManager{}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, apiVersion+"/server/start", nil))
+
+	var body apiError
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, codePreflight, body.Error.Code)
+	require.Len(t, body.Error.Checks, 1)
+	assert.Equal(t, "eula", body.Error.Checks[0].Name)
+	assert.Equal(t, "Set 'eula=true'.", body.Error.Checks[0].Fix)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, apiVersion+"/server/preflight", nil))
+
+	var res preflightResource
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.False(t, res.Passed)
+	assert.Len(t, res.Checks, 2)
+}
+
+func TestAPIUnauthorized(t *testing.T) {
+	e := gin.New()
+	e.Group(apiVersion, requireToken(newAPIToken("secret"))).GET("/server", func(c *gin.Context) {})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, apiVersion+"/server", nil))
+
+	var body apiError
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, codeUnauthorized, body.Error.Code)
+}
+
+func TestOperationID(t *testing.T) {
+	assert.Equal(t, "postServerStart", operationID(apiRoute{Method: http.MethodPost, Path: "/server/start"}))
+	assert.Equal(t, "getWebhooksDeliveries", operationID(apiRoute{Method: http.MethodGet, Path: "/webhooks/deliveries"}))
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenParam matches a token given as a query parameter.
+var tokenParam = regexp.MustCompile(`([?&]token=)[^&]*`)
+
+// tokenCookie holds the token for browsers, once provided as a query parameter.
+const tokenCookie = "pickaxx_token"
+
+// apiToken holds the token required of API clients. It may be replaced while
+// in use (e.g. when configuration is reloaded).
+type apiToken struct {
+	value atomic.Value
+}
+
+func newAPIToken(token string) *apiToken {
+	t := &apiToken{}
+	t.Set(token)
+	return t
+}
+
+// Get returns the current token.
+func (t *apiToken) Get() string {
+	return t.value.Load().(string)
+}
+
+// Set replaces the token.
+func (t *apiToken) Set(token string) {
+	t.value.Store(token)
+}
+
+// requireToken is middleware which rejects requests that do not provide the
+// current token. Tokens are accepted as a bearer token in the 'Authorization'
+// header, a cookie, or a 'token' query parameter (which also sets the cookie,
+// so browsers only need to provide it once).
+func requireToken(current *apiToken) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := current.Get()
+
+		if token == "" {
+			return // authentication not configured
+		}
+
+		if q := c.Query("token"); q != "" && tokensMatch(q, token) {
+			c.SetCookie(tokenCookie, q, 0, "/", "", c.Request.TLS != nil, true)
+			return
+		}
+
+		if bearer := c.GetHeader("Authorization"); strings.HasPrefix(bearer, "Bearer ") {
+			if tokensMatch(strings.TrimPrefix(bearer, "Bearer "), token) {
+				return
+			}
+		}
+
+		if cookie, err := c.Cookie(tokenCookie); err == nil && tokensMatch(cookie, token) {
+			return
+		}
+
+		if strings.HasPrefix(c.Request.URL.Path, apiVersion) {
+			abortWithError(c, http.StatusUnauthorized, codeUnauthorized, "invalid or missing token")
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"err": "invalid or missing token"})
+	}
+}
+
+func tokensMatch(provided, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) == 1
+}
+
+// redactToken hides the value of a token given as a query parameter in
+// path, so that it is not written to logs.
+func redactToken(path string) string {
+	return tokenParam.ReplaceAllString(path, "${1}REDACTED")
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactToken(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/", "/"},
+		{"/?token=secret", "/?token=REDACTED"},
+		{"/console?tab=logs&token=secret", "/console?tab=logs&token=REDACTED"},
+		{"/?token=secret&tab=logs", "/?token=REDACTED&tab=logs"},
+		{"/?mytoken=value", "/?mytoken=value"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, redactToken(tt.path), tt.path)
+	}
+}
+
+func TestLogFormatter(t *testing.T) {
+	line := logFormatter(gin.LogFormatterParams{
+		StatusCode: 200,
+		Method:     "GET",
+		Path:       "/?token=secret",
+	})
+
+	assert.NotContains(t, line, "secret")
+	assert.Contains(t, line, `"/?token=REDACTED"`)
+}
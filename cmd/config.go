@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/ivan3bx/pickaxx/minecraft"
+)
+
+const (
+	// defaultConfigFile is read at startup, if it exists.
+	defaultConfigFile = "pickaxx.json"
+
+	// defaultListenAddr is the address of the web server, if not configured.
+	defaultListenAddr = "127.0.0.1:8080"
+)
+
+// config holds settings loaded from a JSON file.
+type config struct {
+	Listen   string                  `json:"listen"`   // Address of the web server.
+	Token    string                  `json:"token"`    // Required by API clients, if set.
+	TLS      *tlsConfig              `json:"tls"`      // Serves HTTPS, if set.
+	Detach   bool                    `json:"detach"`   // Leave the server running when pickaxx exits.
+	Server   serverConfig            `json:"server"`   // The game server to manage.
+	Types    []gameserver.ServerType `json:"types"`    // Server types, in addition to those built in.
+	JavaDirs []string                `json:"javaDirs"` // Searched for Java runtimes, in addition to common install paths.
+	Webhooks []pickaxx.WebhookConfig `json:"webhooks"` // Notified of events from the server.
+}
+
+// serverConfig describes the game server to manage.
+type serverConfig struct {
+	Type  string `json:"type"`  // Name of a server type. Defaults to Minecraft: Java Edition.
+	Dir   string `json:"dir"`   // Working directory of the server.
+	Port  int    `json:"port"`  // Defaults to the port in server.properties, or that of the server type.
+	Query bool   `json:"query"` // Enable the query protocol, reporting every player online.
+
+	// JVM settings (e.g. heap size), for servers launched from a jar.
+	JVM *minecraft.JVMConfig `json:"jvm"`
+
+	// Java runtime, as a path or major version (e.g. "17"). Chosen to suit the server jar, if not set.
+	Java string `json:"java"`
+}
+
+// serverType returns the configured server type.
+func (c *config) serverType() (gameserver.ServerType, error) {
+	name := c.Server.Type
+	if name == "" {
+		return minecraft.JavaEdition, nil
+	}
+
+	for _, t := range c.Types {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+
+	if t, ok := gameserver.Types[name]; ok {
+		return t, nil
+	}
+
+	return gameserver.ServerType{}, fmt.Errorf("unknown server type '%s'", name)
+}
+
+// serverOptions returns options for managing the configured server.
+func (c *config) serverOptions() ([]gameserver.Option, error) {
+	t, err := c.serverType()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []gameserver.Option{gameserver.WithType(t)}
+
+	if c.Server.Dir != "" {
+		opts = append(opts, gameserver.WithWorkingDir(c.Server.Dir))
+	}
+
+	if c.Detach {
+		opts = append(opts, gameserver.WithDetach())
+	}
+
+	if c.Server.Query {
+		opts = append(opts, minecraft.WithQuery())
+	}
+
+	if c.Server.JVM != nil {
+		opts = append(opts, minecraft.WithJVM(*c.Server.JVM))
+	}
+
+	if c.Server.Java != "" {
+		opts = append(opts, minecraft.WithJava(c.Server.Java))
+	}
+
+	if len(c.JavaDirs) > 0 {
+		opts = append(opts, minecraft.WithJavaDirs(c.JavaDirs...))
+	}
+
+	return opts, nil
+}
+
+// clientAddress returns the URL of this instance, for clients on the same
+// host (e.g. 'pickaxx ctl').
+func (c *config) clientAddress() string {
+	scheme := "http"
+	if c.TLS != nil {
+		scheme = "https"
+	}
+
+	host, port, err := net.SplitHostPort(c.Listen)
+	if err != nil {
+		return scheme + "://" + c.Listen
+	}
+
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+// loadConfig reads configuration from the given path. A missing file
+// results in a default configuration.
+func loadConfig(path string) (*config, error) {
+	cfg := &config{Listen: defaultListenAddr}
+
+	b, err := ioutil.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %w", path, err)
+	}
+
+	// each instance manages a single server
+	var multiple struct {
+		Servers json.RawMessage `json:"servers"`
+	}
+	if json.Unmarshal(b, &multiple); multiple.Servers != nil {
+		return nil, fmt.Errorf("invalid config file '%s': 'servers' is not supported; run an instance of pickaxx for each server", path)
+	}
+
+	for i, hook := range cfg.Webhooks {
+		if hook.URL == "" {
+			return nil, fmt.Errorf("invalid config file '%s': webhook %d has no url", path, i)
+		}
+	}
+
+	for _, t := range cfg.Types {
+		if err := t.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config file '%s': %w", path, err)
+		}
+	}
+
+	if _, err := cfg.serverType(); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %w", path, err)
+	}
+
+	if jvm := cfg.Server.JVM; jvm != nil {
+		if err := jvm.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config file '%s': %w", path, err)
+		}
+	}
+
+	if t := cfg.TLS; t != nil && !t.SelfSigned && (t.CertFile == "" || t.KeyFile == "") {
+		return nil, fmt.Errorf("invalid config file '%s': tls requires cert & key files, or selfSigned", path)
+	}
+
+	return cfg, nil
+}
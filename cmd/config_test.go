@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/ivan3bx/pickaxx/minecraft"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	path := filepath.Join(t.TempDir(), "pickaxx.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(body), 0644))
+	return path
+}
+
+func TestLoadConfigServerType(t *testing.T) {
+	cfg, err := loadConfig(writeConfig(t, `{
+		"server": {"type": "sleeper", "dir": "/srv/sleeper"},
+		"types": [{"name": "sleeper", "command": ["sleep", "{{.Port}}"], "probe": "none"}]
+	}`))
+	require.NoError(t, err)
+
+	st, err := cfg.serverType()
+	require.NoError(t, err)
+	assert.Equal(t, "sleeper", st.Name)
+	assert.Equal(t, gameserver.ProbeNone, st.Probe)
+
+	cfg, err = loadConfig(writeConfig(t, `{}`))
+	require.NoError(t, err)
+
+	st, err = cfg.serverType()
+	require.NoError(t, err)
+	assert.Equal(t, minecraft.JavaEdition.Name, st.Name, "defaults to java edition")
+}
+
+func TestLoadConfigInvalidServerType(t *testing.T) {
+	tests := map[string]string{
+		"unknown type": `{"server": {"type": "nope"}}`,
+		"invalid type": `{"types": [{"name": "x"}]}`,
+		"bad pattern":  `{"types": [{"name": "x", "command": ["x"], "ready": "("}]}`,
+		"bad jvm":      `{"server": {"jvm": {"profile": "turbo"}}}`,
+		"servers":      `{"servers": [{"type": "minecraft"}, {"type": "bedrock"}]}`,
+	}
+
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadConfig(writeConfig(t, body))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestClientAddress(t *testing.T) {
+	tests := []struct {
+		listen   string
+		tls      bool
+		expected string
+	}{
+		{"127.0.0.1:8080", false, "http://127.0.0.1:8080"},
+		{":8080", false, "http://127.0.0.1:8080"},
+		{"0.0.0.0:8443", true, "https://127.0.0.1:8443"},
+		{"[::]:8080", false, "http://127.0.0.1:8080"},
+		{"example.com:8080", false, "http://example.com:8080"},
+	}
+
+	for _, tt := range tests {
+		cfg := &config{Listen: tt.listen}
+		if tt.tls {
+			cfg.TLS = &tlsConfig{SelfSigned: true}
+		}
+
+		assert.Equal(t, tt.expected, cfg.clientAddress(), tt.listen)
+	}
+}
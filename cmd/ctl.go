@@ -0,0 +1,675 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/minecraft"
+	"golang.org/x/term"
+)
+
+const ctlUsage = `Usage: pickaxx ctl [flags] <command> [args]
+
+Commands:
+  status                 show server state & resource usage
+  start                  start the server
+  stop [flags]           stop the server (see 'stop -h')
+  restart [flags]        restart the server (see 'restart -h')
+  send <command>         send a command to the server console
+  logs [-f] [-n lines]   show recent console output, optionally following new output
+  console                interactive console
+  upgrade [-force] <key> upgrade the server jar to a jar staged with /api/v1/uploads
+  rollback [-world]      roll back the last upgrade (-world also restores the world)
+  backup                 back up the server's working directory
+  health                 check pickaxx is healthy, exiting non-zero if not
+
+Flags:
+`
+
+// ctlClient talks to a running instance of pickaxx over HTTP.
+type ctlClient struct {
+	addr   *url.URL
+	token  string
+	json   bool
+	out    io.Writer
+	client *http.Client
+	dialer *websocket.Dialer
+}
+
+// clientFlags registers flags for connecting to an instance of pickaxx,
+// returning a function which creates a client once flags are parsed.
+func clientFlags(fs *flag.FlagSet) func() (*ctlClient, error) {
+	var (
+		addr   = fs.String("addr", "", "address of the pickaxx instance (defaults to the listen address in -config)")
+		config = fs.String("config", defaultConfigFile, "configuration file of a local instance, read for its address & token unless -addr is given")
+		token  = fs.String("token", os.Getenv("PICKAXX_TOKEN"), "API token (defaults to $PICKAXX_TOKEN)")
+		asJSON = fs.Bool("json", false, "print JSON responses")
+		caCert = fs.String("cacert", "", "trust the certificate in this file (e.g. a self-signed pickaxx.crt)")
+		noCert = fs.Bool("insecure", false, "skip verification of the server certificate")
+	)
+
+	return func() (*ctlClient, error) {
+		if *addr == "" {
+			cfg, err := loadConfig(*config)
+			if err != nil {
+				return nil, err
+			}
+
+			*addr = cfg.clientAddress()
+
+			if *token == "" {
+				*token = cfg.Token
+			}
+
+			if t := cfg.TLS; t != nil && t.SelfSigned && *caCert == "" && !*noCert {
+				*caCert = t.CertFile
+				if *caCert == "" {
+					*caCert = defaultCertFile
+				}
+			}
+		}
+
+		u, err := url.Parse(*addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address: %w", err)
+		}
+
+		tlsConfig := &tls.Config{InsecureSkipVerify: *noCert}
+
+		if *caCert != "" {
+			pem, err := ioutil.ReadFile(*caCert)
+			if err != nil {
+				return nil, err
+			}
+
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in '%s'", *caCert)
+			}
+		}
+
+		return &ctlClient{
+			addr:  u,
+			token: *token,
+			json:  *asJSON,
+			out:   os.Stdout,
+			client: &http.Client{
+				Timeout:   time.Minute * 10, // restarts may include a countdown, and upgrades wait for the server to load
+				Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+			},
+			dialer: &websocket.Dialer{
+				Proxy:            http.ProxyFromEnvironment,
+				HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+				TLSClientConfig:  tlsConfig,
+			},
+		}, nil
+	}
+}
+
+// runCtl runs the 'ctl' subcommand, returning an exit code.
+func runCtl(args []string) int {
+	var (
+		fs        = flag.NewFlagSet("ctl", flag.ContinueOnError)
+		newClient = clientFlags(fs)
+	)
+
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), ctlUsage)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	c, err := newClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if err := c.run(fs.Arg(0), fs.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func (c *ctlClient) run(command string, args []string) error {
+	switch command {
+	case "status":
+		return c.status()
+	case "start":
+		return c.post(apiVersion+"/server/start", nil, "server starting")
+	case "stop", "restart":
+		return c.stop(command, args)
+	case "send":
+		if len(args) == 0 {
+			return errors.New("usage: send <command>")
+		}
+		return c.post(apiVersion+"/server/commands", commandRequest{strings.Join(args, " ")}, "command sent")
+	case "logs":
+		return c.logs(args)
+	case "console":
+		return c.console()
+	case "upgrade":
+		return c.upgrade(args)
+	case "rollback":
+		return c.rollback(args)
+	case "backup":
+		return c.backup()
+	case "health":
+		return c.health()
+	default:
+		return fmt.Errorf("unknown command '%s'", command)
+	}
+}
+
+func (c *ctlClient) status() error {
+	var body struct {
+		Running bool   `json:"running"`
+		State   string `json:"state"`
+		Stats   *struct {
+			CPUPercent float64 `json:"cpuPercent"`
+			RSS        uint64  `json:"rss"`
+		} `json:"stats"`
+		Tick *struct {
+			TPS float64 `json:"tps"`
+		} `json:"tick"`
+	}
+
+	raw, err := c.request(http.MethodGet, apiVersion+"/server", nil)
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.printJSON(raw)
+	}
+
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return err
+	}
+
+	state := body.State
+	if state == "" {
+		state = map[bool]string{true: "Running", false: "Stopped"}[body.Running]
+	}
+
+	fmt.Fprintf(c.out, "State:  %s\n", state)
+
+	if body.Stats != nil {
+		fmt.Fprintf(c.out, "CPU:    %.1f%%\n", body.Stats.CPUPercent)
+		fmt.Fprintf(c.out, "Memory: %.1f MB\n", float64(body.Stats.RSS)/1024/1024)
+	}
+
+	if body.Tick != nil {
+		fmt.Fprintf(c.out, "TPS:    %.1f\n", body.Tick.TPS)
+	}
+
+	return nil
+}
+
+func (c *ctlClient) stop(command string, args []string) error {
+	var (
+		fs  = flag.NewFlagSet(command, flag.ContinueOnError)
+		req = stopRequest{}
+	)
+
+	fs.IntVar(&req.Countdown, "countdown", 0, "seconds to warn players before stopping")
+	fs.IntVar(&req.Interval, "interval", 0, "seconds between warnings")
+	fs.BoolVar(&req.Save, "save", false, "save the world before stopping")
+	fs.IntVar(&req.Timeout, "timeout", 0, "seconds to wait before killing the process")
+	fs.StringVar(&req.Reason, "reason", "", "reason shown to players")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	done := map[string]string{"stop": "server stopping", "restart": "server restarted"}[command]
+	return c.post(apiVersion+"/server/"+command, req, done)
+}
+
+func (c *ctlClient) upgrade(args []string) error {
+	var (
+		fs  = flag.NewFlagSet("upgrade", flag.ContinueOnError)
+		req = upgradeRequest{}
+	)
+
+	fs.BoolVar(&req.Force, "force", false, "allow downgrading to an older version")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: upgrade [-force] <key>")
+	}
+
+	req.Key = fs.Arg(0)
+	return c.upgradeRequest("/server/upgrade", req)
+}
+
+func (c *ctlClient) rollback(args []string) error {
+	var (
+		fs  = flag.NewFlagSet("rollback", flag.ContinueOnError)
+		req = rollbackRequest{}
+	)
+
+	fs.BoolVar(&req.RestoreWorld, "world", false, "also restore the world from the backup, losing all play since the upgrade")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 0 {
+		return errors.New("usage: rollback [-world]")
+	}
+
+	return c.upgradeRequest("/server/rollback", req)
+}
+
+// health checks that pickaxx is healthy, as reported by /healthz. The body
+// of a failing check is read, rather than returned as an error by request.
+func (c *ctlClient) health() error {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(c.addr.String(), "/")+"/healthz", nil)
+	if err != nil {
+		return err
+	}
+	req.Header = c.headers()
+
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	raw, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+
+	var body struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+
+	if err := json.Unmarshal(raw, &body); err != nil || body.Status == "" {
+		return responseError(rsp, raw)
+	}
+
+	if c.json {
+		c.printJSON(raw)
+	} else {
+		fmt.Fprintln(c.out, body.Status)
+	}
+
+	if body.Status == "ok" {
+		return nil
+	}
+
+	var failing []string
+	for name, check := range body.Checks {
+		if check != "ok" {
+			failing = append(failing, fmt.Sprintf("%s: %s", name, check))
+		}
+	}
+	sort.Strings(failing)
+
+	return fmt.Errorf("unhealthy (%s)", strings.Join(failing, ", "))
+}
+
+func (c *ctlClient) backup() error {
+	raw, err := c.request(http.MethodPost, apiVersion+"/server/backups", nil)
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.printJSON(raw)
+	}
+
+	var res backupResource
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "backup: %s\n", res.Path)
+	return nil
+}
+
+// upgradeRequest upgrades (or rolls back) the server jar, printing the result.
+func (c *ctlClient) upgradeRequest(path string, body interface{}) error {
+	raw, err := c.request(http.MethodPost, apiVersion+path, body)
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.printJSON(raw)
+	}
+
+	var res pickaxx.UpgradeResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.out, formatUpgrade(res))
+	return nil
+}
+
+// formatUpgrade summarizes the result of an upgrade or rollback.
+func formatUpgrade(res pickaxx.UpgradeResult) string {
+	describe := func(info *pickaxx.JarInfo) string {
+		if info == nil {
+			return "none"
+		}
+		return minecraft.DescribeJar(info)
+	}
+
+	lines := []string{fmt.Sprintf("server jar: %s -> %s", describe(res.From), describe(res.To))}
+
+	if res.Backup != "" {
+		lines = append(lines, fmt.Sprintf("backup: %s", res.Backup))
+	}
+
+	if res.WorldRestored {
+		lines = append(lines, "world restored from backup")
+	}
+
+	switch {
+	case !res.Restarted:
+		lines = append(lines, "server not running; start it to use the new jar")
+	case res.Running:
+		lines = append(lines, "server restarted, and is running")
+	default:
+		lines = append(lines, fmt.Sprintf("server did not start: %s", res.Error))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (c *ctlClient) logs(args []string) error {
+	var (
+		fs     = flag.NewFlagSet("logs", flag.ContinueOnError)
+		follow = fs.Bool("f", false, "follow new output")
+		lines  = fs.Int("n", 50, "number of recent lines to show")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	raw, err := c.request(http.MethodGet, fmt.Sprintf("%s/server/logs?lines=%d", apiVersion, *lines), nil)
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		if err := c.printJSON(raw); err != nil {
+			return err
+		}
+	} else {
+		var body struct {
+			Lines []string `json:"lines"`
+		}
+
+		if err := json.Unmarshal(raw, &body); err != nil {
+			return err
+		}
+
+		for _, line := range body.Lines {
+			fmt.Fprintln(c.out, line)
+		}
+	}
+
+	if !*follow {
+		return nil
+	}
+
+	return c.follow(c.out)
+}
+
+// follow prints activity from the server until the connection closes.
+func (c *ctlClient) follow(w io.Writer) error {
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		if c.json {
+			fmt.Fprintln(w, string(msg))
+		} else if line := formatActivity(msg); line != "" {
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// console reads commands from the terminal, while printing server activity.
+func (c *ctlClient) console() error {
+	var (
+		in   = os.Stdin
+		fd   = int(in.Fd())
+		read func() (string, error)
+		out  io.Writer = c.out
+	)
+
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		defer term.Restore(fd, state)
+
+		t := term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{in, os.Stdout}, "> ")
+
+		read, out = t.ReadLine, t
+	} else {
+		s := bufio.NewScanner(in)
+		read = func() (string, error) {
+			if s.Scan() {
+				return s.Text(), nil
+			}
+			if s.Err() != nil {
+				return "", s.Err()
+			}
+			return "", io.EOF
+		}
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- c.follow(out) }()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for {
+			line, err := read()
+			if err != nil {
+				return // EOF, or ctrl-c/ctrl-d
+			}
+			lines <- line
+		}
+	}()
+
+	for {
+		select {
+		case err := <-errs:
+			return err
+		case line, ok := <-lines:
+			if !ok {
+				return nil
+			}
+
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+
+			if _, err := c.request(http.MethodPost, apiVersion+"/server/commands", commandRequest{line}); err != nil {
+				fmt.Fprintf(out, "error: %v\n", err)
+			}
+		}
+	}
+}
+
+// formatActivity converts a websocket message into a line for display.
+func formatActivity(msg []byte) string {
+	var data struct {
+		Output string `json:"output"`
+		Stream string `json:"stream"`
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+		Alert  *struct {
+			Message string `json:"message"`
+		} `json:"alert"`
+	}
+
+	if err := json.Unmarshal(msg, &data); err != nil {
+		return string(msg)
+	}
+
+	switch {
+	case data.Output != "" && data.Stream == pickaxx.Stderr:
+		return pickaxx.StderrPrefix + data.Output
+	case data.Output != "":
+		return data.Output
+	case data.Status != "" && data.Reason != "":
+		return fmt.Sprintf("-- %s (%s)", data.Status, data.Reason)
+	case data.Status != "":
+		return fmt.Sprintf("-- %s", data.Status)
+	case data.Alert != nil:
+		return fmt.Sprintf("!! %s", data.Alert.Message)
+	}
+
+	return ""
+}
+
+func (c *ctlClient) dial() (*websocket.Conn, error) {
+	u := *c.addr
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/ws"
+
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+
+	conn, _, err := c.dialer.Dial(u.String(), c.headers())
+	return conn, err
+}
+
+// post sends a request, printing a message (or the JSON response) on success.
+func (c *ctlClient) post(path string, body interface{}, done string) error {
+	raw, err := c.request(http.MethodPost, path, body)
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.printJSON(raw)
+	}
+
+	fmt.Fprintln(c.out, done)
+	return nil
+}
+
+// request sends a request, returning the response body. Responses other
+// than 2xx are returned as errors.
+func (c *ctlClient) request(method, path string, body interface{}) ([]byte, error) {
+	var reader io.Reader
+
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.addr.String(), "/")+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = c.headers()
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	raw, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return nil, responseError(rsp, raw)
+	}
+
+	return raw, nil
+}
+
+func (c *ctlClient) headers() http.Header {
+	h := http.Header{}
+	if c.token != "" {
+		h.Set("Authorization", "Bearer "+c.token)
+	}
+	return h
+}
+
+func (c *ctlClient) printJSON(raw []byte) error {
+	if len(raw) == 0 {
+		raw = []byte("{}")
+	}
+	_, err := fmt.Fprintln(c.out, string(raw))
+	return err
+}
+
+// responseError extracts an error message from a failed response.
+func responseError(rsp *http.Response, raw []byte) error {
+	var body apiError
+
+	if err := json.Unmarshal(raw, &body); err == nil && body.Error.Message != "" {
+		return fmt.Errorf("%s (%s)", body.Error.Message, rsp.Status)
+	}
+
+	return errors.New(rsp.Status)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtlCommands(t *testing.T) {
+	tests := []struct {
+		args     []string
+		json     bool
+		request  string // method & path expected, if a request is sent
+		body     string // request body expected
+		status   int    // of the response, 200 if not set
+		response string
+		output   string
+		err      string
+	}{
+		{
+			args:     []string{"status"},
+			request:  "GET /api/v1/server",
+			response: `{"state": "Running", "stats": {"cpuPercent": 12.5, "rss": 104857600}, "tick": {"tps": 19.8}}`,
+			output:   "State:  Running\nCPU:    12.5%\nMemory: 100.0 MB\nTPS:    19.8\n",
+		},
+		{
+			args:     []string{"status"},
+			request:  "GET /api/v1/server",
+			response: `{"running": false}`,
+			output:   "State:  Stopped\n",
+		},
+		{
+			args:     []string{"status"},
+			json:     true,
+			request:  "GET /api/v1/server",
+			response: `{"state":"Running"}`,
+			output:   "{\"state\":\"Running\"}\n",
+		},
+		{
+			args:    []string{"start"},
+			request: "POST /api/v1/server/start",
+			output:  "server starting\n",
+		},
+		{
+			args:    []string{"stop", "-countdown", "30", "-save", "-reason", "maintenance"},
+			request: "POST /api/v1/server/stop",
+			body:    `{"countdown":30,"interval":0,"save":true,"timeout":0,"reason":"maintenance"}`,
+			output:  "server stopping\n",
+		},
+		{
+			args:    []string{"restart", "-interval", "10"},
+			request: "POST /api/v1/server/restart",
+			body:    `{"countdown":0,"interval":10,"save":false,"timeout":0,"reason":""}`,
+			output:  "server restarted\n",
+		},
+		{
+			args:    []string{"send", "say", "hello"},
+			request: "POST /api/v1/server/commands",
+			body:    `{"command":"say hello"}`,
+			output:  "command sent\n",
+		},
+		{
+			args:     []string{"send", "list"},
+			request:  "POST /api/v1/server/commands",
+			body:     `{"command":"list"}`,
+			status:   http.StatusConflict,
+			response: `{"error": {"code": "not_running", "message": "server not running"}}`,
+			err:      "server not running (409 Conflict)",
+		},
+		{
+			args:     []string{"logs", "-n", "2"},
+			request:  "GET /api/v1/server/logs?lines=2",
+			response: `{"lines": ["one", "two"]}`,
+			output:   "one\ntwo\n",
+		},
+		{
+			args:     []string{"upgrade", "-force", "0123.jar"},
+			request:  "POST /api/v1/server/upgrade",
+			body:     `{"key":"0123.jar","force":true}`,
+			response: `{"to": {"flavor": "paper", "version": "1.20.1", "javaVersion": 17}}`,
+			output:   "server jar: none -> Paper 1.20.1 (Java 17)\nserver not running; start it to use the new jar\n",
+		},
+		{
+			args:     []string{"rollback", "-world"},
+			request:  "POST /api/v1/server/rollback",
+			body:     `{"restoreWorld":true}`,
+			response: `{"to": {"flavor": "paper", "version": "1.19.4", "javaVersion": 17}, "worldRestored": true, "restarted": true, "running": true}`,
+			output:   "server jar: none -> Paper 1.19.4 (Java 17)\nworld restored from backup\nserver restarted, and is running\n",
+		},
+		{
+			args:     []string{"backup"},
+			request:  "POST /api/v1/server/backups",
+			status:   http.StatusCreated,
+			response: `{"path": "backups/backup-20230801-120000.tar.gz"}`,
+			output:   "backup: backups/backup-20230801-120000.tar.gz\n",
+		},
+		{
+			args:     []string{"health"},
+			request:  "GET /healthz",
+			response: `{"status": "ok", "checks": {"clients": "ok"}}`,
+			output:   "ok\n",
+		},
+		{
+			args:     []string{"health"},
+			request:  "GET /healthz",
+			status:   http.StatusServiceUnavailable,
+			response: `{"status": "failing", "checks": {"clients": "output loop inactive", "server": "ok"}}`,
+			output:   "failing\n",
+			err:      "unhealthy (clients: output loop inactive)",
+		},
+		{
+			args:    []string{"health"},
+			request: "GET /healthz",
+			status:  http.StatusBadGateway,
+			err:     "502 Bad Gateway",
+		},
+		{
+			args: []string{"send"},
+			err:  "usage: send <command>",
+		},
+		{
+			args: []string{"upgrade", "-force"},
+			err:  "usage: upgrade [-force] <key>",
+		},
+		{
+			args: []string{"rollback", "now"},
+			err:  "usage: rollback [-world]",
+		},
+		{
+			args: []string{"stop", "-countdown", "soon"},
+			err:  `invalid value "soon" for flag -countdown: parse error`,
+		},
+		{
+			args: []string{"reload"},
+			err:  "unknown command 'reload'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.request+" "+tt.args[0], func(t *testing.T) {
+			var request, body, auth string
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				request, body, auth = r.Method+" "+r.URL.RequestURI(), string(b), r.Header.Get("Authorization")
+
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			addr, _ := url.Parse(srv.URL)
+			out := &bytes.Buffer{}
+
+			c := &ctlClient{addr: addr, token: "secret", json: tt.json, out: out, client: srv.Client()}
+			err := c.run(tt.args[0], tt.args[1:])
+
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.err, err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.request, request)
+			assert.Equal(t, tt.body, body)
+			assert.Equal(t, tt.output, out.String())
+
+			if tt.request != "" {
+				assert.Equal(t, "Bearer secret", auth)
+			}
+		})
+	}
+}
+
+func TestFormatActivity(t *testing.T) {
+	tests := []struct {
+		msg      string
+		expected string
+	}{
+		{`{"output": "Done (5.2s)!", "stream": "stdout"}`, "Done (5.2s)!"},
+		{`{"output": "Server is starting"}`, "Server is starting"},
+		{`{"output": "java.lang.OutOfMemoryError", "stream": "stderr"}`, "[stderr] java.lang.OutOfMemoryError"},
+		{`{"status": "Stopping", "reason": "update"}`, "-- Stopping (update)"},
+		{`{"alert": {"message": "low TPS"}}`, "!! low TPS"},
+		{`{"stats": {"cpuPercent": 12.5}}`, ""},
+		{`not json`, "not json"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, formatActivity([]byte(tt.msg)), tt.msg)
+	}
+}
+
+func TestNewConsoleLine(t *testing.T) {
+	assert.Equal(t, consoleLine{"Done", false}, newConsoleLine("Done"))
+	assert.Equal(t, consoleLine{"Exception", true}, newConsoleLine("[stderr] Exception"))
+}
+
+func TestFormatUpgrade(t *testing.T) {
+	res := pickaxx.UpgradeResult{
+		From:      &pickaxx.JarInfo{Flavor: "paper", Version: "1.19.4", JavaVersion: 17},
+		To:        &pickaxx.JarInfo{Flavor: "paper", Version: "1.20.1", JavaVersion: 17},
+		Backup:    "backups/pre-upgrade.tar.gz",
+		Restarted: true,
+		Error:     "server failed to start",
+	}
+
+	assert.Equal(t, "server jar: Paper 1.19.4 (Java 17) -> Paper 1.20.1 (Java 17)\n"+
+		"backup: backups/pre-upgrade.tar.gz\n"+
+		"server did not start: server failed to start", formatUpgrade(res))
+
+	res.From, res.Backup, res.Restarted = nil, "", false
+	assert.Equal(t, "server jar: none -> Paper 1.20.1 (Java 17)\n"+
+		"server not running; start it to use the new jar", formatUpgrade(res))
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// pidFile is a file holding the PID of this process, preventing more than
+// one instance from running with the same file.
+type pidFile struct {
+	path string
+}
+
+// createPIDFile writes the PID of this process to path. If the file exists,
+// and names a running process, an error is returned. A file naming a process
+// which no longer exists is considered stale, and replaced.
+func createPIDFile(path string) (*pidFile, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+
+		if errors.Is(err, os.ErrExist) {
+			pid, running := readPIDFile(path)
+			if running {
+				return nil, fmt.Errorf("already running (pid %d, from %s)", pid, path)
+			}
+
+			// stale; remove & try again
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+
+		if err != nil {
+			os.Remove(path)
+			return nil, err
+		}
+
+		return &pidFile{path}, nil
+	}
+
+	return nil, fmt.Errorf("unable to create %s", path)
+}
+
+// readPIDFile returns the PID in a file, and true if that process is running.
+func readPIDFile(path string) (int, bool) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+
+	return pid, processExists(pid)
+}
+
+// processExists returns true if a process with the given PID exists.
+func processExists(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM) // EPERM: exists, owned by another user
+}
+
+// Remove deletes the PID file, if it still names this process.
+func (p *pidFile) Remove() error {
+	if pid, _ := readPIDFile(p.path); pid != os.Getpid() {
+		return nil
+	}
+	return os.Remove(p.path)
+}
+
+// reopenableFile is a log file which can be reopened (e.g. after being
+// rotated by logrotate). This implementation can be accessed concurrently
+// by multiple goroutines.
+type reopenableFile struct {
+	sync.Mutex
+	path string
+	file *os.File
+}
+
+func openLogFile(path string) (*reopenableFile, error) {
+	f := &reopenableFile{path: path}
+	return f, f.Reopen()
+}
+
+// Reopen closes the file, and opens it again at the same path.
+func (f *reopenableFile) Reopen() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file = file
+
+	return nil
+}
+
+func (f *reopenableFile) Write(p []byte) (int, error) {
+	f.Lock()
+	defer f.Unlock()
+	return f.file.Write(p)
+}
+
+// Close closes the underlying file.
+func (f *reopenableFile) Close() error {
+	f.Lock()
+	defer f.Unlock()
+	return f.file.Close()
+}
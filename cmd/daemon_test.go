@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pickaxx.pid")
+
+	pid, err := createPIDFile(path)
+	require.NoError(t, err)
+
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), strings.TrimSpace(string(content)))
+
+	// this process is running, so a second instance is refused
+	_, err = createPIDFile(path)
+	assert.Error(t, err)
+
+	require.NoError(t, pid.Remove())
+	assert.NoFileExists(t, path)
+}
+
+func TestCreatePIDFileStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pickaxx.pid")
+
+	// PIDs are limited to 2^22 on Linux, so this can't be running
+	require.NoError(t, ioutil.WriteFile(path, []byte(fmt.Sprintf("%d\n", 1<<30)), 0644))
+
+	_, err := createPIDFile(path)
+	require.NoError(t, err)
+
+	pid, running := readPIDFile(path)
+	assert.Equal(t, os.Getpid(), pid)
+	assert.True(t, running)
+}
+
+func TestReopenableFile(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		path    = filepath.Join(dir, "pickaxx.log")
+		rotated = filepath.Join(dir, "pickaxx.log.1")
+	)
+
+	f, err := openLogFile(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	f.Write([]byte("before\n"))
+	require.NoError(t, os.Rename(path, rotated))
+
+	f.Write([]byte("rotated\n"))
+	require.NoError(t, f.Reopen())
+	f.Write([]byte("after\n"))
+
+	old, _ := ioutil.ReadFile(rotated)
+	current, _ := ioutil.ReadFile(path)
+
+	assert.Equal(t, "before\nrotated\n", string(old))
+	assert.Equal(t, "after\n", string(current))
+}
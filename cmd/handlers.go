@@ -7,11 +7,12 @@ import (
 	"html/template"
 	"io"
 	"os"
-	"path/filepath"
 
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/gin-gonic/gin"
@@ -26,9 +27,13 @@ var upgrader = websocket.Upgrader{
 }
 
 type processHandler struct {
+	manager  pickaxx.ProcessManager
+	writer   io.Writer
+	webhooks *pickaxx.WebhookDispatcher
+
+	// console output of the server, replaced each time it starts
+	logLock sync.RWMutex
 	logFile *os.File
-	manager pickaxx.ProcessManager
-	writer  io.Writer
 }
 
 // newlineWriter is a writer that inserts '\n' newlines after each call.
@@ -43,42 +48,82 @@ func (w *newlineWriter) Write(p []byte) (n int, err error) {
 	return w.wrapped.Write([]byte("\n"))
 }
 
-// monitor output coming from a process by sending it where it needs to go.
-func (h *processHandler) monitor(ch <-chan pickaxx.Data) error {
-	var (
-		err error
-	)
+// consoleLine is a line of console output, as displayed.
+type consoleLine struct {
+	Text   string
+	Stderr bool // highlighted
+}
 
-	if h.logFile != nil {
-		log.Warn("processHandler already monitoring activity?")
+// newConsoleLine parses a line of console output, as persisted.
+func newConsoleLine(line string) consoleLine {
+	if strings.HasPrefix(line, pickaxx.StderrPrefix) {
+		return consoleLine{strings.TrimPrefix(line, pickaxx.StderrPrefix), true}
 	}
+	return consoleLine{line, false}
+}
 
+// monitor output coming from a process by sending it where it needs to go.
+func (h *processHandler) monitor(ch <-chan pickaxx.Data) error {
 	// set up log file
-	if h.logFile, err = ioutil.TempFile(os.TempDir(), fmt.Sprintf("pickaxx_%d", minecraft.DefaultPort)); err != nil {
+	logFile, err := ioutil.TempFile(os.TempDir(), fmt.Sprintf("pickaxx_%d", minecraft.DefaultPort))
+	if err != nil {
 		return err
 	}
 
+	h.logLock.Lock()
+	if h.logFile != nil {
+		log.Warn("processHandler already monitoring activity?")
+	}
+	h.logFile = logFile
+	h.logLock.Unlock()
+
 	// create a new routine to funnel output where it needs to go
 	go func() {
 		enc := json.NewEncoder(h.writer)
-		w := &newlineWriter{h.logFile}
+		w := &newlineWriter{logFile}
 
 		for newData := range ch {
 			if val, ok := newData.(pickaxx.ConsoleData); ok {
 				io.WriteString(w, val.String())
 			}
 			enc.Encode(newData)
+
+			if h.webhooks != nil {
+				h.webhooks.Dispatch(newData)
+			}
 		}
 	}()
 
 	return nil
 }
 
+// logPath returns the path of the console log, or "" if there is none.
+func (h *processHandler) logPath() string {
+	h.logLock.RLock()
+	defer h.logLock.RUnlock()
+
+	if h.logFile == nil {
+		return ""
+	}
+	return h.logFile.Name()
+}
+
+// publisher returns a function sending events from outside of the server
+// process (which monitor does not see) to clients, and webhooks.
+func publisher(w io.Writer, webhooks *pickaxx.WebhookDispatcher) func(pickaxx.Data) {
+	return func(d pickaxx.Data) {
+		json.NewEncoder(w).Encode(d)
+		webhooks.Dispatch(d)
+	}
+}
+
 func (h *processHandler) rootHandler(c *gin.Context) {
 	var (
 		manager = h.manager
-		lines   []string
+		lines   []consoleLine
 		status  string
+		command string
+		jar     string
 	)
 
 	if manager.Running() {
@@ -86,8 +131,22 @@ func (h *processHandler) rootHandler(c *gin.Context) {
 		status = "Running"
 
 		// set recent activity
-		content, _ := ioutil.ReadFile(h.logFile.Name())
-		lines = strings.Split(string(content), "\n")
+		content, _ := ioutil.ReadFile(h.logPath())
+
+		for _, line := range strings.Split(string(content), "\n") {
+			lines = append(lines, newConsoleLine(line))
+		}
+	} else if reporter, ok := manager.(pickaxx.CommandReporter); ok {
+		// shown before starting
+		if args, err := reporter.CommandLine(); err == nil {
+			command = strings.Join(args, " ")
+		}
+	}
+
+	if reporter, ok := manager.(pickaxx.JarReporter); ok {
+		if info, err := reporter.JarInfo(); err == nil {
+			jar = minecraft.DescribeJar(info)
+		}
 	}
 
 	html, err := tmpls.FindString("index.html")
@@ -103,6 +162,8 @@ func (h *processHandler) rootHandler(c *gin.Context) {
 	err = t.ExecuteTemplate(c.Writer, "", gin.H{
 		"logLines": lines,
 		"status":   status,
+		"command":  command,
+		"jar":      jar,
 	})
 
 	if err != nil {
@@ -119,13 +180,20 @@ func (h *processHandler) startServerHandler(c *gin.Context) {
 	activity, err := manager.Start()
 
 	if err != nil {
-		var status int
-		var message string
-
-		if errors.Is(err, pickaxx.ErrProcessExists) {
+		var (
+			status  int
+			message string
+			pf      *pickaxx.PreflightError
+		)
+
+		switch {
+		case errors.Is(err, pickaxx.ErrProcessExists):
 			status = http.StatusBadRequest
 			message = "server already running"
-		} else {
+		case errors.As(err, &pf):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"err": "server can not be started", "checks": pf.Failed})
+			return
+		default:
 			status = http.StatusInternalServerError
 			message = "failed to start server"
 		}
@@ -135,14 +203,10 @@ func (h *processHandler) startServerHandler(c *gin.Context) {
 	}
 
 	h.monitor(activity)
+	c.JSON(http.StatusOK, gin.H{"output": "server starting"})
 }
 
 func (h *processHandler) createServerHandler(c *gin.Context) {
-	var (
-		tempFile *os.File
-		err      error
-	)
-
 	file, err := c.FormFile("file")
 
 	if err != nil {
@@ -150,38 +214,102 @@ func (h *processHandler) createServerHandler(c *gin.Context) {
 		return
 	}
 
-	if file.Header.Get("Content-Type") != "application/java-archive" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"err": "unsupported file type"})
-		return
-	}
-
-	ext := filepath.Ext(file.Filename)
-	filename := strings.TrimSuffix(file.Filename, ext)
+	key, err := uploads.Stage(c, file)
 
-	if tempFile, err = ioutil.TempFile("", fmt.Sprintf("%s-*%s", filename, ext)); err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"err": "unable to write save file"})
+	switch {
+	case errors.Is(err, errUnsupportedFile):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"err": err.Error()})
 		return
-	}
-
-	if err := c.SaveUploadedFile(file, tempFile.Name()); err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"err": "unable to save file"})
+	case err != nil:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"output": "file is staged",
-		"key":    filepath.Base(tempFile.Name()),
+		"key":    key,
 	})
 }
 
+// stopRequest holds optional parameters for stopping a server.
+type stopRequest struct {
+	Countdown int    `json:"countdown"` // seconds to warn players before stopping
+	Interval  int    `json:"interval"`  // seconds between each warning
+	Save      bool   `json:"save"`      // save the world before stopping
+	Timeout   int    `json:"timeout"`   // seconds before the process is killed
+	Reason    string `json:"reason"`    // shown to players
+}
+
+func (r stopRequest) options() []pickaxx.StopOption {
+	opts := []pickaxx.StopOption{
+		pickaxx.WithCountdown(time.Duration(r.Countdown)*time.Second, time.Duration(r.Interval)*time.Second),
+		pickaxx.WithKillTimeout(time.Duration(r.Timeout) * time.Second),
+		pickaxx.WithReason(r.Reason),
+	}
+
+	if r.Save {
+		opts = append(opts, pickaxx.WithSave())
+	}
+
+	return opts
+}
+
+// shouldBindOptionalJSON binds a JSON request body, if one was sent. Bodies
+// of unknown length (e.g. chunked) are read, and are empty if at EOF.
+func shouldBindOptionalJSON(c *gin.Context, obj interface{}) error {
+	if c.Request.ContentLength == 0 {
+		return nil
+	}
+
+	if err := c.ShouldBindJSON(obj); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
 func (h *processHandler) stopServerHandler(c *gin.Context) {
 	var (
 		manager = h.manager
+		req     stopRequest
 	)
 
-	if err := manager.Stop(); err != nil {
+	if err := shouldBindOptionalJSON(c, &req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"err": "invalid stop request"})
+		return
+	}
+
+	if err := manager.Stop(req.options()...); err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
 	}
+
+	c.JSON(http.StatusOK, gin.H{"output": "server stopping"})
+}
+
+func (h *processHandler) restartServerHandler(c *gin.Context) {
+	var (
+		manager = h.manager
+		req     stopRequest
+	)
+
+	if err := shouldBindOptionalJSON(c, &req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"err": "invalid restart request"})
+		return
+	}
+
+	if !manager.Running() {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"err": "server not running"})
+		return
+	}
+
+	if err := manager.Restart(req.options()...); err != nil {
+		log.WithError(err).Error("restart failed")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"output": "server restarted"})
 }
 
 func (h *processHandler) sendHandler(c *gin.Context) {
@@ -210,6 +338,56 @@ func (h *processHandler) sendHandler(c *gin.Context) {
 	}
 }
 
+func (h *processHandler) statsHandler(c *gin.Context) {
+	reporter, ok := h.manager.(pickaxx.StatsReporter)
+
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"err": "stats not supported"})
+		return
+	}
+
+	var (
+		history = reporter.Stats()
+		latest  *pickaxx.ProcessStats
+	)
+
+	if len(history) > 0 {
+		latest = &history[len(history)-1]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"latest":  latest,
+		"history": history,
+	})
+}
+
+func (h *processHandler) performanceHandler(c *gin.Context) {
+	reporter, ok := h.manager.(pickaxx.PerformanceReporter)
+
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"err": "performance not supported"})
+		return
+	}
+
+	var (
+		history = reporter.Performance()
+		latest  *pickaxx.TickStats
+	)
+
+	if len(history) > 0 {
+		latest = &history[len(history)-1]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"latest":  latest,
+		"history": history,
+	})
+}
+
+func (h *processHandler) webhookDeliveriesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"deliveries": h.webhooks.Deliveries()})
+}
+
 type clientHandler struct {
 	manager *pickaxx.ClientManager
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ivan3bx/pickaxx"
+)
+
+type healthHandler struct {
+	checkers map[string]pickaxx.HealthChecker
+	manager  pickaxx.ProcessManager
+}
+
+// healthz reports whether pickaxx itself is functioning. Responding at all
+// shows the process is up & the web server is serving.
+func (h *healthHandler) healthz(c *gin.Context) {
+	h.respond(c, func(hc pickaxx.HealthChecker) error { return hc.Healthy() })
+}
+
+// readyz reports whether pickaxx is able to manage servers.
+func (h *healthHandler) readyz(c *gin.Context) {
+	h.respond(c, func(hc pickaxx.HealthChecker) error { return hc.Ready() })
+}
+
+// serverHealth reports whether the managed server is running & responsive.
+func (h *healthHandler) serverHealth(c *gin.Context) {
+	reporter, ok := h.manager.(pickaxx.HealthReporter)
+
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"err": "health not supported"})
+		return
+	}
+
+	health := reporter.Health()
+	status := http.StatusOK
+
+	if !health.Healthy {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, health)
+}
+
+func (h *healthHandler) respond(c *gin.Context, check func(pickaxx.HealthChecker) error) {
+	var (
+		status = http.StatusOK
+		checks = gin.H{}
+	)
+
+	for name, hc := range h.checkers {
+		if err := check(hc); err != nil {
+			status = http.StatusServiceUnavailable
+			checks[name] = err.Error()
+		} else {
+			checks[name] = "ok"
+		}
+	}
+
+	body := gin.H{"status": "ok", "checks": checks}
+
+	if status != http.StatusOK {
+		body["status"] = "failing"
+	}
+
+	if reporter, ok := h.manager.(pickaxx.HealthReporter); ok {
+		body["server"] = reporter.Health()
+	}
+
+	c.JSON(status, body)
+}
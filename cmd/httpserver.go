@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -17,21 +19,56 @@ var (
 
 func newRouter() *gin.Engine {
 	e := gin.New()
-	e.Use(gin.Logger(), gin.Recovery())
+	e.Use(gin.LoggerWithFormatter(logFormatter), gin.Recovery(), recordLatency())
 
 	e.StaticFS("/assets", assets)
 	return e
 }
 
-func startWebServer(e http.Handler) *http.Server {
+// logFormatter formats requests as gin does by default, but without the
+// token of requests authenticated with a query parameter.
+func logFormatter(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		redactToken(param.Path),
+		param.ErrorMessage,
+	)
+}
+
+// startWebServer serves requests on the given address, over HTTPS if tlsConfig is not nil.
+func startWebServer(e http.Handler, addr string, tlsConfig *tls.Config) *http.Server {
 	srv := &http.Server{
-		Addr:    "127.0.0.1:8080",
-		Handler: e,
+		Addr:      addr,
+		Handler:   e,
+		TLSConfig: tlsConfig,
 	}
 
 	go func() {
-		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-			log.Fatalf("server failed: %w", err)
+		var err error
+
+		if tlsConfig != nil {
+			err = srv.ListenAndServeTLS("", "") // certificates are provided by TLSConfig
+		} else {
+			err = srv.ListenAndServe()
+		}
+
+		if err != http.ErrServerClosed {
+			log.Fatalf("server failed: %v", err)
 		}
 	}()
 
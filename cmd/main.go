@@ -1,69 +1,281 @@
 package main
 
 import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
 	"syscall"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/cli"
+	"github.com/apex/log/handlers/json"
+	"github.com/gin-gonic/gin"
 	"github.com/ivan3bx/pickaxx"
-	"github.com/ivan3bx/pickaxx/minecraft"
+	"github.com/ivan3bx/pickaxx/gameserver"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "ctl":
+			os.Exit(runCtl(os.Args[2:]))
+		case "tui":
+			os.Exit(runTUI(os.Args[2:]))
+		}
+	}
+
 	var (
 		clientMgr  *pickaxx.ClientManager = &pickaxx.ClientManager{}
-		processMgr pickaxx.ProcessManager = minecraft.New(minecraft.DefaultPort)
+		processMgr pickaxx.ProcessManager
+		configFile string
+		pidPath    string
+		logPath    string
+		logFile    *reopenableFile
+	)
+
+	flag.StringVar(&configFile, "config", defaultConfigFile, "path to configuration file")
+	flag.StringVar(&pidPath, "pidfile", "", "write the process ID to this file")
+	flag.StringVar(&logPath, "log", "", "write logs to this file as JSON, instead of the terminal (reopened on SIGUSR1)")
+	flag.Parse()
+
+	if logPath != "" {
+		var err error
+		if logFile, err = openLogFile(logPath); err != nil {
+			log.WithError(err).Fatal("unable to open log file")
+		}
+		configureLogging(log.DebugLevel, logFile)
+	} else {
+		configureLogging(log.DebugLevel, nil)
+	}
+
+	if pidPath != "" {
+		pid, err := createPIDFile(pidPath)
+		if err != nil {
+			log.WithError(err).Fatal("unable to create pid file")
+		}
+		defer pid.Remove()
+	}
+
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		log.WithError(err).Fatal("unable to load configuration")
+	}
+
+	opts, err := cfg.serverOptions()
+	if err != nil {
+		log.WithError(err).Fatal("invalid server configuration")
+	}
+
+	var (
+		webhooks = pickaxx.NewWebhookDispatcher(cfg.Webhooks)
+		token    = newAPIToken(cfg.Token)
 	)
 
-	configureLogging(log.DebugLevel)
+	// events from outside the server process (e.g. backups)
+	opts = append(opts, gameserver.WithEvents(publisher(clientMgr, webhooks)))
+
+	processMgr = gameserver.New(cfg.Server.Port, opts...)
+
+	// leave the server running on exit, if configured
+	var detacher pickaxx.Detacher
+	if cfg.Detach {
+		d, ok := processMgr.(pickaxx.Detacher)
+		if !ok {
+			log.Fatal("server does not support detaching")
+		}
+		detacher = d
+	}
 
 	e := newRouter()
+	api := e.Group("/", requireToken(token))
 
 	// routes: process handling
 	ph := processHandler{
-		manager: processMgr,
-		writer:  clientMgr,
+		manager:  processMgr,
+		writer:   clientMgr,
+		webhooks: webhooks,
 	}
 	{
-		e.GET("/", ph.rootHandler)
-		e.POST("/start", ph.startServerHandler)
-		e.POST("/stop", ph.stopServerHandler)
-		e.POST("/server", ph.createServerHandler)
-		e.POST("/send", ph.sendHandler)
+		api.GET("/", ph.rootHandler)
+		api.POST("/start", ph.startServerHandler)
+		api.POST("/stop", ph.stopServerHandler)
+		api.POST("/restart", ph.restartServerHandler)
+		api.POST("/server", ph.createServerHandler)
+		api.POST("/send", ph.sendHandler)
+		api.GET("/stats", ph.statsHandler)
+		api.GET("/performance", ph.performanceHandler)
+		api.GET("/webhooks/deliveries", ph.webhookDeliveriesHandler)
+	}
 
+	// routes: versioned API
+	v1 := apiHandler{&ph}
+	{
+		v1.register(e.Group(apiVersion, requireToken(token)))
 	}
 
 	// routes: client handling
 	ch := clientHandler{clientMgr}
 	{
-		e.GET("/ws", ch.webSocketHandler)
+		api.GET("/ws", ch.webSocketHandler)
+	}
+
+	// routes: metrics
+	mh := metricsHandler{[]pickaxx.MetricsCollector{clientMgr, requestLatency}}
+	if c, ok := processMgr.(pickaxx.MetricsCollector); ok {
+		mh.collectors = append(mh.collectors, c)
+	}
+	{
+		api.GET("/metrics", mh.metrics)
+	}
+
+	// routes: health
+	hh := healthHandler{
+		checkers: map[string]pickaxx.HealthChecker{"clients": clientMgr},
+		manager:  processMgr,
+	}
+	if hc, ok := processMgr.(pickaxx.HealthChecker); ok {
+		hh.checkers["server"] = hc
+	}
+	{
+		e.GET("/healthz", hh.healthz)
+		e.GET("/readyz", hh.readyz)
+		e.GET("/healthz/server", hh.serverHealth)
 	}
 
 	// Start the web server
-	srv := startWebServer(e)
+	var tlsConfig *tls.Config
+	if cfg.TLS != nil {
+		if tlsConfig, err = loadTLS(cfg.TLS, cfg.Listen); err != nil {
+			log.WithError(err).Fatal("unable to configure TLS")
+		}
+	}
+
+	servers := []*http.Server{startWebServer(e, cfg.Listen, tlsConfig)}
+
+	if cfg.TLS != nil && cfg.TLS.Redirect != "" {
+		servers = append(servers, startWebServer(redirectHandler(cfg.Listen), cfg.TLS.Redirect, nil))
+	}
+
+	// adopt a server left running by a previous instance
+	if d, ok := processMgr.(pickaxx.Detacher); ok {
+		if activity, err := d.Reattach(); err != nil {
+			log.WithError(err).Warn("unable to reattach to server")
+		} else if activity != nil {
+			log.Info("reattached to running server")
+			ph.monitor(activity)
+		}
+	}
+
+	// reload on SIGHUP, reopen logs on SIGUSR1, shutdown on interrupt
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1)
 
-	// shutdown on interrupt
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	for sig := range signals {
+		if sig == syscall.SIGHUP {
+			if next, err := loadConfig(configFile); err != nil {
+				log.WithError(err).Error("unable to reload configuration")
+			} else {
+				cfg = reloadConfig(cfg, next, token, webhooks, processMgr)
+			}
+			continue
+		}
+
+		if sig == syscall.SIGUSR1 {
+			if logFile != nil {
+				if err := logFile.Reopen(); err != nil {
+					log.WithError(err).Error("unable to reopen log file")
+				}
+			}
+			continue
+		}
+
+		break
+	}
 
-	<-quit
 	log.Debug("shutdown initiated")
 	{
-		stopWebServer(srv)
-		stopProcesses(processMgr)
+		for _, srv := range servers {
+			stopWebServer(srv)
+		}
+		stopProcesses(processMgr, detacher)
 		stopClientManager(clientMgr)
+		webhooks.Close()
+		uploads.Close()
 	}
 	log.Info("shutdown complete")
 }
 
-func configureLogging(level log.Level) {
+// configureLogging writes logs to w, or the terminal if w is nil.
+func configureLogging(level log.Level, w io.Writer) {
 	log.SetLevel(level)
-	log.SetHandler(cli.Default)
+
+	if w == nil {
+		log.SetHandler(cli.Default)
+		return
+	}
+
+	log.SetHandler(json.New(w))
+	gin.DefaultWriter, gin.DefaultErrorWriter = w, w
+}
+
+// reloadConfig applies settings which can change while running, returning
+// the configuration now in effect.
+func reloadConfig(current, next *config, token *apiToken, webhooks *pickaxx.WebhookDispatcher, m pickaxx.ProcessManager) *config {
+	token.Set(next.Token)
+	webhooks.Configure(next.Webhooks)
+
+	if next.Listen != current.Listen || !reflect.DeepEqual(next.TLS, current.TLS) || next.Detach != current.Detach {
+		log.Warn("changes to listen address, tls & detach take effect after restarting pickaxx")
+		next.Listen, next.TLS, next.Detach = current.Listen, current.TLS, current.Detach
+	}
+
+	if !reflect.DeepEqual(next.Server, current.Server) || !reflect.DeepEqual(next.Types, current.Types) || !reflect.DeepEqual(next.JavaDirs, current.JavaDirs) {
+		if err := reconfigureServer(m, next); err != nil {
+			log.WithError(err).Error("server settings not changed")
+			next.Server, next.Types, next.JavaDirs = current.Server, current.Types, current.JavaDirs
+		} else {
+			log.Info("server settings take effect when the server next starts")
+		}
+	}
+
+	log.Info("configuration reloaded")
+	return next
+}
+
+// reconfigureServer applies the server settings of cfg to the process
+// manager, for the next time the server starts.
+func reconfigureServer(m pickaxx.ProcessManager, cfg *config) error {
+	r, ok := m.(gameserver.Reconfigurable)
+	if !ok {
+		return errors.New("server does not support reconfiguring")
+	}
+
+	opts, err := cfg.serverOptions()
+	if err != nil {
+		return err
+	}
+
+	r.Reconfigure(cfg.Server.Port, opts...)
+	return nil
 }
 
-func stopProcesses(m pickaxx.ProcessManager) {
+// stopProcesses stops the server, or leaves it running if d is not nil.
+// The server is stopped if detaching fails.
+func stopProcesses(m pickaxx.ProcessManager, d pickaxx.Detacher) {
+	if d != nil && m.Running() {
+		if err := d.Detach(); err != nil {
+			log.WithError(err).Error("unable to detach from server")
+		} else {
+			log.Info("server left running")
+			return
+		}
+	}
+
 	m.Stop()
 }
 
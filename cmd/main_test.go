@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubDetacher is a stubManager which can be detached from.
+type stubDetacher struct {
+	stubManager
+	err      error
+	detached bool
+}
+
+func (m *stubDetacher) Detach() error {
+	if m.err != nil {
+		return m.err
+	}
+	m.detached, m.running = true, false
+	return nil
+}
+
+func (m *stubDetacher) Reattach() (<-chan pickaxx.Data, error) { return nil, nil }
+
+func TestStopProcesses(t *testing.T) {
+	tests := []struct {
+		name     string
+		running  bool
+		detach   bool
+		err      error
+		detached bool
+	}{
+		{name: "stopped", running: true},
+		{name: "detached", running: true, detach: true, detached: true},
+		{name: "not running", detach: true},
+		{name: "detach fails", running: true, detach: true, err: errors.New("server was not started detached")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &stubDetacher{stubManager: stubManager{running: tt.running}, err: tt.err}
+
+			var d pickaxx.Detacher
+			if tt.detach {
+				d = m
+			}
+
+			stopProcesses(m, d)
+
+			assert.Equal(t, tt.detached, m.detached)
+			assert.False(t, m.running, "server is either stopped or detached")
+		})
+	}
+}
+
+// stubReconfigurable records the settings given to Reconfigure.
+type stubReconfigurable struct {
+	stubManager
+	port int
+	opts []gameserver.Option
+}
+
+func (m *stubReconfigurable) Reconfigure(port int, opts ...gameserver.Option) {
+	m.port, m.opts = port, opts
+}
+
+func TestReloadConfig(t *testing.T) {
+	current := &config{Listen: ":8080", Token: "old", Detach: false}
+	current.Server.Port = 25565
+
+	next := &config{Listen: ":9090", Token: "new", Detach: true}
+	next.Server.Port = 25566
+	next.Server.Java = "17"
+
+	token := newAPIToken(current.Token)
+	webhooks := pickaxx.NewWebhookDispatcher(nil)
+	defer webhooks.Close()
+
+	m := &stubReconfigurable{}
+	cfg := reloadConfig(current, next, token, webhooks, m)
+
+	assert.Equal(t, "new", token.Get())
+	assert.Equal(t, "new", cfg.Token)
+
+	// server settings apply when the server next starts
+	assert.Equal(t, 25566, cfg.Server.Port)
+	assert.Equal(t, 25566, m.port)
+	assert.Len(t, m.opts, 2, "type & java")
+
+	// these are only read as pickaxx starts
+	assert.Equal(t, ":8080", cfg.Listen)
+	assert.False(t, cfg.Detach, "servers already started are not detachable")
+}
+
+func TestReloadConfigInvalidServer(t *testing.T) {
+	current := &config{}
+	next := &config{}
+	next.Server.Type = "unknown"
+
+	m := &stubReconfigurable{}
+	webhooks := pickaxx.NewWebhookDispatcher(nil)
+	defer webhooks.Close()
+
+	cfg := reloadConfig(current, next, newAPIToken(""), webhooks, m)
+
+	assert.Empty(t, cfg.Server.Type, "invalid server settings are not applied")
+	assert.Nil(t, m.opts)
+
+	// nor are settings for managers which can't be reconfigured
+	cfg = reloadConfig(current, &config{Server: serverConfig{Port: 25566}}, newAPIToken(""), webhooks, &stubManager{})
+	assert.Zero(t, cfg.Server.Port)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ivan3bx/pickaxx"
+)
+
+// requestLatency tracks latency of HTTP requests handled by the router.
+var requestLatency = &pickaxx.LatencyHistogram{
+	Name: "pickaxx_http_request_duration_seconds",
+	Help: "Latency of HTTP requests.",
+}
+
+// recordLatency is middleware that records latency of each request.
+func recordLatency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		path := c.FullPath()
+		if path == "" {
+			path = "unmatched" // avoid unbounded label values
+		}
+
+		requestLatency.Observe(time.Since(start), pickaxx.Labels{
+			"method": c.Request.Method,
+			"path":   path,
+			"status": strconv.Itoa(c.Writer.Status()),
+		})
+	}
+}
+
+type metricsHandler struct {
+	collectors []pickaxx.MetricsCollector
+}
+
+func (h *metricsHandler) metrics(c *gin.Context) {
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if err := pickaxx.WriteMetrics(c.Writer, h.collectors...); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openAPIVersion is the version of the OpenAPI specification generated.
+const openAPIVersion = "3.0.3"
+
+// schema is a JSON schema object, as used by OpenAPI.
+type schema map[string]interface{}
+
+// openAPISpec generates an OpenAPI document describing the given routes.
+// Request & response schemas are derived from the types in each route.
+func openAPISpec(prefix string, routes []apiRoute) map[string]interface{} {
+	var (
+		components = map[string]schema{}
+		paths      = map[string]map[string]interface{}{}
+		errorRef   = schemaFor(reflect.TypeOf(apiError{}), components)
+	)
+
+	for _, r := range routes {
+		path := prefix + r.Path
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+
+		op := map[string]interface{}{
+			"summary":     r.Summary,
+			"operationId": operationID(r),
+			"security":    []map[string][]string{{"bearer": {}}},
+		}
+
+		responses := map[string]interface{}{
+			strconv.Itoa(r.Status): response(http.StatusText(r.Status), schemaFor(reflect.TypeOf(r.Response), components)),
+			"401":                  response(http.StatusText(http.StatusUnauthorized), errorRef),
+		}
+
+		for _, status := range r.ErrorStatus {
+			responses[strconv.Itoa(status)] = response(http.StatusText(status), errorRef)
+		}
+		op["responses"] = responses
+
+		if len(r.Query) > 0 {
+			params := []map[string]interface{}{}
+			for _, p := range r.Query {
+				params = append(params, map[string]interface{}{
+					"name":        p.Name,
+					"in":          "query",
+					"description": p.Description,
+					"schema":      schema{"type": p.Type},
+				})
+			}
+			op["parameters"] = params
+		}
+
+		switch {
+		case r.Request != nil:
+			op["requestBody"] = map[string]interface{}{
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemaFor(reflect.TypeOf(r.Request), components)},
+				},
+			}
+		case r.Upload:
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"multipart/form-data": map[string]interface{}{
+						"schema": schema{
+							"type":       "object",
+							"required":   []string{"file"},
+							"properties": map[string]schema{"file": {"type": "string", "format": "binary"}},
+						},
+					},
+				},
+			}
+		}
+
+		paths[path][strings.ToLower(r.Method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]string{
+			"title":   "pickaxx",
+			"version": strings.TrimPrefix(prefix, "/api/"),
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": components,
+			"securitySchemes": map[string]schema{
+				"bearer": {"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// operationID derives an identifier from a route, e.g. "postServerStart".
+func operationID(r apiRoute) string {
+	id := strings.ToLower(r.Method)
+
+	for _, part := range strings.FieldsFunc(r.Path, func(c rune) bool { return c == '/' || c == '-' }) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+
+	return id
+}
+
+func response(description string, s schema) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": s},
+		},
+	}
+}
+
+func ref(name string) schema {
+	return schema{"$ref": "#/components/schemas/" + name}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the schema of a type. Named struct types are added to
+// components, and referenced.
+func schemaFor(t reflect.Type, components map[string]schema) schema {
+	switch {
+	case t == timeType:
+		return schema{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		return schemaFor(t.Elem(), components)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return schema{"type": "array", "items": schemaFor(t.Elem(), components)}
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": schemaFor(t.Elem(), components)}
+	case reflect.Struct:
+		name := componentName(t)
+
+		if _, ok := components[name]; !ok {
+			components[name] = nil // placeholder, in case of recursive types
+			components[name] = structSchema(t, components)
+		}
+
+		return ref(name)
+	}
+
+	return schema{} // any value
+}
+
+func structSchema(t reflect.Type, components map[string]schema) schema {
+	props := map[string]schema{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.PkgPath != "" && !f.Anonymous {
+			continue // unexported
+		}
+
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+
+		props[name] = schemaFor(f.Type, components)
+	}
+
+	return schema{"type": "object", "properties": props}
+}
+
+// componentName returns the name of a type in components, e.g. "Server" for serverResource.
+func componentName(t reflect.Type) string {
+	name := strings.TrimPrefix(strings.TrimSuffix(t.Name(), "Resource"), "api")
+
+	if name == "" {
+		return "Object"
+	}
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
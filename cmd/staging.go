@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errUnsupportedFile is returned when an uploaded file is not a jar.
+var errUnsupportedFile = errors.New("unsupported file type")
+
+// stagingKey is the form of the keys of staged jars.
+var stagingKey = regexp.MustCompile(`^[0-9a-f]{32}\.jar$`)
+
+// uploads holds uploaded jars until they are used.
+var uploads = &stagingArea{}
+
+// stagingArea holds uploaded jars in a directory private to this instance,
+// under random keys it generates. Only those keys are accepted, so that API
+// callers can not name any other file. This implementation can be accessed
+// concurrently by multiple goroutines.
+type stagingArea struct {
+	lock   sync.Mutex
+	dir    string // created on first use
+	closed bool
+}
+
+// init creates the staging directory, if not yet created, returning it.
+func (s *stagingArea) init() (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return "", errors.New("staging area closed")
+	}
+
+	if s.dir == "" {
+		dir, err := ioutil.TempDir("", "pickaxx-uploads-")
+		if err != nil {
+			return "", err
+		}
+		s.dir = dir
+	}
+
+	return s.dir, nil
+}
+
+// Stage saves an uploaded jar, returning a key identifying it.
+func (s *stagingArea) Stage(c *gin.Context, file *multipart.FileHeader) (string, error) {
+	if file.Header.Get("Content-Type") != "application/java-archive" {
+		return "", errUnsupportedFile
+	}
+
+	dir, err := s.init()
+	if err != nil {
+		return "", errors.New("unable to create staging directory")
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	key := hex.EncodeToString(b) + ".jar"
+
+	if err := c.SaveUploadedFile(file, filepath.Join(dir, key)); err != nil {
+		return "", errors.New("unable to save file")
+	}
+
+	return key, nil
+}
+
+// Path returns the path of a staged jar.
+func (s *stagingArea) Path(key string) (string, error) {
+	if !stagingKey.MatchString(key) {
+		return "", errors.New("invalid key")
+	}
+
+	dir, err := s.init()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, key)
+
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("no jar staged with key '%s'", key)
+	}
+
+	return path, nil
+}
+
+// Close removes all staged jars. No more jars are staged once closed.
+func (s *stagingArea) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closed = true
+
+	if s.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(s.dir)
+}
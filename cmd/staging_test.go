@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stageUpload posts a file to a handler staging it, returning the key.
+func stageUpload(t *testing.T, s *stagingArea, name, contentType string) (string, error) {
+	var (
+		body bytes.Buffer
+		w    = multipart.NewWriter(&body)
+	)
+
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", `form-data; name="file"; filename="`+name+`"`)
+	h.Set("Content-Type", contentType)
+
+	part, err := w.CreatePart(h)
+	require.NoError(t, err)
+	part.Write([]byte("jar"))
+	require.NoError(t, w.Close())
+
+	var (
+		key      string
+		stageErr error
+	)
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.POST("/", func(c *gin.Context) {
+		file, err := c.FormFile("file")
+		require.NoError(t, err)
+		key, stageErr = s.Stage(c, file)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	return key, stageErr
+}
+
+func TestStagingArea(t *testing.T) {
+	s := &stagingArea{}
+	defer s.Close()
+
+	_, err := stageUpload(t, s, "paper.jar", "text/plain")
+	assert.Equal(t, errUnsupportedFile, err)
+
+	key, err := stageUpload(t, s, "../paper-1.20.1.jar", "application/java-archive")
+	require.NoError(t, err)
+	assert.Regexp(t, stagingKey, key, "keys are generated, not taken from the upload")
+
+	path, err := s.Path(key)
+	require.NoError(t, err)
+	assert.Equal(t, s.dir, filepath.Dir(path))
+
+	b, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "jar", string(b))
+
+	info, err := os.Stat(s.dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm(), "staging directory is private")
+
+	// only keys of staged jars are accepted
+	other, err := ioutil.TempFile("", "other-*.jar")
+	require.NoError(t, err)
+	other.Close()
+	defer os.Remove(other.Name())
+
+	for _, key := range []string{
+		filepath.Base(other.Name()),
+		"../" + filepath.Base(other.Name()),
+		"0123456789abcdef0123456789abcdef.jar",
+		"",
+	} {
+		_, err := s.Path(key)
+		assert.Error(t, err, key)
+	}
+
+	require.NoError(t, s.Close())
+	assert.NoDirExists(t, s.dir)
+}
+
+func TestStagingAreaClose(t *testing.T) {
+	s := &stagingArea{}
+
+	// closing as pickaxx exits may race with an upload
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stageUpload(t, s, "paper.jar", "application/java-archive")
+	}()
+
+	require.NoError(t, s.Close())
+	<-done
+
+	_, err := stageUpload(t, s, "paper.jar", "application/java-archive")
+	assert.Error(t, err, "no more jars are staged once closed")
+
+	if s.dir != "" {
+		assert.NoDirExists(t, s.dir)
+	}
+}
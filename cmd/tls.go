@@ -0,0 +1,193 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/apex/log"
+)
+
+const (
+	defaultCertFile = "pickaxx.crt"
+	defaultKeyFile  = "pickaxx.key"
+
+	selfSignedValidity = time.Hour * 24 * 365 * 2
+)
+
+// tlsConfig configures HTTPS.
+type tlsConfig struct {
+	CertFile   string `json:"cert"`       // PEM encoded certificate (chain).
+	KeyFile    string `json:"key"`        // PEM encoded private key.
+	SelfSigned bool   `json:"selfSigned"` // Generate a certificate & key, if the files don't exist.
+	Redirect   string `json:"redirect"`   // Address serving redirects from HTTP to HTTPS, if set.
+}
+
+// loadTLS returns the server TLS configuration, generating a self-signed
+// certificate if configured to. The listen address is included in the
+// names a generated certificate is valid for.
+func loadTLS(cfg *tlsConfig, listen string) (*tls.Config, error) {
+	certFile, keyFile := cfg.CertFile, cfg.KeyFile
+
+	if cfg.SelfSigned {
+		if certFile == "" {
+			certFile = defaultCertFile
+		}
+		if keyFile == "" {
+			keyFile = defaultKeyFile
+		}
+
+		if _, err := os.Stat(certFile); errors.Is(err, os.ErrNotExist) {
+			log.WithField("cert", certFile).Info("generating self-signed certificate")
+
+			if err := generateCertificate(certFile, keyFile, certificateHosts(listen)); err != nil {
+				return nil, fmt.Errorf("unable to generate certificate: %w", err)
+			}
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load certificate: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// generateCertificate writes a new self-signed certificate, and its key, valid for the given hosts.
+func generateCertificate(certFile, keyFile string, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"pickaxx"}, CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true, // allows clients to trust the certificate directly
+	}
+
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := writePEM(keyFile, "EC PRIVATE KEY", keyDER, 0600); err != nil {
+		return err
+	}
+
+	return writePEM(certFile, "CERTIFICATE", der, 0644)
+}
+
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if err := pem.Encode(f, &pem.Block{Type: blockType, Bytes: der}); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// certificateHosts returns names a generated certificate should be valid
+// for: localhost, this machine's hostname & addresses, and the listen address.
+func certificateHosts(listen string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+
+	if name, err := os.Hostname(); err == nil {
+		hosts = append(hosts, name)
+	}
+
+	if host, _, err := net.SplitHostPort(listen); err == nil && host != "" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsUnspecified() {
+			hosts = append(hosts, host)
+		}
+	}
+
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && !ipNet.IP.IsLinkLocalUnicast() {
+				hosts = append(hosts, ipNet.IP.String())
+			}
+		}
+	}
+
+	return unique(hosts)
+}
+
+func unique(values []string) []string {
+	var (
+		seen   = map[string]bool{}
+		result = []string{}
+	)
+
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+// redirectHandler redirects requests to HTTPS on the port of the given address.
+func redirectHandler(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host // no port
+		}
+
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+}
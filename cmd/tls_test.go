@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTLSSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg := &tlsConfig{
+		CertFile:   filepath.Join(dir, "test.crt"),
+		KeyFile:    filepath.Join(dir, "test.key"),
+		SelfSigned: true,
+	}
+
+	first, err := loadTLS(cfg, "192.0.2.10:8443")
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(first.Certificates[0].Certificate[0])
+	require.NoError(t, err)
+
+	assert.NoError(t, cert.VerifyHostname("localhost"))
+	assert.NoError(t, cert.VerifyHostname("192.0.2.10"))
+
+	// certificate is persisted, and reused
+	second, err := loadTLS(cfg, "192.0.2.10:8443")
+	require.NoError(t, err)
+	assert.Equal(t, first.Certificates[0].Certificate, second.Certificates[0].Certificate)
+}
+
+func TestLoadTLSMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := loadTLS(&tlsConfig{
+		CertFile: filepath.Join(dir, "missing.crt"),
+		KeyFile:  filepath.Join(dir, "missing.key"),
+	}, defaultListenAddr)
+
+	assert.Error(t, err)
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		httpsAddr string
+		host      string
+		expected  string
+	}{
+		{":8443", "example.local:8080", "https://example.local:8443/status?x=1"},
+		{"0.0.0.0:443", "example.local", "https://example.local/status?x=1"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/status?x=1", nil)
+		req.Host = tt.host
+
+		rec := httptest.NewRecorder()
+		redirectHandler(tt.httpsAddr).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, tt.expected, rec.Header().Get("Location"))
+	}
+}
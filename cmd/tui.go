@@ -0,0 +1,484 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	tuiScrollback = 1000 // console lines kept for redrawing
+	tuiHistory    = 100  // commands kept in history
+)
+
+// minecraftCommands are completed when pressing tab in the command input.
+var minecraftCommands = []string{
+	"advancement", "attribute", "ban", "ban-ip", "banlist", "bossbar", "clear",
+	"clone", "data", "datapack", "debug", "defaultgamemode", "deop", "difficulty",
+	"effect", "enchant", "execute", "experience", "fill", "forceload", "function",
+	"gamemode", "gamerule", "give", "help", "item", "kick", "kill", "list",
+	"locate", "loot", "me", "msg", "op", "pardon", "pardon-ip", "particle",
+	"playsound", "recipe", "reload", "save-all", "save-off", "save-on", "say",
+	"schedule", "scoreboard", "seed", "setblock", "setidletimeout",
+	"setworldspawn", "spawnpoint", "spectate", "spreadplayers", "stop",
+	"stopsound", "summon", "tag", "team", "teammsg", "teleport", "tell",
+	"tellraw", "time", "title", "tp", "trigger", "weather", "whitelist",
+	"worldborder", "xp",
+}
+
+// tui is a full-screen terminal console. The screen is split into a
+// scrolling console, a status bar, and a command input line.
+type tui struct {
+	client *ctlClient
+	out    io.Writer
+
+	width, height int
+
+	lines   []string // console scrollback
+	input   []rune   // current command input
+	history []string // previously submitted commands
+	histIdx int      // position in history while browsing (len(history) when not browsing)
+
+	state   string
+	players int
+	memory  uint64
+	tps     float64
+}
+
+// runTUI runs the 'tui' subcommand, returning an exit code.
+func runTUI(args []string) int {
+	var (
+		fs        = flag.NewFlagSet("tui", flag.ContinueOnError)
+		newClient = clientFlags(fs)
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	c, err := newClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if err := runTerminalUI(c); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func runTerminalUI(c *ctlClient) error {
+	fd := int(os.Stdin.Fd())
+
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("tui requires a terminal")
+	}
+
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	t := &tui{client: c, out: os.Stdout, state: "Unknown"}
+	t.loadStatus()
+
+	// enter alternate screen; restore on exit
+	fmt.Fprint(t.out, "\x1b[?1049h")
+	defer fmt.Fprint(t.out, "\x1b[r\x1b[?1049l")
+
+	t.resize()
+
+	var (
+		messages = make(chan []byte)
+		keys     = make(chan []byte)
+		resized  = make(chan os.Signal, 1)
+		errs     = make(chan error, 2)
+	)
+
+	signal.Notify(resized, syscall.SIGWINCH)
+	defer signal.Stop(resized)
+
+	go func() {
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				errs <- fmt.Errorf("connection closed: %w", err)
+				return
+			}
+			messages <- msg
+		}
+	}()
+
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				errs <- err
+				return
+			}
+			keys <- append([]byte{}, buf[:n]...)
+		}
+	}()
+
+	for {
+		select {
+		case err := <-errs:
+			return err
+		case <-resized:
+			t.resize()
+		case msg := <-messages:
+			t.handleMessage(msg)
+		case b := <-keys:
+			if quit := t.handleKeys(b); quit {
+				return nil
+			}
+		}
+	}
+}
+
+// loadStatus populates the status bar from the current server status.
+func (t *tui) loadStatus() {
+	raw, err := t.client.request(http.MethodGet, apiVersion+"/server", nil)
+	if err != nil {
+		return
+	}
+
+	var body struct {
+		State   string   `json:"state"`
+		Players []string `json:"players"`
+		Stats   *struct {
+			RSS uint64 `json:"rss"`
+		} `json:"stats"`
+		Tick *struct {
+			TPS float64 `json:"tps"`
+		} `json:"tick"`
+	}
+
+	if json.Unmarshal(raw, &body) != nil {
+		return
+	}
+
+	if body.State != "" {
+		t.state = body.State
+	}
+
+	t.players = len(body.Players)
+
+	if body.Stats != nil {
+		t.memory = body.Stats.RSS
+	}
+
+	if body.Tick != nil {
+		t.tps = body.Tick.TPS
+	}
+}
+
+// handleMessage updates the console & status bar from server activity.
+func (t *tui) handleMessage(msg []byte) {
+	var data struct {
+		Status string `json:"status"`
+		Stats  *struct {
+			RSS uint64 `json:"rss"`
+		} `json:"stats"`
+		Tick *struct {
+			TPS float64 `json:"tps"`
+		} `json:"tick"`
+		Player *struct {
+			Action string `json:"action"`
+		} `json:"player"`
+	}
+
+	json.Unmarshal(msg, &data)
+
+	switch {
+	case data.Status != "":
+		t.state = data.Status
+		if data.Status == "Stopped" || data.Status == "Failed" {
+			t.players, t.memory, t.tps = 0, 0, 0
+		}
+	case data.Stats != nil:
+		t.memory = data.Stats.RSS
+	case data.Tick != nil:
+		t.tps = data.Tick.TPS
+	case data.Player != nil && data.Player.Action == "joined":
+		t.players++
+	case data.Player != nil && data.Player.Action == "left" && t.players > 0:
+		t.players--
+	}
+
+	if line := formatActivity(msg); line != "" {
+		t.println(line)
+	}
+
+	t.drawStatus()
+	t.drawInput()
+}
+
+// handleKeys processes keyboard input, returning true if the user wants to quit.
+func (t *tui) handleKeys(b []byte) bool {
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x03: // ctrl-c
+			return true
+		case b[0] == 0x04 && len(t.input) == 0: // ctrl-d
+			return true
+		case b[0] == '\r' || b[0] == '\n':
+			t.submit()
+		case b[0] == 0x7f || b[0] == 0x08: // backspace
+			if len(t.input) > 0 {
+				t.input = t.input[:len(t.input)-1]
+			}
+		case b[0] == '\t':
+			t.complete()
+		case b[0] == 0x15: // ctrl-u
+			t.input = t.input[:0]
+		case b[0] == 0x0c: // ctrl-l
+			t.lines = nil
+			t.redraw()
+		case b[0] == 0x1b:
+			seq := escapeSequence(b)
+			switch seq {
+			case "\x1b[A", "\x1bOA": // up
+				t.browseHistory(-1)
+			case "\x1b[B", "\x1bOB": // down
+				t.browseHistory(1)
+			}
+			b = b[len(seq):] // other sequences are ignored
+			continue
+		case b[0] >= 0x20:
+			r, size := utf8.DecodeRune(b)
+			t.input = append(t.input, r)
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+
+	t.drawInput()
+	return false
+}
+
+// escapeSequence returns the escape sequence at the start of b: a CSI
+// sequence (e.g. "\x1b[3~" for delete) up to its final byte, an SS3 sequence
+// (e.g. "\x1bOA" for up, in application mode), or an escape followed by a
+// key (e.g. alt-f). An incomplete sequence is returned whole.
+func escapeSequence(b []byte) string {
+	if len(b) < 2 {
+		return string(b)
+	}
+
+	switch b[1] {
+	case '[':
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7e {
+				return string(b[:i+1])
+			}
+		}
+		return string(b)
+	case 'O':
+		if len(b) < 3 {
+			return string(b)
+		}
+		return string(b[:3])
+	}
+
+	_, size := utf8.DecodeRune(b[1:])
+	return string(b[:1+size])
+}
+
+// submit sends the current input to the server as a command.
+func (t *tui) submit() {
+	command := strings.TrimSpace(string(t.input))
+	t.input = t.input[:0]
+
+	if command == "" {
+		return
+	}
+
+	if len(t.history) == 0 || t.history[len(t.history)-1] != command {
+		t.history = append(t.history, command)
+		if len(t.history) > tuiHistory {
+			t.history = t.history[1:]
+		}
+	}
+	t.histIdx = len(t.history)
+
+	if _, err := t.client.request(http.MethodPost, apiVersion+"/server/commands", commandRequest{command}); err != nil {
+		t.println(fmt.Sprintf("error: %v", err))
+	}
+}
+
+// browseHistory moves through previous commands.
+func (t *tui) browseHistory(delta int) {
+	idx := t.histIdx + delta
+
+	if idx < 0 || idx > len(t.history) {
+		return
+	}
+
+	t.histIdx = idx
+
+	if idx == len(t.history) {
+		t.input = t.input[:0]
+	} else {
+		t.input = []rune(t.history[idx])
+	}
+}
+
+// complete completes the command name being typed.
+func (t *tui) complete() {
+	text := string(t.input)
+	slash := strings.HasPrefix(text, "/")
+	prefix := strings.TrimPrefix(text, "/")
+
+	if strings.Contains(prefix, " ") {
+		return // only command names are completed
+	}
+
+	matches := completions(prefix)
+
+	switch len(matches) {
+	case 0:
+		return
+	case 1:
+		text = matches[0] + " "
+	default:
+		text = commonPrefix(matches)
+		t.println(strings.Join(matches, "  "))
+	}
+
+	if slash {
+		text = "/" + text
+	}
+
+	t.input = []rune(text)
+}
+
+// completions returns Minecraft commands beginning with prefix.
+func completions(prefix string) []string {
+	matches := []string{}
+
+	for _, cmd := range minecraftCommands {
+		if strings.HasPrefix(cmd, prefix) {
+			matches = append(matches, cmd)
+		}
+	}
+
+	sort.Strings(matches)
+	return matches
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
+
+// println adds a line to the console, scrolling the console region.
+func (t *tui) println(line string) {
+	t.lines = append(t.lines, line)
+	if len(t.lines) > tuiScrollback {
+		t.lines = t.lines[len(t.lines)-tuiScrollback:]
+	}
+
+	// writing a newline on the last console row scrolls only the console region
+	fmt.Fprintf(t.out, "\x1b[%d;1H\n%s", t.consoleHeight(), t.truncate(line))
+}
+
+func (t *tui) consoleHeight() int {
+	if t.height < 3 {
+		return 1
+	}
+	return t.height - 2
+}
+
+func (t *tui) truncate(line string) string {
+	if r := []rune(line); t.width > 0 && len(r) > t.width {
+		return string(r[:t.width])
+	}
+	return line
+}
+
+// resize reads the terminal size, and redraws the screen.
+func (t *tui) resize() {
+	w, h, err := term.GetSize(int(os.Stdin.Fd()))
+	if err != nil {
+		w, h = 80, 24
+	}
+
+	t.width, t.height = w, h
+	t.redraw()
+}
+
+// redraw draws the entire screen.
+func (t *tui) redraw() {
+	fmt.Fprintf(t.out, "\x1b[r\x1b[2J\x1b[1;%dr", t.consoleHeight())
+
+	start := len(t.lines) - t.consoleHeight()
+	if start < 0 {
+		start = 0
+	}
+
+	for i, line := range t.lines[start:] {
+		fmt.Fprintf(t.out, "\x1b[%d;1H%s", i+1, t.truncate(line))
+	}
+
+	t.drawStatus()
+	t.drawInput()
+}
+
+// drawStatus draws the status bar, in reverse video.
+func (t *tui) drawStatus() {
+	status := fmt.Sprintf(" %s | players: %d | memory: %.0f MB", t.state, t.players, float64(t.memory)/1024/1024)
+
+	if t.tps > 0 {
+		status += fmt.Sprintf(" | TPS: %.1f", t.tps)
+	}
+
+	status += " | ctrl-c to quit"
+
+	if pad := t.width - utf8.RuneCountInString(status); pad > 0 {
+		status += strings.Repeat(" ", pad)
+	}
+
+	fmt.Fprintf(t.out, "\x1b[%d;1H\x1b[7m%s\x1b[0m", t.height-1, t.truncate(status))
+}
+
+// drawInput draws the command input, leaving the cursor at the end of input.
+func (t *tui) drawInput() {
+	input := "> " + string(t.input)
+
+	// keep the end of long input visible
+	if r := []rune(input); t.width > 0 && len(r) >= t.width {
+		input = string(r[len(r)-t.width+1:])
+	}
+
+	fmt.Fprintf(t.out, "\x1b[%d;1H\x1b[2K%s", t.height, input)
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestTUI returns a tui drawing to a buffer, whose commands are recorded.
+func newTestTUI(t *testing.T) (*tui, *bytes.Buffer, *[]string) {
+	commands := &[]string{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		*commands = append(*commands, r.Method+" "+r.URL.Path+" "+string(b))
+	}))
+	t.Cleanup(srv.Close)
+
+	addr, _ := url.Parse(srv.URL)
+	out := &bytes.Buffer{}
+
+	c := &ctlClient{addr: addr, out: ioutil.Discard, client: srv.Client()}
+	return &tui{client: c, out: out, width: 40, height: 10, state: "Unknown"}, out, commands
+}
+
+func TestTUIKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string // each a separate read from the terminal
+		input    string
+		history  []string
+		commands []string
+		quit     bool
+	}{
+		{
+			name:  "typing",
+			keys:  []string{"say h", "é"},
+			input: "say hé",
+		},
+		{
+			name:  "backspace",
+			keys:  []string{"sayx\x7f", "\x08\x7f\x7f\x7f\x7f"},
+			input: "",
+		},
+		{
+			name:  "ctrl-u",
+			keys:  []string{"say hello\x15list"},
+			input: "list",
+		},
+		{
+			name:     "enter",
+			keys:     []string{"  list \r"},
+			history:  []string{"list"},
+			commands: []string{`POST /api/v1/server/commands {"command":"list"}`},
+		},
+		{
+			name:     "repeated command",
+			keys:     []string{"list\r", "list\n", "\r"},
+			history:  []string{"list"},
+			commands: []string{`POST /api/v1/server/commands {"command":"list"}`, `POST /api/v1/server/commands {"command":"list"}`},
+		},
+		{
+			name:    "history up",
+			keys:    []string{"list\rseed\r", "\x1b[A\x1b[A"},
+			input:   "list",
+			history: []string{"list", "seed"},
+		},
+		{
+			name:    "history past oldest",
+			keys:    []string{"list\rseed\r", "\x1b[A\x1b[A\x1b[A"},
+			input:   "list",
+			history: []string{"list", "seed"},
+		},
+		{
+			name:    "history down",
+			keys:    []string{"list\rseed\r", "\x1b[A\x1b[A\x1b[B"},
+			input:   "seed",
+			history: []string{"list", "seed"},
+		},
+		{
+			name:    "history down to new input",
+			keys:    []string{"list\r", "\x1b[A\x1b[B\x1b[B"},
+			input:   "",
+			history: []string{"list"},
+		},
+		{
+			name:  "other escape sequences",
+			keys:  []string{"ab\x1b[Dc"},
+			input: "abc",
+		},
+		{
+			name:  "delete, page up & page down",
+			keys:  []string{"ab\x1b[3~", "\x1b[5~c\x1b[6~"},
+			input: "abc",
+		},
+		{
+			name:  "modified keys",
+			keys:  []string{"ab\x1b[1;5Cc"},
+			input: "abc",
+		},
+		{
+			name:  "alt keys",
+			keys:  []string{"ab\x1bf", "\x1bbc\x1bé"},
+			input: "abc",
+		},
+		{
+			name:    "application mode history",
+			keys:    []string{"list\rseed\r", "\x1bOA\x1bOA\x1bOB"},
+			input:   "seed",
+			history: []string{"list", "seed"},
+		},
+		{
+			name:  "escape",
+			keys:  []string{"ab", "\x1b", "c"},
+			input: "abc",
+		},
+		{
+			name:  "tab",
+			keys:  []string{"/weat\t"},
+			input: "/weather ",
+		},
+		{
+			name: "ctrl-c",
+			keys: []string{"say hello\x03"},
+			quit: true,
+		},
+		{
+			name: "ctrl-d",
+			keys: []string{"\x04"},
+			quit: true,
+		},
+		{
+			name:  "ctrl-d with input",
+			keys:  []string{"say\x04"},
+			input: "say",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ui, _, commands := newTestTUI(t)
+
+			var quit bool
+			for _, k := range tt.keys {
+				if quit = ui.handleKeys([]byte(k)); quit {
+					break
+				}
+			}
+
+			assert.Equal(t, tt.quit, quit)
+
+			if !tt.quit {
+				assert.Equal(t, tt.input, string(ui.input))
+			}
+
+			if tt.history == nil {
+				assert.Empty(t, ui.history)
+			} else {
+				assert.Equal(t, tt.history, ui.history)
+			}
+
+			if tt.commands != nil {
+				assert.Equal(t, tt.commands, *commands)
+			} else if len(tt.history) == 0 {
+				assert.Empty(t, *commands)
+			}
+		})
+	}
+}
+
+func TestTUIHistoryLimit(t *testing.T) {
+	ui, _, _ := newTestTUI(t)
+
+	for i := 0; i < tuiHistory+5; i++ {
+		ui.input = []rune(strings.Repeat("x", i+1))
+		ui.submit()
+	}
+
+	assert.Len(t, ui.history, tuiHistory)
+	assert.Equal(t, strings.Repeat("x", 6), ui.history[0])
+	assert.Equal(t, len(ui.history), ui.histIdx)
+}
+
+func TestTUISubmitError(t *testing.T) {
+	ui, out, _ := newTestTUI(t)
+	ui.client.addr, _ = url.Parse("http://127.0.0.1:1")
+
+	ui.handleKeys([]byte("list\r"))
+
+	assert.Len(t, ui.lines, 1)
+	assert.True(t, strings.HasPrefix(ui.lines[0], "error: "), ui.lines[0])
+	assert.Contains(t, out.String(), "error: ")
+}
+
+func TestTUIComplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		listed   string // possible completions printed to the console
+	}{
+		{"weat", "weather ", ""},
+		{"/weat", "/weather ", ""},
+		{"save", "save-", "save-all  save-off  save-on"},
+		{"sa", "sa", "save-all  save-off  save-on  say"},
+		{"/ban", "/ban", "ban  ban-ip  banlist"},
+		{"nothing", "nothing", ""},
+		{"say hel", "say hel", ""},
+		{"", "", strings.Join(minecraftCommands, "  ")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ui, _, _ := newTestTUI(t)
+			ui.input = []rune(tt.input)
+
+			ui.complete()
+
+			assert.Equal(t, tt.expected, string(ui.input))
+
+			if tt.listed == "" {
+				assert.Empty(t, ui.lines)
+			} else {
+				assert.Equal(t, []string{tt.listed}, ui.lines)
+			}
+		})
+	}
+}
+
+func TestTUIHandleMessage(t *testing.T) {
+	ui, out, _ := newTestTUI(t)
+	ui.state, ui.width = "Running", 80
+
+	messages := []string{
+		`{"player": {"name": "Steve", "action": "joined"}}`,
+		`{"player": {"name": "Alex", "action": "joined"}}`,
+		`{"player": {"name": "Steve", "action": "left"}}`,
+		`{"stats": {"rss": 209715200}}`,
+		`{"tick": {"tps": 19.5}}`,
+		`{"output": "Done (5.2s)!"}`,
+	}
+
+	for _, m := range messages {
+		ui.handleMessage([]byte(m))
+	}
+
+	assert.Equal(t, 1, ui.players)
+	assert.Equal(t, uint64(209715200), ui.memory)
+	assert.Equal(t, 19.5, ui.tps)
+	assert.Equal(t, []string{"Done (5.2s)!"}, ui.lines)
+	assert.Contains(t, out.String(), "Running | players: 1 | memory: 200 MB | TPS: 19.5")
+
+	ui.handleMessage([]byte(`{"status": "Stopped"}`))
+
+	assert.Equal(t, "Stopped", ui.state)
+	assert.Zero(t, ui.players)
+	assert.Zero(t, ui.memory)
+	assert.Zero(t, ui.tps)
+	assert.Equal(t, []string{"Done (5.2s)!", "-- Stopped"}, ui.lines)
+
+	// leaving with no players counted doesn't go negative
+	ui.handleMessage([]byte(`{"player": {"name": "Alex", "action": "left"}}`))
+	assert.Zero(t, ui.players)
+}
+
+func TestTUIDrawStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		width    int
+		tps      float64
+		expected string
+	}{
+		{
+			name:     "padded",
+			width:    60,
+			expected: " Running | players: 2 | memory: 100 MB | ctrl-c to quit     ",
+		},
+		{
+			name:     "tps",
+			width:    60,
+			tps:      19.84,
+			expected: " Running | players: 2 | memory: 100 MB | TPS: 19.8 | ctrl-c ",
+		},
+		{
+			name:     "truncated",
+			width:    20,
+			expected: " Running | players: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ui, out, _ := newTestTUI(t)
+			ui.width, ui.state, ui.players, ui.memory, ui.tps = tt.width, "Running", 2, 100*1024*1024, tt.tps
+
+			ui.drawStatus()
+
+			assert.Equal(t, "\x1b[9;1H\x1b[7m"+tt.expected+"\x1b[0m", out.String())
+		})
+	}
+}
+
+func TestTUIDrawInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "> "},
+		{"say hi", "> say hi"},
+		{"say " + strings.Repeat("a", 40), strings.Repeat("a", 39)}, // the end is kept visible
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ui, out, _ := newTestTUI(t)
+			ui.input = []rune(tt.input)
+
+			ui.drawInput()
+
+			assert.Equal(t, "\x1b[10;1H\x1b[2K"+tt.expected, out.String())
+		})
+	}
+}
+
+func TestTUIPrintln(t *testing.T) {
+	ui, out, _ := newTestTUI(t)
+
+	ui.println(strings.Repeat("a", 50))
+
+	assert.Equal(t, "\x1b[8;1H\n"+strings.Repeat("a", 40), out.String())
+	assert.Equal(t, []string{strings.Repeat("a", 50)}, ui.lines, "scrollback keeps the full line")
+
+	for i := 0; i < tuiScrollback+10; i++ {
+		ui.println("line")
+	}
+
+	assert.Len(t, ui.lines, tuiScrollback)
+}
+
+func TestTUIRedraw(t *testing.T) {
+	ui, out, _ := newTestTUI(t)
+	ui.width, ui.height = 20, 5
+
+	for _, l := range []string{"one", "two", "three", "four"} {
+		ui.println(l)
+	}
+	out.Reset()
+
+	ui.redraw()
+
+	// only the last lines fitting in the console are drawn
+	s := out.String()
+	assert.True(t, strings.HasPrefix(s, "\x1b[r\x1b[2J\x1b[1;3r"), s)
+	assert.Contains(t, s, "\x1b[1;1Htwo\x1b[2;1Hthree\x1b[3;1Hfour")
+	assert.NotContains(t, s, "one")
+	assert.Contains(t, s, "\x1b[4;1H\x1b[7m")
+	assert.Contains(t, s, "\x1b[5;1H\x1b[2K> ")
+
+	// ctrl-l clears the console
+	out.Reset()
+	ui.handleKeys([]byte{0x0c})
+
+	assert.Empty(t, ui.lines)
+	assert.NotContains(t, out.String(), "four")
+}
+
+func TestTUIConsoleHeight(t *testing.T) {
+	for height, expected := range map[int]int{0: 1, 2: 1, 3: 1, 24: 22} {
+		ui := &tui{height: height}
+		assert.Equal(t, expected, ui.consoleHeight(), "height %d", height)
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	assert.Equal(t, "save-", commonPrefix([]string{"save-all", "save-off", "save-on"}))
+	assert.Equal(t, "", commonPrefix([]string{"ban", "kick"}))
+	assert.Equal(t, "list", commonPrefix([]string{"list"}))
+}
+
+func TestEscapeSequence(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"\x1b[A", "\x1b[A"},
+		{"\x1b[Aabc", "\x1b[A"},
+		{"\x1b[3~x", "\x1b[3~"},
+		{"\x1b[1;5Cx", "\x1b[1;5C"},
+		{"\x1bOBx", "\x1bOB"},
+		{"\x1bfx", "\x1bf"},
+		{"\x1béx", "\x1bé"},
+		{"\x1b", "\x1b"},
+		{"\x1b[1;", "\x1b[1;"}, // incomplete
+		{"\x1bO", "\x1bO"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, escapeSequence([]byte(tt.keys)), "%q", tt.keys)
+	}
+}
@@ -0,0 +1,14 @@
+package pickaxx
+
+// Detacher is implemented by process managers able to leave a process
+// running when pickaxx exits, and to re-adopt it when pickaxx next starts.
+type Detacher interface {
+
+	// Detach releases the running process without stopping it.
+	Detach() error
+
+	// Reattach adopts a process left running by a previous call to Detach,
+	// returning its activity as Start would. The channel is nil if there is
+	// no process to adopt.
+	Reattach() (<-chan Data, error)
+}
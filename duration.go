@@ -0,0 +1,40 @@
+package pickaxx
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Duration is a time.Duration which is represented in JSON as a
+// string (e.g. "1m30s"). Numbers are interpreted as seconds.
+type Duration time.Duration
+
+// MarshalJSON converts this duration to valid JSON.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON parses a duration from a string or number of seconds.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	switch value := v.(type) {
+	case float64:
+		*d = Duration(value * float64(time.Second))
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	default:
+		return errors.New("invalid duration")
+	}
+
+	return nil
+}
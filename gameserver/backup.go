@@ -0,0 +1,320 @@
+package gameserver
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ivan3bx/pickaxx"
+)
+
+var _ pickaxx.Backuper = &Manager{}
+
+const (
+	// backupDir holds backups of the server, within its working directory.
+	backupDir = "backups"
+
+	// saveTimeout is how long a running server has to save the world, before
+	// it is backed up.
+	saveTimeout = time.Minute * 2
+)
+
+// Backup archives the working directory to its backup directory, asking a
+// running server to save the world first. This waits for any maintenance in
+// progress (e.g. an upgrade).
+func (m *Manager) Backup() (path string, err error) {
+	err = m.Maintain(func() error {
+		path, err = m.BackupAs(fmt.Sprintf("backup-%s.tar.gz", time.Now().Format("20060102-150405")))
+		return err
+	})
+	return path, err
+}
+
+// Maintain runs change while no other maintenance (e.g. a backup) is in
+// progress, returning its error.
+func (m *Manager) Maintain(change func() error) error {
+	m.maintenance.Lock()
+	defer m.maintenance.Unlock()
+	return change()
+}
+
+// writeBackup archives the working directory dir (except backups, and files
+// used by pickaxx itself) to a gzipped tarball in its backup directory,
+// returning the path of the backup.
+func writeBackup(dir, name string) (string, error) {
+	if err := os.MkdirAll(filepath.Join(dir, backupDir), 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, backupDir, name)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeTarball(f, dir); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	return path, nil
+}
+
+func writeTarball(w io.Writer, dir string) error {
+	var (
+		zw = gzip.NewWriter(w)
+		tw = tar.NewWriter(zw)
+	)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		if info.IsDir() && (rel == backupDir || rel == DataDir) {
+			return filepath.SkipDir
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil // e.g. named pipes
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// RestoreBackup extracts a backup written by Backup into dir,
+// replacing files of the same name. Directories at the top of the backup
+// (e.g. the world) are emptied first, so that files written since the
+// backup are removed; other files not in the backup are left as is. If
+// include is set, only the files it returns true for are extracted.
+func RestoreBackup(path, dir string, include func(name string) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(zr)
+	root := filepath.Clean(dir) + string(os.PathSeparator)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if include != nil && !include(strings.TrimSuffix(hdr.Name, "/")) {
+			continue
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(target, root) {
+			return fmt.Errorf("invalid path in backup: '%s'", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			// directories precede their contents in a backup
+			if !strings.Contains(strings.TrimSuffix(hdr.Name, "/"), "/") {
+				if err := os.RemoveAll(target); err != nil {
+					return err
+				}
+			}
+			err = os.MkdirAll(target, os.FileMode(hdr.Mode).Perm())
+		case tar.TypeSymlink:
+			os.Remove(target)
+			err = os.Symlink(hdr.Linkname, target)
+		case tar.TypeReg:
+			err = extractFile(tr, target, os.FileMode(hdr.Mode).Perm())
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractFile(r io.Reader, path string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// BackupAs backs up the working directory, as name within its backup
+// directory. A running server is asked to save the world, and not to write
+// to it until backed up (see holdSaves). Its progress is reported as events.
+// Unlike Backup, this does not wait for maintenance, so may be used within
+// Maintain.
+func (m *Manager) BackupAs(name string) (string, error) {
+	m.emit(backupEvent{Status: "started", Path: filepath.Join(m.WorkingDir, backupDir, name)})
+
+	if m.Running() {
+		resume, err := m.holdSaves(saveTimeout)
+		if err != nil {
+			m.emit(backupEvent{Status: "failed", Path: filepath.Join(m.WorkingDir, backupDir, name), Error: err.Error()})
+			return "", err
+		}
+		defer resume()
+	}
+
+	path, err := writeBackup(m.WorkingDir, name)
+	if err != nil {
+		m.emit(backupEvent{Status: "failed", Path: filepath.Join(m.WorkingDir, backupDir, name), Error: err.Error()})
+		return "", err
+	}
+
+	m.emit(backupEvent{Status: "completed", Path: path})
+	return path, nil
+}
+
+// holdSaves asks a running server to stop writing the world, and then to
+// save it, returning a function which resumes writing. If the server type
+// recognizes output once saved, this waits for it, failing after timeout.
+func (m *Manager) holdSaves(timeout time.Duration) (resume func(), err error) {
+	t := m.Type
+
+	resume = func() {
+		if t.SaveOnCommand == "" {
+			return
+		}
+		if err := m.Send(t.SaveOnCommand); err != nil {
+			log.WithError(err).Warn("unable to resume saving")
+		}
+	}
+
+	if t.SaveOffCommand != "" {
+		if err := m.Send(t.SaveOffCommand); err != nil {
+			return nil, fmt.Errorf("unable to stop saving: %w", err)
+		}
+	}
+
+	if t.SaveCommand == "" {
+		return resume, nil
+	}
+
+	// waiting starts before saving, so that the output is not missed
+	var saved <-chan struct{}
+	if t.Saved != nil {
+		ch, stop := m.output.Wait(t.Saved)
+		defer stop()
+		saved = ch
+	}
+
+	if err := m.Send(t.SaveCommand); err != nil {
+		resume()
+		return nil, fmt.Errorf("unable to save the world: %w", err)
+	}
+
+	if saved == nil {
+		return resume, nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-saved:
+		return resume, nil
+	case <-timer.C:
+		resume()
+		return nil, fmt.Errorf("server did not save the world within %v", timeout)
+	}
+}
+
+// emit passes an event to the handler set by WithEvents, if any.
+func (m *Manager) emit(d pickaxx.Data) {
+	if m.events != nil {
+		m.events(d)
+	}
+}
+
+// backupEvent reports the progress of a backup.
+type backupEvent struct {
+	Status string `json:"status"` // started, completed or failed
+	Path   string `json:"path"`
+	Error  string `json:"error,omitempty"`
+}
+
+// MarshalJSON converts this event to valid JSON.
+func (d backupEvent) MarshalJSON() ([]byte, error) {
+	type backup backupEvent // avoid recursion
+	return json.Marshal(map[string]backup{"backup": backup(d)})
+}
+
+// EventType identifies this kind of event, e.g. "backup-completed".
+func (d backupEvent) EventType() string { return "backup-" + d.Status }
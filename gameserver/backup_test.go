@@ -0,0 +1,158 @@
+package gameserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRestore(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "world", "region"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "world", "region", "r.0.0.mca"), []byte("old world"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "server.properties"), []byte("server-port=25565\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, DataDir, processFile), []byte("{}"), 0644))
+
+	backup, err := writeBackup(dir, "test.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, backupDir, "test.tar.gz"), backup)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "world", "region", "r.0.0.mca"), []byte("upgraded world"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "server.properties")))
+	require.NoError(t, os.Remove(filepath.Join(dir, DataDir, processFile)))
+
+	require.NoError(t, RestoreBackup(backup, dir, nil))
+
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "world", "region", "r.0.0.mca"))
+	assert.Equal(t, "old world", string(b))
+	assert.FileExists(t, filepath.Join(dir, "server.properties"))
+	assert.NoFileExists(t, filepath.Join(dir, DataDir, processFile), "files used by pickaxx are not backed up")
+}
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "server.properties"), []byte("server-port=25565\n"), 0644))
+
+	var events []string
+	m := New(testPort, WithWorkingDir(dir), WithEvents(func(d pickaxx.Data) {
+		b, _ := json.Marshal(d)
+		events = append(events, d.(pickaxx.Event).EventType()+" "+string(b))
+	}))
+
+	path, err := m.Backup()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, backupDir), filepath.Dir(path))
+	assert.Regexp(t, `^backup-\d{8}-\d{6}\.tar\.gz$`, filepath.Base(path))
+	assert.FileExists(t, path)
+
+	assert.Equal(t, []string{
+		`backup-started {"backup":{"status":"started","path":"` + path + `"}}`,
+		`backup-completed {"backup":{"status":"completed","path":"` + path + `"}}`,
+	}, events)
+
+	// backups can not be written where backups are expected
+	events = nil
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, backupDir)))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, backupDir), nil, 0644))
+
+	_, err = m.Backup()
+	assert.Error(t, err)
+	require.Len(t, events, 2)
+	assert.Contains(t, events[1], "backup-failed ")
+	assert.Contains(t, events[1], `"error":`)
+}
+
+// saverType is run by a script recording console commands to commands.log,
+// which reports saving the world unless told not to respond.
+func saverType(respond bool) ServerType {
+	script := `while read cmd; do echo "$cmd" >> commands.log; ` +
+		`if [ "$cmd" = "save-all flush" ] && [ "$0" = "respond" ]; then echo "[12:00:00] [Server thread/INFO]: Saved the game"; fi; done`
+
+	arg := "respond"
+	if !respond {
+		arg = "silent"
+	}
+
+	return ServerType{
+		Name:           "saver",
+		Command:        []string{"sh", "-c", script, arg},
+		Probe:          ProbeNone,
+		SaveCommand:    "save-all flush",
+		SaveOffCommand: "save-off",
+		SaveOnCommand:  "save-on",
+		Saved:          MustPattern(`]: Saved the game$`),
+	}
+}
+
+func TestBackupRunning(t *testing.T) {
+	dir := t.TempDir()
+
+	m := New(testPort, WithType(saverType(true)), WithWorkingDir(dir))
+
+	isRunning := m.notifier.Register(Running)
+	defer m.notifier.Unregister(isRunning)
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+	<-isRunning
+
+	done := drain(activity)
+	defer func() {
+		m.Stop()
+		<-done
+	}()
+
+	path, err := m.Backup()
+	require.NoError(t, err)
+
+	readLog := func(dir string) string {
+		b, _ := ioutil.ReadFile(filepath.Join(dir, "commands.log"))
+		return string(b)
+	}
+
+	// saving resumes once backed up
+	assert.Eventually(t, func() bool {
+		return readLog(dir) == "save-off\nsave-all flush\nsave-on\n"
+	}, time.Second*5, time.Millisecond*10, readLog(dir))
+
+	restored := t.TempDir()
+	require.NoError(t, RestoreBackup(path, restored, nil))
+	assert.Equal(t, "save-off\nsave-all flush\n", readLog(restored), "backed up once saved, and before saving resumed")
+}
+
+func TestHoldSavesTimeout(t *testing.T) {
+	dir := t.TempDir()
+
+	m := New(testPort, WithType(saverType(false)), WithWorkingDir(dir))
+
+	isRunning := m.notifier.Register(Running)
+	defer m.notifier.Unregister(isRunning)
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+	<-isRunning
+
+	done := drain(activity)
+	defer func() {
+		m.Stop()
+		<-done
+	}()
+
+	_, err = m.holdSaves(time.Millisecond * 100)
+	assert.Error(t, err)
+
+	// saving is resumed, as the world is not backed up
+	assert.Eventually(t, func() bool {
+		b, _ := ioutil.ReadFile(filepath.Join(dir, "commands.log"))
+		return string(b) == "save-off\nsave-all flush\nsave-on\n"
+	}, time.Second*5, time.Millisecond*10)
+}
@@ -1,8 +1,8 @@
-package minecraft
+package gameserver
 
 //go:generate stringer -type=ServerState -trimprefix=Server         -output=enums_string.go
 
-//ServerState describes the current state of a Minecraft server.
+//ServerState describes the current state of a game server.
 type ServerState int
 
 // Server Statuses
@@ -12,6 +12,7 @@ const (
 	Running
 	Stopping
 	Stopped
+	Failed // stopped, after failing to start
 )
 
 // func (state ServerState) writeJSON(w io.Writer) error {
@@ -1,6 +1,6 @@
 // Code generated by "stringer -type=ServerState -trimprefix=Server -output=enums_string.go"; DO NOT EDIT.
 
-package minecraft
+package gameserver
 
 import "strconv"
 
@@ -13,11 +13,12 @@ func _() {
 	_ = x[Running-2]
 	_ = x[Stopping-3]
 	_ = x[Stopped-4]
+	_ = x[Failed-5]
 }
 
-const _ServerState_name = "UnknownStartingRunningStoppingStopped"
+const _ServerState_name = "UnknownStartingRunningStoppingStoppedFailed"
 
-var _ServerState_index = [...]uint8{0, 7, 15, 22, 30, 37}
+var _ServerState_index = [...]uint8{0, 7, 15, 22, 30, 37, 43}
 
 func (i ServerState) String() string {
 	if i < 0 || i >= ServerState(len(_ServerState_index)-1) {
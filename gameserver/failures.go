@@ -0,0 +1,114 @@
+package gameserver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+)
+
+// failureWindow is how long after starting console output is inspected for
+// startup failures, if the server has not yet loaded.
+const failureWindow = time.Minute * 2
+
+// FailureSignature recognizes a startup failure in console output.
+type FailureSignature struct {
+	Pattern *Pattern `json:"pattern"`
+	Code    string   `json:"code"`    // Identifies the failure (e.g. "portInUse").
+	Message string   `json:"message"` // What went wrong.
+	Fix     string   `json:"fix"`     // How to fix it.
+}
+
+// failureTracker recognizes startup failures in early console output, until
+// the server has loaded. This implementation can be accessed concurrently by
+// multiple goroutines.
+type failureTracker struct {
+	sync.Mutex
+	signatures []FailureSignature
+	ready      *Pattern  // once matched, output is no longer inspected
+	until      time.Time // output after this is no longer inspected
+	loaded     bool
+	failure    *pickaxx.StartupFailure
+}
+
+// Reset clears any failure (e.g. when the server starts), and inspects
+// output from now on using the given signatures.
+func (t *failureTracker) Reset(signatures []FailureSignature, ready *Pattern) {
+	t.Lock()
+	defer t.Unlock()
+	t.signatures = append([]FailureSignature{}, signatures...)
+	t.ready = ready
+	t.until = time.Now().Add(failureWindow)
+	t.loaded = false
+	t.failure = nil
+}
+
+// Observe inspects a line of console output, returning an alert for clients
+// if it is the first to show the server failed to start.
+func (t *failureTracker) Observe(line string) []pickaxx.Data {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.failure != nil || t.loaded || time.Now().After(t.until) {
+		return nil
+	}
+
+	if t.ready != nil && t.ready.MatchString(line) {
+		t.loaded = true
+		return nil
+	}
+
+	for _, sig := range t.signatures {
+		if !sig.Pattern.MatchString(line) {
+			continue
+		}
+
+		t.failure = &pickaxx.StartupFailure{
+			Code:    sig.Code,
+			Message: sig.Message,
+			Fix:     sig.Fix,
+			Line:    line,
+			Time:    time.Now(),
+		}
+
+		return []pickaxx.Data{Alert{
+			Kind:    "startupFailure",
+			Message: fmt.Sprintf("Server failed to start: %s. %s", sig.Message, sig.Fix),
+		}}
+	}
+
+	return nil
+}
+
+// Failure returns the failure recognized since the last reset, if any.
+func (t *failureTracker) Failure() *pickaxx.StartupFailure {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.failure == nil {
+		return nil
+	}
+
+	f := *t.failure
+	return &f
+}
+
+// observeFailures inspects a line of console output, stopping the server if
+// it failed to start.
+func (m *Manager) observeFailures(line string) []pickaxx.Data {
+	data := m.failures.Observe(line)
+
+	if len(data) > 0 {
+		if f := m.failures.Failure(); f != nil {
+			m.Stop(pickaxx.WithReason(f.Message))
+		}
+	}
+
+	return data
+}
+
+// Failure returns why the server last failed to start, if it did.
+func (m *Manager) Failure() *pickaxx.StartupFailure {
+	return m.failures.Failure()
+}
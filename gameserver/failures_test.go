@@ -0,0 +1,113 @@
+package gameserver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFailures recognize startup failures, e.g. "Error: port 7777 in use".
+var testFailures = []FailureSignature{
+	{Pattern: MustPattern(`^Error: port \d+ in use`), Code: "portInUse", Message: "server port already in use", Fix: "Stop the other server"},
+	{Pattern: MustPattern(`^Error: world not found`), Code: "noWorld", Message: "world not found", Fix: "Create a world"},
+}
+
+func TestFailureTracker(t *testing.T) {
+	tests := []struct {
+		line string
+		code string
+	}{
+		{"Error: port 7777 in use", "portInUse"},
+		{"Error: world not found", "noWorld"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			tr := failureTracker{}
+			tr.Reset(testFailures, MustPattern("^Server started$"))
+
+			assert.Nil(t, tr.Observe("Loading world"))
+			assert.Nil(t, tr.Failure())
+
+			data := tr.Observe(tt.line)
+			require.Len(t, data, 1)
+			assert.IsType(t, Alert{}, data[0])
+
+			f := tr.Failure()
+			require.NotNil(t, f)
+			assert.Equal(t, tt.code, f.Code)
+			assert.Equal(t, tt.line, f.Line)
+			assert.NotEmpty(t, f.Fix)
+
+			assert.Nil(t, tr.Observe(tt.line), "only the first failure is reported")
+		})
+	}
+}
+
+func TestFailureTrackerAfterReady(t *testing.T) {
+	tr := failureTracker{}
+	tr.Reset(testFailures, MustPattern("^Server started$"))
+
+	tr.Observe("Server started")
+
+	assert.Nil(t, tr.Observe("Error: world not found"))
+	assert.Nil(t, tr.Failure())
+}
+
+func TestStateChangeEventFailure(t *testing.T) {
+	b, err := json.Marshal(stateChangeEvent{
+		State:   Failed,
+		Reason:  "EULA not accepted",
+		Failure: &pickaxx.StartupFailure{Code: "eula", Message: "EULA not accepted", Fix: "accept it"},
+	})
+	require.NoError(t, err)
+
+	var decoded struct {
+		Status  string                 `json:"status"`
+		Failure pickaxx.StartupFailure `json:"failure"`
+	}
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, "Failed", decoded.Status)
+	assert.Equal(t, "eula", decoded.Failure.Code)
+}
+
+func TestStartupFailed(t *testing.T) {
+	m := New(testPort, WithType(ServerType{
+		Name:     "failing",
+		Command:  []string{"sh", "-c", "echo 'Error: world not found' >&2; exec cat"},
+		Probe:    ProbeNone,
+		Failures: testFailures,
+	}), WithWorkingDir(t.TempDir()))
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+
+	var (
+		alert *Alert
+		state stateChangeEvent
+	)
+
+	for data := range activity {
+		switch d := data.(type) {
+		case Alert:
+			alert = &d
+		case stateChangeEvent:
+			state = d
+		}
+	}
+
+	require.NotNil(t, alert, "expected an alert with remediation")
+	assert.Contains(t, alert.Message, "world not found. Create a world")
+
+	assert.Equal(t, Failed, state.State)
+	require.NotNil(t, state.Failure)
+	assert.Equal(t, "noWorld", state.Failure.Code)
+
+	assert.Equal(t, Failed, m.State())
+	assert.False(t, m.Running())
+	require.NotNil(t, m.Health().Failure)
+	assert.Equal(t, "noWorld", m.Health().Failure.Code)
+}
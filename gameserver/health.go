@@ -0,0 +1,159 @@
+package gameserver
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+)
+
+var (
+	_ pickaxx.HealthChecker  = &Manager{}
+	_ pickaxx.HealthReporter = &Manager{}
+	_ pickaxx.InfoReporter   = &Manager{}
+)
+
+const (
+	// startingTimeout is the longest a server may remain 'Starting'.
+	startingTimeout = time.Second * 30
+
+	// stoppingGrace is added to the expected time a server may remain 'Stopping'.
+	stoppingGrace = time.Second * 30
+
+	// probeStaleAfter is how long since the last liveness probe before a running server is unhealthy.
+	probeStaleAfter = time.Second * 30
+)
+
+// Healthy returns an error if the server appears stuck transitioning between states.
+func (m *Manager) Healthy() error {
+	var (
+		state = m.State()
+		opts  = m.stopOptions()
+	)
+
+	m.lock.RLock()
+	since := time.Since(m.stateSince)
+	m.lock.RUnlock()
+
+	switch {
+	case state == Starting && since > startingTimeout:
+		return fmt.Errorf("server starting for %v", since.Round(time.Second))
+	case state == Stopping && since > opts.Countdown+opts.KillTimeout+stoppingGrace:
+		return fmt.Errorf("server stopping for %v", since.Round(time.Second))
+	}
+
+	return nil
+}
+
+// Ready returns an error if the working directory is missing or not writable.
+func (m *Manager) Ready() error {
+	dir := m.WorkingDir
+	if dir == "" {
+		dir = DefaultWorkingDir
+	}
+
+	if err := dirWritable(dir); err != nil {
+		return fmt.Errorf("working directory not writable: %w", err)
+	}
+
+	return nil
+}
+
+// Health returns a summary of the server's current health.
+func (m *Manager) Health() pickaxx.ServerHealth {
+	state := m.State()
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	h := pickaxx.ServerHealth{
+		State:   state.String(),
+		Crashes: atomic.LoadUint64(&m.crashes),
+	}
+
+	if !m.lastProbe.IsZero() {
+		probe := m.lastProbe
+		h.LastProbe = &probe
+	}
+
+	if !m.lastCrash.IsZero() {
+		crash := m.lastCrash
+		h.LastCrash = &crash
+	}
+
+	// a newly running server is healthy until its first probe is due
+	sinceProbe := time.Since(m.stateSince)
+	if m.lastProbe.After(m.stateSince) {
+		sinceProbe = time.Since(m.lastProbe)
+	}
+
+	if state == Failed {
+		h.Failure = m.failures.Failure()
+	}
+
+	h.Healthy = state == Running && (sinceProbe < probeStaleAfter || m.livenessProbe() == nil)
+
+	return h
+}
+
+// Info returns the status last reported by the running server, through its
+// liveness probe and its hooks (e.g. by querying it). This is nil if neither
+// reports status.
+func (m *Manager) Info() *pickaxx.ServerInfo {
+	if !m.currentStateIn(Running, Stopping) {
+		return nil
+	}
+
+	var query *pickaxx.ServerInfo
+	if r, ok := m.Hooks().(pickaxx.InfoReporter); ok {
+		query = r.Info()
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return mergeInfo(m.info, query)
+}
+
+// mergeInfo combines status reported by a probe with that of the hooks (e.g.
+// a query), which is more complete.
+func mergeInfo(probe, query *pickaxx.ServerInfo) *pickaxx.ServerInfo {
+	switch {
+	case query == nil && probe == nil:
+		return nil
+	case query == nil:
+		info := *probe
+		return &info
+	}
+
+	info := *query
+	info.PlayerNames = append([]string{}, query.PlayerNames...)
+	info.Plugins = append([]string{}, query.Plugins...)
+
+	if probe != nil && info.GameMode == "" {
+		info.GameMode = probe.GameMode
+	}
+
+	return &info
+}
+
+// probed records a successful liveness probe, and any status reported.
+func (m *Manager) probed(info *pickaxx.ServerInfo) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.lastProbe = time.Now()
+
+	if info != nil {
+		m.info = info
+	}
+}
+
+// crashed records that the server stopped responding.
+func (m *Manager) crashed() {
+	atomic.AddUint64(&m.crashes, 1)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.lastCrash = time.Now()
+}
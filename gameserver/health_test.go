@@ -0,0 +1,117 @@
+package gameserver
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthy(t *testing.T) {
+	m := &Manager{}
+	assert.NoError(t, m.Healthy())
+
+	m.setState(Starting)
+	assert.NoError(t, m.Healthy())
+
+	m.stateSince = time.Now().Add(-startingTimeout * 2)
+	assert.Error(t, m.Healthy(), "stuck starting")
+}
+
+func TestReady(t *testing.T) {
+	m := &Manager{WorkingDir: t.TempDir()}
+	assert.NoError(t, m.Ready())
+
+	m.WorkingDir = filepath.Join(m.WorkingDir, "missing")
+	assert.Error(t, m.Ready())
+}
+
+func TestHealth(t *testing.T) {
+	m := &Manager{}
+	assert.False(t, m.Health().Healthy)
+
+	m.setState(Running)
+	assert.True(t, m.Health().Healthy, "newly running")
+
+	m.stateSince = time.Now().Add(-probeStaleAfter * 2)
+	assert.False(t, m.Health().Healthy, "no probe")
+
+	m.probed(nil)
+	assert.True(t, m.Health().Healthy)
+	assert.NotNil(t, m.Health().LastProbe)
+
+	m.crashed()
+	assert.Equal(t, uint64(1), m.Health().Crashes)
+	assert.NotNil(t, m.Health().LastCrash)
+}
+
+// infoProbe reports the status of a server, as probed.
+type infoProbe struct {
+	info *pickaxx.ServerInfo
+}
+
+func (p infoProbe) Probe(ctx context.Context, port int) (*pickaxx.ServerInfo, error) {
+	return p.info, nil
+}
+
+// infoHooks report the status of a server, e.g. as queried.
+type infoHooks struct {
+	info *pickaxx.ServerInfo
+}
+
+func (h *infoHooks) Info() *pickaxx.ServerInfo { return h.info }
+
+func TestManagerInfo(t *testing.T) {
+	m := New(testPort, WithProbe(infoProbe{}))
+	assert.Equal(t, infoProbe{}, m.livenessProbe())
+
+	m.setState(Running)
+	assert.Nil(t, m.Info())
+
+	m.probed(&pickaxx.ServerInfo{MOTD: "hello"})
+	m.probed(nil) // probes without status keep the last known status
+	require.NotNil(t, m.Info())
+	assert.Equal(t, "hello", m.Info().MOTD)
+
+	m.setState(Stopped)
+	assert.Nil(t, m.Info())
+
+	m = New(testPort, WithType(ServerType{Name: "x", Probe: ProbeNone}))
+	assert.Nil(t, m.livenessProbe())
+}
+
+func TestManagerInfoFromHooks(t *testing.T) {
+	hooks := &infoHooks{}
+	m := New(testPort, WithType(ServerType{
+		Name:  "x",
+		Hooks: func(*Manager) Hooks { return hooks },
+	}))
+
+	m.setState(Running)
+	m.probed(&pickaxx.ServerInfo{MOTD: "probe", GameMode: "Survival"})
+
+	hooks.info = &pickaxx.ServerInfo{MOTD: "query"}
+	require.NotNil(t, m.Info())
+	assert.Equal(t, "query", m.Info().MOTD)
+	assert.Equal(t, "Survival", m.Info().GameMode)
+}
+
+func TestMergeInfo(t *testing.T) {
+	probe := &pickaxx.ServerInfo{MOTD: "probe", GameMode: "Survival"}
+	query := &pickaxx.ServerInfo{MOTD: "query", PlayerNames: []string{"Steve"}}
+
+	assert.Nil(t, mergeInfo(nil, nil))
+	assert.Equal(t, "probe", mergeInfo(probe, nil).MOTD)
+
+	merged := mergeInfo(probe, query)
+	assert.Equal(t, "query", merged.MOTD)
+	assert.Equal(t, "Survival", merged.GameMode)
+	assert.Equal(t, []string{"Steve"}, merged.PlayerNames)
+
+	merged.PlayerNames[0] = "Alex"
+	assert.Equal(t, "Steve", query.PlayerNames[0], "copied")
+}
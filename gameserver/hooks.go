@@ -0,0 +1,186 @@
+package gameserver
+
+import (
+	"context"
+
+	"github.com/ivan3bx/pickaxx"
+)
+
+var (
+	_ pickaxx.AllowlistManager    = &Manager{}
+	_ pickaxx.JarReporter         = &Manager{}
+	_ pickaxx.JavaLister          = &Manager{}
+	_ pickaxx.PerformanceReporter = &Manager{}
+	_ pickaxx.PluginManager       = &Manager{}
+	_ pickaxx.Upgrader            = &Manager{}
+)
+
+// Hooks extend a manager for a type of server (e.g. reading its settings
+// files, or polling it). Hooks implement any of the interfaces below, which
+// the manager calls as the server is started & run; and any of pickaxx's
+// optional interfaces (e.g. pickaxx.AllowlistManager), which the manager
+// implements by calling its hooks. Those not implemented by the hooks of a
+// type are not supported for it.
+type Hooks interface{}
+
+// PortReader reads the port the server is configured with (e.g. in its
+// settings file).
+type PortReader interface {
+
+	// ConfiguredPort returns the port, or 0 if not configured.
+	ConfiguredPort() int
+}
+
+// Launcher adjusts how the server is launched.
+type Launcher interface {
+
+	// Launch returns the command starting the server, given that of its
+	// type. Unless fresh, choices made for the last launch (e.g. of a Java
+	// runtime) may be reused; the server is launched fresh as it starts.
+	Launch(command []string, fresh bool) ([]string, error)
+}
+
+// Checker adds preflight checks (see pickaxx.Preflighter).
+type Checker interface {
+
+	// Checks checks the server can be started with the given command.
+	Checks(command []string) []pickaxx.Check
+}
+
+// Starter prepares the server, before its process starts.
+type Starter interface {
+
+	// Starting is called before the server process starts (but not as a
+	// server left running is adopted). The server is started even if this
+	// fails.
+	Starting() error
+}
+
+// Resetter clears state of the last run.
+type Resetter interface {
+
+	// Reset is called once the server is running, before its output is read.
+	Reset()
+}
+
+// Observer inspects console output.
+type Observer interface {
+
+	// Observe inspects a line of console output, returning any data to send
+	// to clients.
+	Observe(line string) []pickaxx.Data
+}
+
+// Runner runs alongside the server (e.g. polling it).
+type Runner interface {
+
+	// Run is called once the server is running, and returns once the
+	// context is done.
+	Run(ctx context.Context)
+}
+
+// Announcer warns players the server is stopping, in addition to the
+// countdown sent with the say command of the type.
+type Announcer interface {
+
+	// AnnounceStop is called as a countdown to stopping begins, with the
+	// reason for stopping (if any).
+	AnnounceStop(reason string)
+}
+
+// Allowlist returns the players allowed to join, if supported by the hooks.
+func (m *Manager) Allowlist() (pickaxx.Allowlist, error) {
+	if a, ok := m.Hooks().(pickaxx.AllowlistManager); ok {
+		return a.Allowlist()
+	}
+	return pickaxx.Allowlist{Players: []string{}}, pickaxx.ErrNotSupported
+}
+
+// Allow adds a player to the allowlist, if supported by the hooks.
+func (m *Manager) Allow(name string) error {
+	if a, ok := m.Hooks().(pickaxx.AllowlistManager); ok {
+		return a.Allow(name)
+	}
+	return pickaxx.ErrNotSupported
+}
+
+// Disallow removes a player from the allowlist, if supported by the hooks.
+func (m *Manager) Disallow(name string) error {
+	if a, ok := m.Hooks().(pickaxx.AllowlistManager); ok {
+		return a.Disallow(name)
+	}
+	return pickaxx.ErrNotSupported
+}
+
+// JarInfo describes the server jar, if supported by the hooks.
+func (m *Manager) JarInfo() (*pickaxx.JarInfo, error) {
+	if r, ok := m.Hooks().(pickaxx.JarReporter); ok {
+		return r.JarInfo()
+	}
+	return nil, pickaxx.ErrNotSupported
+}
+
+// JavaRuntimes returns the Java runtimes found, if the hooks look for any.
+func (m *Manager) JavaRuntimes() []pickaxx.JavaRuntime {
+	if l, ok := m.Hooks().(pickaxx.JavaLister); ok {
+		return l.JavaRuntimes()
+	}
+	return []pickaxx.JavaRuntime{}
+}
+
+// Performance returns recent in-game performance samples, oldest first, if
+// the hooks report any.
+func (m *Manager) Performance() []pickaxx.TickStats {
+	if r, ok := m.Hooks().(pickaxx.PerformanceReporter); ok {
+		return r.Performance()
+	}
+	return []pickaxx.TickStats{}
+}
+
+// Plugins returns the plugins installed, if supported by the hooks.
+func (m *Manager) Plugins() (*pickaxx.PluginList, error) {
+	if p, ok := m.Hooks().(pickaxx.PluginManager); ok {
+		return p.Plugins()
+	}
+	return nil, pickaxx.ErrNotSupported
+}
+
+// InstallPlugin installs a plugin, if supported by the hooks.
+func (m *Manager) InstallPlugin(path, name string) (*pickaxx.PluginChange, error) {
+	if p, ok := m.Hooks().(pickaxx.PluginManager); ok {
+		return p.InstallPlugin(path, name)
+	}
+	return nil, pickaxx.ErrNotSupported
+}
+
+// EnablePlugin enables (or disables) a plugin, if supported by the hooks.
+func (m *Manager) EnablePlugin(file string, enabled bool) (*pickaxx.PluginChange, error) {
+	if p, ok := m.Hooks().(pickaxx.PluginManager); ok {
+		return p.EnablePlugin(file, enabled)
+	}
+	return nil, pickaxx.ErrNotSupported
+}
+
+// RemovePlugin removes a plugin, if supported by the hooks.
+func (m *Manager) RemovePlugin(file string) (*pickaxx.PluginChange, error) {
+	if p, ok := m.Hooks().(pickaxx.PluginManager); ok {
+		return p.RemovePlugin(file)
+	}
+	return nil, pickaxx.ErrNotSupported
+}
+
+// Upgrade replaces the server software, if supported by the hooks.
+func (m *Manager) Upgrade(path string, force bool) (*pickaxx.UpgradeResult, error) {
+	if u, ok := m.Hooks().(pickaxx.Upgrader); ok {
+		return u.Upgrade(path, force)
+	}
+	return nil, pickaxx.ErrNotSupported
+}
+
+// Rollback reverts the last upgrade, if supported by the hooks.
+func (m *Manager) Rollback(restoreWorld bool) (*pickaxx.UpgradeResult, error) {
+	if u, ok := m.Hooks().(pickaxx.Upgrader); ok {
+		return u.Rollback(restoreWorld)
+	}
+	return nil, pickaxx.ErrNotSupported
+}
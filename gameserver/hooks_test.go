@@ -0,0 +1,94 @@
+package gameserver
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// launchHooks read a configured port, and add an argument to the command.
+type launchHooks struct {
+	port int
+}
+
+func (h *launchHooks) ConfiguredPort() int { return h.port }
+
+func (h *launchHooks) Launch(command []string, fresh bool) ([]string, error) {
+	return append(command, "--hooked"), nil
+}
+
+func TestLaunchWithHooks(t *testing.T) {
+	typ := ServerType{
+		Name:        "x",
+		Command:     []string{"server", "-port", "{{.Port}}"},
+		DefaultPort: 7777,
+		Hooks:       func(*Manager) Hooks { return &launchHooks{port: 7800} },
+	}
+
+	tests := []struct {
+		name     string
+		port     int
+		hooks    func(*Manager) Hooks
+		expected int
+	}{
+		{"configured", 7900, typ.Hooks, 7900},
+		{"read by hooks", 0, typ.Hooks, 7800},
+		{"type default", 0, func(*Manager) Hooks { return &launchHooks{} }, 7777},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ.Hooks = tt.hooks
+			m := New(tt.port, WithType(typ))
+
+			l, err := m.deriveLaunch(false)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, l.port)
+			assert.Equal(t, []string{"server", "-port", fmt.Sprint(tt.expected), "--hooked"}, l.command)
+		})
+	}
+}
+
+func TestConfigureHooks(t *testing.T) {
+	typ := ServerType{
+		Name:    "x",
+		Command: []string{"true"},
+		Hooks:   func(*Manager) Hooks { return &launchHooks{} },
+	}
+
+	m := New(0, WithType(typ), Configure(func(h Hooks) {
+		h.(*launchHooks).port = 7800
+	}))
+	assert.Equal(t, 7800, m.Hooks().(*launchHooks).ConfiguredPort())
+
+	// hooks are created again for new settings
+	m.Reconfigure(0, WithType(typ))
+	m.applyPending()
+	m.setDefaults()
+	assert.Equal(t, 0, m.Hooks().(*launchHooks).ConfiguredPort())
+}
+
+func TestHooksNotSupported(t *testing.T) {
+	m := New(0, WithType(ServerType{Name: "x", Command: []string{"true"}}))
+	assert.Nil(t, m.Hooks())
+
+	_, err := m.Allowlist()
+	assert.True(t, errors.Is(err, pickaxx.ErrNotSupported))
+	assert.True(t, errors.Is(m.Allow("Steve"), pickaxx.ErrNotSupported))
+
+	_, err = m.JarInfo()
+	assert.True(t, errors.Is(err, pickaxx.ErrNotSupported))
+
+	_, err = m.Plugins()
+	assert.True(t, errors.Is(err, pickaxx.ErrNotSupported))
+
+	_, err = m.Upgrade("server.jar", false)
+	assert.True(t, errors.Is(err, pickaxx.ErrNotSupported))
+
+	assert.Empty(t, m.JavaRuntimes())
+	assert.Empty(t, m.Performance())
+}
@@ -0,0 +1,851 @@
+package gameserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ivan3bx/pickaxx"
+)
+
+// DefaultWorkingDir is the default working directory
+const DefaultWorkingDir = "testserver"
+
+// ErrNoProcess signifies no process exists to take an action on.
+var ErrNoProcess = errors.New("no process running")
+
+// New creates a new process manager for a server of the given type (see
+// WithType). If port is 0, the port is read by the hooks of the type when
+// started (e.g. from the server's settings), or else is that of the type.
+func New(port int, opts ...Option) *Manager {
+	m := &Manager{
+		Port: port,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.setDefaults()
+	return m
+}
+
+// Option configures a process manager.
+type Option func(*Manager)
+
+// WithType manages a server of the given type.
+func WithType(t ServerType) Option {
+	return func(m *Manager) {
+		m.Type = t
+	}
+}
+
+// WithWorkingDir runs the server in the given directory.
+func WithWorkingDir(dir string) Option {
+	return func(m *Manager) {
+		m.WorkingDir = dir
+	}
+}
+
+// WithDetach starts servers detached from pickaxx, so that they survive
+// pickaxx exiting, and can be adopted again when it next starts (see
+// pickaxx.Detacher).
+func WithDetach() Option {
+	return func(m *Manager) {
+		m.detachable = true
+	}
+}
+
+// WithEvents passes events which happen outside of the server process (e.g.
+// backups) to handle, as they may happen while no server is running.
+func WithEvents(handle func(pickaxx.Data)) Option {
+	return func(m *Manager) {
+		m.events = handle
+	}
+}
+
+// Configure applies settings to the hooks of the type, as they are created.
+// Hooks of other types are expected to be ignored (e.g. by a type switch).
+func Configure(apply func(Hooks)) Option {
+	return func(m *Manager) {
+		m.configure = append(m.configure, apply)
+	}
+}
+
+// Reconfigurable is implemented by process managers created by New, whose
+// settings can be changed while pickaxx runs.
+type Reconfigurable interface {
+
+	// Reconfigure replaces the port & options given to New, taking effect
+	// when the server next starts (or restarts). Options for detaching &
+	// events are ignored, keeping those given to New.
+	Reconfigure(port int, opts ...Option)
+}
+
+var (
+	_ Reconfigurable          = &Manager{}
+	_ pickaxx.CommandReporter = &Manager{}
+	_ pickaxx.StatsReporter   = &Manager{}
+	_ pickaxx.PlayerLister    = &Manager{}
+)
+
+// Manager manages the process lifecycle of a game server. Anything
+// particular to a type of server is left to its hooks (see Hooks).
+type Manager struct {
+	// counters, accessed atomically (first, for 64-bit alignment on ARM)
+	restarts      uint64
+	crashes       uint64
+	commands      uint64
+	commandErrors uint64
+
+	// settings, not changed once started (see launched)
+	Type       ServerType // The type of server managed.
+	Command    []string   // Defaults to the command of 'Type' if not set.
+	WorkingDir string     // Defaults to 'DefaultWorkingDir' if not set.
+	Port       int        // Defaults to the port read by the hooks, or that of 'Type', if not set.
+
+	// settings given to Reconfigure, applied on the next start (if set)
+	pending *Manager
+
+	// liveness probe, overriding that of 'Type' if set
+	probe LivenessProbe
+
+	// hooks of 'Type' (see Hooks), and settings applied as they are created
+	hooks     Hooks
+	configure []func(Hooks)
+
+	// Child process
+	proc   process
+	cmdIn  io.Writer
+	cmdOut io.Reader
+	cmdErr io.Reader
+
+	// detached servers
+	detachable bool             // start servers detached
+	console    *detachedConsole // non-nil if the current server is detached
+	detach     chan chan error  // requests to detach, receiving the result
+
+	// state transition
+	state     ServerState
+	lock      sync.RWMutex
+	nextState chan ServerState
+
+	// how the server was last started (or adopted)
+	launched launch
+
+	// options for the current stop request
+	stopOpts pickaxx.StopOptions
+
+	// set once a stop is requested, until the server starts again; and if
+	// requested while starting, to stop as soon as it is running
+	stopRequested   bool
+	stopWhenRunning bool
+
+	// skips the rest of a countdown to stopping, while one is in progress
+	skipCountdown context.CancelFunc
+
+	// non-nil while a restart is in progress; receives the result
+	restartDone chan error
+
+	// run once stopped, before starting again, during a restart (if set)
+	beforeRestart func() error
+
+	// held during maintenance (e.g. backups, or upgrades), see Maintain
+	maintenance sync.Mutex
+
+	// receives events from outside of the server process (if set)
+	events func(pickaxx.Data)
+
+	// observers of state transitions
+	notifier StatusNotifier
+
+	// recent resource usage of the child process
+	stats statsHistory
+
+	// whether the server has finished loading
+	ready readyTracker
+
+	// players currently online
+	players playerTracker
+
+	// startup failures recognized in console output
+	failures failureTracker
+
+	// callers waiting for console output
+	output outputWatch
+
+	// time the server last started running
+	startedAt time.Time
+
+	// health
+	stateSince time.Time // time of the last state transition
+	lastProbe  time.Time // last successful liveness probe
+	lastCrash  time.Time // last time the server stopped responding
+
+	// latest status reported by a liveness probe
+	info *pickaxx.ServerInfo
+}
+
+// Start will initialize a new process, sending all output to the provided
+// channel and set values on this object to track process state.
+// This returns an error if the process is already running.
+func (m *Manager) Start() (<-chan pickaxx.Data, error) {
+	if m.Running() {
+		return nil, fmt.Errorf("server already running: %w", pickaxx.ErrProcessExists)
+	}
+
+	m.applyPending()
+	m.setDefaults()
+
+	l, err := m.prepareLaunch()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.preflight(l); err != nil {
+		return nil, err
+	}
+
+	m.nextState = make(chan ServerState, 1)
+	m.detach = make(chan chan error)
+	m.resetStop()
+	activity := make(chan pickaxx.Data, 10)
+
+	// start processing state changes
+	go eventLoop(m, activity)
+
+	// progress to next state
+	m.nextState <- Starting
+
+	return activity, nil
+}
+
+// Reconfigure replaces the port & options given to New, as the server next
+// starts. See Reconfigurable.
+func (m *Manager) Reconfigure(port int, opts ...Option) {
+	n := &Manager{Port: port}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	m.lock.Lock()
+	m.pending = n
+	m.lock.Unlock()
+}
+
+// applyPending applies settings given to Reconfigure, if any. This is only
+// called as the server starts.
+func (m *Manager) applyPending() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	n := m.pending
+	if n == nil {
+		return
+	}
+
+	m.Type, m.WorkingDir, m.Port = n.Type, n.WorkingDir, n.Port
+	m.probe, m.configure = n.probe, n.configure
+	m.hooks, m.pending = nil, nil // created again for the new settings
+}
+
+// launch is how the server is started.
+type launch struct {
+	port    int
+	command []string
+}
+
+// setDefaults sets the working directory, if not set, and creates the hooks
+// of the type.
+func (m *Manager) setDefaults() {
+	if m.WorkingDir == "" {
+		m.WorkingDir = DefaultWorkingDir
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.hooks != nil || m.Type.Hooks == nil {
+		return
+	}
+
+	m.hooks = m.Type.Hooks(m)
+	for _, apply := range m.configure {
+		apply(m.hooks)
+	}
+}
+
+// Hooks returns the hooks of the type of server managed, or nil if it has
+// none.
+func (m *Manager) Hooks() Hooks {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.hooks
+}
+
+// deriveLaunch returns how the server would be started now, from its
+// settings & hooks, without changing the manager. Unless fresh, hooks may
+// reuse choices made for the last launch (see Launcher).
+func (m *Manager) deriveLaunch(fresh bool) (launch, error) {
+	var (
+		l     = launch{port: m.Port}
+		hooks = m.Hooks()
+	)
+
+	if r, ok := hooks.(PortReader); ok && l.port == 0 {
+		l.port = r.ConfiguredPort()
+	}
+
+	if l.port == 0 {
+		l.port = m.Type.DefaultPort
+	}
+
+	if len(m.Command) > 0 {
+		l.command = append([]string{}, m.Command...)
+		return l, nil
+	}
+
+	if len(m.Type.Command) == 0 {
+		return l, errors.New("no server type, or its command is empty")
+	}
+
+	cmd, err := m.Type.command(l.port, m.WorkingDir)
+	if err != nil {
+		return l, err
+	}
+
+	if launcher, ok := hooks.(Launcher); ok {
+		if cmd, err = launcher.Launch(cmd, fresh); err != nil {
+			return l, err
+		}
+	}
+
+	l.command = cmd
+	return l, nil
+}
+
+// prepareLaunch derives how the server is to be started, recording it as
+// how the server was launched. This is only called as the server starts.
+func (m *Manager) prepareLaunch() (launch, error) {
+	l, err := m.deriveLaunch(true)
+	if err != nil {
+		return l, err
+	}
+
+	m.lock.Lock()
+	m.launched = l
+	m.lock.Unlock()
+
+	return l, nil
+}
+
+// currentLaunch returns how the server is running, or else how it would be
+// started now.
+func (m *Manager) currentLaunch() (launch, error) {
+	m.lock.RLock()
+	l, stopped := m.launched, m.stateIn(Unknown, Stopped, Failed)
+	m.lock.RUnlock()
+
+	if !stopped && len(l.command) > 0 {
+		return l, nil
+	}
+
+	return m.deriveLaunch(false)
+}
+
+// port returns the port the server was last started on, or else that
+// configured.
+func (m *Manager) port() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if m.launched.port != 0 {
+		return m.launched.port
+	}
+	return m.Port
+}
+
+// CommandLine returns the command, and its arguments, the server is running
+// with, or else would be started with.
+func (m *Manager) CommandLine() ([]string, error) {
+	l, err := m.currentLaunch()
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{}, l.command...), nil
+}
+
+// StartedAt returns the time the server last started running.
+func (m *Manager) StartedAt() time.Time {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.startedAt
+}
+
+// workingDir returns the server's working directory, even if not yet initialized.
+func (m *Manager) workingDir() string {
+	if m.WorkingDir == "" {
+		return DefaultWorkingDir
+	}
+	return m.WorkingDir
+}
+
+// Stop will halt the current process by sending a shutdown command.
+// This will kill the process if it does not respond in a given timeframe.
+// Options may add a countdown to warn players, save the world first, or
+// provide a reason shown to players. Calling Stop again during a countdown
+// skips the rest of it.
+func (m *Manager) Stop(opts ...pickaxx.StopOption) error {
+	log := log.WithField("action", "ProcessManager.Stop()")
+
+	// stopping again during a countdown stops now
+	m.lock.Lock()
+	if skip := m.skipCountdown; m.state == Stopping && skip != nil {
+		m.lock.Unlock()
+		skip()
+		return nil
+	}
+
+	// only one stop is queued: the event loop also sends to nextState, and
+	// would block on a second
+	if m.stopRequested || !m.stateIn(Starting, Running) {
+		m.lock.Unlock()
+		log.Info("not running")
+		return ErrNoProcess
+	}
+
+	m.stopRequested = true
+	m.stopOpts = pickaxx.NewStopOptions(opts...)
+
+	// a server still starting is stopped once running (see eventLoop)
+	starting := m.state == Starting
+	m.stopWhenRunning = starting
+	m.lock.Unlock()
+
+	if !starting {
+		m.nextState <- Stopping
+	}
+	return nil
+}
+
+// Restart will stop the current process and start it again. Activity for
+// the new process continues on the channel returned by the original call to
+// Start. This blocks until the server is running again, returning an error
+// if either stopping or starting the server fails.
+func (m *Manager) Restart(opts ...pickaxx.StopOption) error {
+	return m.RestartWith(nil, opts...)
+}
+
+// RestartWith stops & starts the server, as Restart. If set, before is run
+// once the server has stopped (e.g. to replace its files); the server is
+// started again even if it fails.
+func (m *Manager) RestartWith(before func() error, opts ...pickaxx.StopOption) error {
+	done := make(chan error, 1)
+
+	m.lock.Lock()
+	if m.restartDone != nil {
+		m.lock.Unlock()
+		return errors.New("restart already in progress")
+	}
+	m.restartDone, m.beforeRestart = done, before
+	m.lock.Unlock()
+
+	if err := m.Stop(opts...); err != nil {
+		m.finishRestart(err)
+		return err
+	}
+
+	if err := <-done; err != nil {
+		return fmt.Errorf("restart failed: %w", err)
+	}
+
+	atomic.AddUint64(&m.restarts, 1)
+	return nil
+}
+
+// restarting returns true if a restart is in progress.
+func (m *Manager) restarting() bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.restartDone != nil
+}
+
+// finishRestart reports the result of a restart, if one is in progress.
+func (m *Manager) finishRestart(err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.restartDone != nil {
+		m.restartDone <- err
+		m.restartDone, m.beforeRestart = nil, nil
+	}
+}
+
+// runBeforeRestart runs the function set to run before restarting, if any.
+func (m *Manager) runBeforeRestart() error {
+	m.lock.RLock()
+	before := m.beforeRestart
+	m.lock.RUnlock()
+
+	if before == nil {
+		return nil
+	}
+	return before()
+}
+
+// Submit will submit a new command to the underlying server.
+// Any output is returned asynchonously in the processing loop.
+// Prefixed slash-commands will have slashes trimmed (e.g. "/help" -> "help").
+// Commands submitted here are counted in metrics; those sent by pickaxx
+// itself (e.g. to poll performance, or stop the server) use Send.
+func (m *Manager) Submit(command string) error {
+	atomic.AddUint64(&m.commands, 1)
+
+	err := m.Send(command)
+	if err != nil {
+		atomic.AddUint64(&m.commandErrors, 1)
+	}
+
+	return err
+}
+
+// Send writes a command to the server's console, as Submit, without
+// counting it.
+func (m *Manager) Send(command string) error {
+	if !m.currentStateIn(Running, Stopping) {
+		return ErrNoProcess
+	}
+
+	if len(command) == 0 {
+		return errors.New("command is empty")
+	}
+
+	if command[0] == '/' {
+		command = command[1:]
+	}
+
+	_, err := io.WriteString(m.cmdIn, command+"\n")
+	return err
+}
+
+// State returns the current state of the server.
+func (m *Manager) State() ServerState {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.state
+}
+
+// Running returns whether the process is running.
+func (m *Manager) Running() bool {
+	return m.currentStateIn(Starting, Running)
+}
+
+// eventLoop processes state transitions.
+func eventLoop(m *Manager, out chan<- pickaxx.Data) {
+	var (
+		log = log.WithField("action", "eventLoop")
+		wg  = sync.WaitGroup{}
+
+		newState ServerState
+		detached chan error // receives the result of detaching, once complete
+		exited   chan error // receives the result of waiting for the running process
+		err      error
+	)
+
+	mainCtx := context.Background()
+	stopRunning := func() {} // replaced each time the server is running
+
+	defer m.finishRestart(ErrNoProcess) // never leave a restart waiting
+
+	defer func() {
+		stopRunning()
+		log.Debug("waiting for child processes to quit")
+		wg.Wait() // wait for any child routines to quit
+
+		log.Debug("closing activity channel")
+		close(out)
+
+		if detached != nil {
+			detached <- nil
+		}
+	}()
+
+	for {
+		// blocks until next state transition event
+		select {
+		case next := <-m.nextState:
+			newState = m.setState(next)
+		case reply := <-m.detach:
+			if m.console == nil || newState != Running {
+				reply <- errors.New("server was not started detached")
+				continue
+			}
+
+			out <- consoleOutput{Text: "Detached. Server is still running."}
+			m.console.Close()
+			m.setState(Unknown)
+			detached = reply
+			return
+		case err := <-exited:
+			exited = nil
+
+			// unless stopping, the server exited by itself
+			if !m.claimStop(pickaxx.WithReason("process exited")) {
+				continue
+			}
+
+			log.WithError(err).Warn("server exited unexpectedly")
+			stopRunning()
+			m.crashed()
+			out <- crashEvent{Reason: "process exited", Time: time.Now()}
+			out <- consoleOutput{Text: "Process exited unexpectedly."}
+
+			if m.console != nil {
+				m.console.Close()
+				m.console.remove()
+			}
+
+			newState = m.setState(Stopped)
+		}
+
+		switch newState {
+		case Starting:
+			out <- consoleOutput{Text: "Server is starting"}
+
+			if _, err = startServer(mainCtx, m); err != nil {
+				log.WithError(err).Error("failed to start server")
+				m.finishRestart(err)
+			}
+		case Running:
+			m.finishRestart(nil)
+			runCtx, cancel := context.WithCancel(mainCtx)
+			stopRunning = cancel
+			hooks := m.Hooks()
+			m.ready.Reset(m.Type.Ready)
+			m.players.Reset(m.Type.Parsers)
+			m.failures.Reset(m.Type.Failures, m.Type.Ready)
+
+			if r, ok := hooks.(Resetter); ok {
+				r.Reset()
+			}
+
+			m.lock.Lock()
+			m.startedAt = m.proc.StartedAt()
+			m.info = nil
+			m.lock.Unlock()
+
+			// read stdout & stderr concurrently
+			streams := map[string]io.Reader{pickaxx.Stdout: m.cmdOut, pickaxx.Stderr: m.cmdErr}
+			observers := []func(string) []pickaxx.Data{m.ready.Observe, m.players.Observe, m.observeFailures, m.output.Observe}
+
+			if o, ok := hooks.(Observer); ok {
+				observers = append(observers, o.Observe)
+			}
+
+			for stream, r := range streams {
+				wg.Add(1)
+				go func(r io.Reader, stream string) {
+					defer wg.Done()
+					pipeOutput(r, stream, out, observers...)
+				}(r, stream)
+			}
+
+			// notice the process exiting, even if it is not probed. This is not
+			// waited for on return, as a detached process outlives the loop.
+			exited = make(chan error, 1)
+			go func(proc process, exited chan<- error) {
+				exited <- proc.Wait()
+			}(m.proc, exited)
+
+			// start liveness probe
+			if probe := m.livenessProbe(); probe != nil {
+				wg.Add(1)
+				go func(ctx context.Context, probe LivenessProbe, delay time.Duration) {
+					defer wg.Done()
+					if err := checkPort(ctx, probe, m.port(), m.Loaded, delay, probeInterval, m.probed); err != nil {
+						m.crashed()
+						out <- crashEvent{Reason: "process not responding", Time: time.Now()}
+						out <- consoleOutput{Text: "Process not responding. Initiating shutdown."}
+						m.Stop(pickaxx.WithReason("process not responding"))
+					}
+				}(runCtx, probe, m.Type.probeDelay())
+			}
+
+			// sample resource usage
+			wg.Add(1)
+			go func(ctx context.Context, pid int) {
+				defer wg.Done()
+				sampleStats(ctx, pid, &m.stats, statsInterval, out)
+			}(runCtx, m.proc.Pid())
+
+			// run hooks alongside the server (e.g. polling it)
+			if r, ok := hooks.(Runner); ok {
+				wg.Add(1)
+				go func(ctx context.Context) {
+					defer wg.Done()
+					r.Run(ctx)
+				}(runCtx)
+			}
+
+			// stop requested while starting
+			m.lock.Lock()
+			stop := m.stopWhenRunning
+			m.stopWhenRunning = false
+			m.lock.Unlock()
+
+			if stop {
+				m.nextState <- Stopping
+			}
+		case Stopping:
+			out <- consoleOutput{Text: "Shutting down.."}
+			stopRunning()
+			exited = nil // stopServer waits for the process
+
+			// observers are notified before any countdown begins
+			m.notifier.Notify(newState)
+			out <- stateChangeEvent{State: newState, Reason: m.stopReason()}
+
+			stopServer(mainCtx, m, m.stopOptions())
+			continue
+		case Stopped:
+			if failure := m.failures.Failure(); failure != nil {
+				newState = m.setState(Failed)
+				out <- consoleOutput{Text: fmt.Sprintf("Server failed to start: %s", failure.Message)}
+
+				m.notifier.Notify(newState)
+				out <- stateChangeEvent{State: newState, Reason: failure.Message, Failure: failure}
+
+				m.finishRestart(fmt.Errorf("server failed to start: %s", failure.Message))
+				return
+			}
+
+			out <- consoleOutput{Text: "Shutdown complete. Thanks for playing."}
+		}
+
+		m.notifier.Notify(newState)
+		out <- stateChangeEvent{State: newState, Reason: m.stopReason()}
+
+		if newState == Stopped && m.restarting() {
+			out <- consoleOutput{Text: "Restarting.."}
+
+			if err = m.runBeforeRestart(); err != nil {
+				out <- consoleOutput{Text: err.Error()}
+			}
+
+			// routines of the last run may read settings until they quit
+			wg.Wait()
+			m.applyPending()
+			m.setDefaults()
+
+			l, err := m.prepareLaunch()
+			if err != nil {
+				m.finishRestart(err)
+				return
+			}
+
+			if err = m.preflight(l); err != nil {
+				out <- consoleOutput{Text: err.Error()}
+				m.finishRestart(err)
+				return
+			}
+
+			m.resetStop()
+			m.nextState <- Starting
+			continue
+		}
+
+		if newState == Stopped {
+			return
+		}
+	}
+}
+
+// Stats returns recent resource usage samples of the server process, oldest first.
+func (m *Manager) Stats() []pickaxx.ProcessStats {
+	return m.stats.List()
+}
+
+// Players returns names of players currently online, sorted.
+func (m *Manager) Players() []string {
+	if !m.currentStateIn(Running, Stopping) {
+		return []string{}
+	}
+	return m.players.List()
+}
+
+// currentStateIn returns true if process is in any of the provided states.
+func (m *Manager) currentStateIn(states ...ServerState) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.stateIn(states...)
+}
+
+// stateIn is currentStateIn, with the lock held.
+func (m *Manager) stateIn(states ...ServerState) bool {
+	for _, s := range states {
+		if m.state == s {
+			return true
+		}
+	}
+	return false
+}
+
+// stopOptions returns the options provided for the current stop request.
+func (m *Manager) stopOptions() pickaxx.StopOptions {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if m.stopOpts.KillTimeout == 0 {
+		return pickaxx.NewStopOptions()
+	}
+	return m.stopOpts
+}
+
+// claimStop records a stop, as Stop, returning false if the server is not
+// running or a stop was already requested. The caller is to stop the server.
+func (m *Manager) claimStop(opts ...pickaxx.StopOption) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.stopRequested || m.state != Running {
+		return false
+	}
+
+	m.stopRequested = true
+	m.stopOpts = pickaxx.NewStopOptions(opts...)
+	return true
+}
+
+// resetStop clears any stop request, as the server is started.
+func (m *Manager) resetStop() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.stopOpts = pickaxx.StopOptions{}
+	m.stopRequested, m.stopWhenRunning = false, false
+}
+
+// stopReason returns the reason for stopping, if the server is stopping.
+func (m *Manager) stopReason() string {
+	if !m.currentStateIn(Stopping, Stopped) {
+		return ""
+	}
+	return m.stopOptions().Reason
+}
+
+func (m *Manager) setState(newState ServerState) ServerState {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	log.WithField("state", fmt.Sprintf("%v->%v", m.state, newState)).Info("state transition")
+	m.state = newState
+	m.stateSince = time.Now()
+
+	return newState
+}
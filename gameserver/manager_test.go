@@ -0,0 +1,283 @@
+package gameserver
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPort is the port of servers started by tests.
+const testPort = 25565
+
+func init() {
+	log.SetLevel(log.ErrorLevel)
+}
+func TestNewServerManager(t *testing.T) {
+	t.Run("initialized state", func(t *testing.T) {
+		m := New(testPort)
+
+		assert.False(t, m.Running())
+		assert.Error(t, m.Stop(), "expected error on newly initialized server")
+		assert.Error(t, m.Submit("/list"), "expect error on newly initialized server")
+	})
+
+	t.Run("starting", func(t *testing.T) {
+		var m *Manager
+
+		tests := []struct {
+			name      string
+			checkFunc func(t *testing.T, activity <-chan pickaxx.Data)
+		}{
+			{
+				name: "is running",
+				checkFunc: func(t *testing.T, activity <-chan pickaxx.Data) {
+					assertAsync(t, func() bool { return m.state == Running })
+				},
+			},
+			{
+				name: "has I/O configured",
+				checkFunc: func(t *testing.T, activity <-chan pickaxx.Data) {
+					assertAsync(t, func() bool { return m.cmdIn != nil })
+				},
+			},
+			{
+				name: "sends status to clients",
+				checkFunc: func(t *testing.T, activity <-chan pickaxx.Data) {
+					assertAsync(t, func() bool {
+						output, _ := json.Marshal(<-activity)
+						return strings.Contains(string(output), `{"status":"Running"}`)
+					})
+				},
+			},
+			{
+				name: "sends commands to executable",
+				checkFunc: func(t *testing.T, activity <-chan pickaxx.Data) {
+					m.Submit("unique-input-string-123")
+					assertAsync(t, func() bool {
+						output, _ := json.Marshal(<-activity)
+						return strings.Contains(string(output), `unique-input-string-123`)
+					})
+				},
+			},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+
+				// new process manager
+				m = &Manager{
+					Command:    []string{"cat"}, // simple input/output executable
+					WorkingDir: os.TempDir(),
+				}
+
+				// create channels to observe state transitions
+				isRunning := m.notifier.Register(Running)
+
+				defer func() {
+					if m.proc != nil {
+						m.proc.Kill() // test process must be killed
+						m.Stop()
+					}
+					m.notifier.Unregister(isRunning)
+				}()
+
+				// Start the server
+				activity, err := m.Start()
+
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				<-isRunning
+
+				// Run our test
+				tc.checkFunc(t, activity)
+			})
+		}
+	})
+}
+
+func TestRestart(t *testing.T) {
+	t.Run("not running", func(t *testing.T) {
+		m := New(testPort)
+		assert.Error(t, m.Restart())
+	})
+
+	t.Run("continues activity", func(t *testing.T) {
+		m := &Manager{
+			Command:    []string{"cat"},
+			WorkingDir: os.TempDir(),
+		}
+
+		isRunning := m.notifier.Register(Running)
+		defer m.notifier.Unregister(isRunning)
+
+		activity, err := m.Start()
+		if !assert.NoError(t, err) {
+			return
+		}
+		<-isRunning
+
+		// collect status changes until activity ends
+		statuses := []string{}
+		done := make(chan bool)
+		go func() {
+			for data := range activity {
+				if ev, ok := data.(stateChangeEvent); ok {
+					statuses = append(statuses, ev.State.String())
+				}
+			}
+			close(done)
+		}()
+
+		firstProcess := m.proc
+
+		// 'cat' ignores the stop command; force a quick kill
+		assert.NoError(t, m.Restart(pickaxx.WithKillTimeout(time.Millisecond*50)))
+		assert.True(t, m.Running())
+		assert.NotEqual(t, firstProcess, m.proc)
+
+		m.Stop(pickaxx.WithKillTimeout(time.Millisecond * 50))
+		<-done
+
+		assert.Equal(t, []string{
+			"Starting", "Running", "Stopping", "Stopped",
+			"Starting", "Running", "Stopping", "Stopped",
+		}, statuses)
+	})
+}
+
+func TestReconfigure(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+
+	m := &Manager{
+		Command:    []string{"cat"},
+		WorkingDir: first,
+	}
+
+	isRunning := m.notifier.Register(Running)
+	defer m.notifier.Unregister(isRunning)
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+	<-isRunning
+
+	done := drain(activity)
+
+	// the running server is unchanged
+	m.Reconfigure(25566, WithWorkingDir(second), WithProbe(TCPProbe{}))
+	assert.Equal(t, first, m.WorkingDir)
+	assert.Nil(t, m.probe)
+
+	// settings apply as it restarts
+	require.NoError(t, m.Restart(pickaxx.WithKillTimeout(time.Millisecond*50)))
+	assert.Equal(t, second, m.WorkingDir)
+	assert.Equal(t, 25566, m.port())
+	assert.Equal(t, TCPProbe{}, m.probe)
+
+	m.Stop(pickaxx.WithKillTimeout(time.Millisecond * 50))
+	<-done
+}
+
+func TestServerExits(t *testing.T) {
+	m := &Manager{
+		Type:       ServerType{Name: "custom", Probe: ProbeNone},
+		Command:    []string{"sh", "-c", "sleep 0.2; exit 3"},
+		WorkingDir: t.TempDir(),
+	}
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+
+	var (
+		crashed bool
+		stopped stateChangeEvent
+	)
+
+	timeout := time.After(time.Second * 5)
+
+loop:
+	for {
+		select {
+		case data, ok := <-activity:
+			if !ok {
+				break loop
+			}
+			switch ev := data.(type) {
+			case crashEvent:
+				crashed = ev.Reason == "process exited"
+			case stateChangeEvent:
+				stopped = ev
+			}
+		case <-timeout:
+			require.Fail(t, "server still running after exiting")
+		}
+	}
+
+	assert.True(t, crashed, "crash not reported")
+	assert.Equal(t, Stopped, stopped.State)
+	assert.Equal(t, "process exited", stopped.Reason)
+	assert.Equal(t, Stopped, m.State())
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&m.crashes))
+	assert.NoError(t, m.Healthy())
+
+	// stopping is no longer possible, and it may be started again
+	assert.Equal(t, ErrNoProcess, m.Stop())
+
+	activity, err = m.Start()
+	require.NoError(t, err)
+	<-drain(activity)
+}
+
+func assertAsync(t *testing.T, testFunc func() bool, msgs ...string) {
+	const (
+		timeout = time.Millisecond * 300
+		tick    = time.Millisecond * 2
+	)
+	assert.Eventually(t, testFunc, timeout, tick, msgs)
+}
+
+func TestCommandLineWhileRestarting(t *testing.T) {
+	m := New(testPort, WithType(ServerType{
+		Name:    "echo",
+		Command: []string{"cat"},
+		Probe:   ProbeNone,
+	}), WithWorkingDir(t.TempDir()))
+
+	isRunning := m.notifier.Register(Running)
+	defer m.notifier.Unregister(isRunning)
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+	<-isRunning
+
+	done := drain(activity)
+
+	// reading the command line never changes the manager
+	stop := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				cmd, err := m.CommandLine()
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"cat"}, cmd)
+			}
+		}
+	}()
+
+	require.NoError(t, m.Restart(pickaxx.WithKillTimeout(time.Millisecond*50)))
+	close(stop)
+
+	require.NoError(t, m.Stop(pickaxx.WithKillTimeout(time.Millisecond*50)))
+	<-done
+}
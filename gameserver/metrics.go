@@ -0,0 +1,95 @@
+package gameserver
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+)
+
+var _ pickaxx.MetricsCollector = &Manager{}
+
+// allStates are all states a server may be in, reported as one gauge per state.
+var allStates = []ServerState{Unknown, Starting, Running, Stopping, Stopped, Failed}
+
+// Collect returns metrics for this server.
+func (m *Manager) Collect() []pickaxx.Metric {
+	var (
+		server  = pickaxx.Labels{"server": strconv.Itoa(m.port())}
+		state   = m.State()
+		states  = []pickaxx.Sample{}
+		uptime  float64
+		latest  pickaxx.ProcessStats
+		tps     = []pickaxx.Sample{}
+		history = m.Stats()
+		perf    = m.Performance()
+	)
+
+	for _, s := range allStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		states = append(states, pickaxx.Sample{Labels: withState(server, s), Value: value})
+	}
+
+	m.lock.RLock()
+	if state == Running && !m.startedAt.IsZero() {
+		uptime = time.Since(m.startedAt).Seconds()
+	}
+	m.lock.RUnlock()
+
+	if len(history) > 0 && state == Running {
+		latest = history[len(history)-1]
+	}
+
+	if len(perf) > 0 && state == Running {
+		tps = append(tps, pickaxx.Sample{Labels: server, Value: perf[len(perf)-1].TPS})
+	}
+
+	return []pickaxx.Metric{
+		{
+			Name:    "pickaxx_server_state",
+			Help:    "Current state of the server (1 for the active state).",
+			Type:    pickaxx.Gauge,
+			Samples: states,
+		},
+		gauge("pickaxx_server_uptime_seconds", "Time since the server started running.", server, uptime),
+		counter("pickaxx_server_restarts_total", "Number of restarts.", server, atomic.LoadUint64(&m.restarts)),
+		counter("pickaxx_server_crashes_total", "Number of times the server stopped responding.", server, atomic.LoadUint64(&m.crashes)),
+		gauge("pickaxx_server_players_online", "Number of players online.", server, float64(len(m.players.List()))),
+		gauge("pickaxx_process_cpu_percent", "CPU used by the server process, as a percent of one CPU.", server, latest.CPUPercent),
+		gauge("pickaxx_process_resident_memory_bytes", "Resident memory of the server process.", server, float64(latest.RSS)),
+		{
+			Name:    "pickaxx_server_tps",
+			Help:    "Most recent ticks per second reported by the server.",
+			Type:    pickaxx.Gauge,
+			Samples: tps,
+		},
+		counter("pickaxx_commands_submitted_total", "Number of commands submitted to the server by clients (e.g. the API).", server, atomic.LoadUint64(&m.commands)),
+		counter("pickaxx_command_errors_total", "Number of commands from clients which could not be submitted.", server, atomic.LoadUint64(&m.commandErrors)),
+	}
+}
+
+func gauge(name, help string, labels pickaxx.Labels, value float64) pickaxx.Metric {
+	return pickaxx.Metric{
+		Name:    name,
+		Help:    help,
+		Type:    pickaxx.Gauge,
+		Samples: []pickaxx.Sample{{Labels: labels, Value: value}},
+	}
+}
+
+func counter(name, help string, labels pickaxx.Labels, value uint64) pickaxx.Metric {
+	return pickaxx.Metric{
+		Name:    name,
+		Help:    help,
+		Type:    pickaxx.Counter,
+		Samples: []pickaxx.Sample{{Labels: labels, Value: float64(value)}},
+	}
+}
+
+func withState(l pickaxx.Labels, s ServerState) pickaxx.Labels {
+	return pickaxx.Labels{"server": l["server"], "state": s.String()}
+}
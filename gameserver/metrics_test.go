@@ -0,0 +1,24 @@
+package gameserver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollect(t *testing.T) {
+	m := &Manager{Port: testPort}
+	m.Submit("list") // fails; not running
+	m.Send("tps")    // sent by pickaxx itself, so not counted
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, pickaxx.WriteMetrics(&buf, m))
+
+	out := buf.String()
+	assert.Contains(t, out, `pickaxx_server_state{server="25565",state="Unknown"} 1`)
+	assert.Contains(t, out, `pickaxx_server_state{server="25565",state="Running"} 0`)
+	assert.Contains(t, out, `pickaxx_commands_submitted_total{server="25565"} 1`)
+	assert.Contains(t, out, `pickaxx_command_errors_total{server="25565"} 1`)
+}
@@ -0,0 +1,102 @@
+package gameserver
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/ivan3bx/pickaxx"
+)
+
+var _ pickaxx.Event = &playerEvent{}
+
+// playerEvent represents a player joining or leaving the server.
+type playerEvent struct {
+	Name   string `json:"name"`
+	Action string `json:"action"` // "joined" or "left"
+}
+
+// MarshalJSON converts this output to valid JSON.
+func (d playerEvent) MarshalJSON() ([]byte, error) {
+	type player playerEvent // avoid recursion
+	return json.Marshal(map[string]player{"player": player(d)})
+}
+
+// EventType identifies this kind of event.
+func (d playerEvent) EventType() string { return "player" }
+
+// playerTracker tracks players currently online. This implementation
+// can be accessed concurrently by multiple goroutines.
+type playerTracker struct {
+	sync.Mutex
+	online  map[string]bool
+	parsers []LineParser
+}
+
+// Reset clears all players (e.g. when the server starts), and recognizes
+// players joining & leaving using the given parsers.
+func (t *playerTracker) Reset(parsers []LineParser) {
+	t.Lock()
+	defer t.Unlock()
+	t.online = map[string]bool{}
+	t.parsers = append([]LineParser{}, parsers...)
+}
+
+// Observe inspects a line of console output, returning any data to send to clients.
+func (t *playerTracker) Observe(line string) []pickaxx.Data {
+	t.Lock()
+	defer t.Unlock()
+
+	event, ok := parsePlayerEvent(t.parsers, line)
+	if !ok {
+		return nil
+	}
+
+	if t.online == nil {
+		t.online = map[string]bool{}
+	}
+
+	if event.Action == "joined" {
+		t.online[event.Name] = true
+	} else {
+		delete(t.online, event.Name)
+	}
+
+	return []pickaxx.Data{event}
+}
+
+// parsePlayerEvent returns the first player event recognized in a line.
+func parsePlayerEvent(parsers []LineParser, line string) (playerEvent, bool) {
+	for _, p := range parsers {
+		var action string
+
+		switch p.Event {
+		case EventPlayerJoined:
+			action = "joined"
+		case EventPlayerLeft:
+			action = "left"
+		default:
+			continue
+		}
+
+		if match := p.Pattern.FindStringSubmatch(line); len(match) > 1 {
+			return playerEvent{Name: match[1], Action: action}, true
+		}
+	}
+
+	return playerEvent{}, false
+}
+
+// List returns names of players online, sorted.
+func (t *playerTracker) List() []string {
+	t.Lock()
+	defer t.Unlock()
+
+	names := make([]string, 0, len(t.online))
+	for name := range t.online {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,43 @@
+package gameserver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testParsers recognize players joining & leaving, e.g. "Steve has joined."
+var testParsers = []LineParser{
+	{MustPattern(`^(\w+) has joined\.$`), EventPlayerJoined},
+	{MustPattern(`^(\w+) has left\.$`), EventPlayerLeft},
+}
+
+func TestPlayerTracker(t *testing.T) {
+	tr := playerTracker{}
+	tr.Reset(testParsers)
+
+	data := tr.Observe("Steve has joined.")
+	if assert.Len(t, data, 1) {
+		bo, _ := json.Marshal(data[0])
+		assert.Equal(t, `{"player":{"name":"Steve","action":"joined"}}`, string(bo))
+	}
+
+	tr.Observe("Alex has joined.")
+	assert.Equal(t, []string{"Alex", "Steve"}, tr.List())
+
+	tr.Observe("Steve has left.")
+	assert.Equal(t, []string{"Alex"}, tr.List())
+
+	assert.Nil(t, tr.Observe("<Alex> Steve has joined."), "chat is ignored")
+
+	tr.Reset(testParsers)
+	assert.Empty(t, tr.List())
+}
+
+func TestPlayerTrackerWithoutParsers(t *testing.T) {
+	tr := playerTracker{}
+
+	assert.Nil(t, tr.Observe("Steve has joined."))
+	assert.Empty(t, tr.List())
+}
@@ -0,0 +1,182 @@
+package gameserver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/ivan3bx/pickaxx"
+)
+
+var _ pickaxx.Preflighter = &Manager{}
+
+// minFreeDisk is the least free disk space needed to start a server.
+const minFreeDisk = 1 << 30
+
+// Names of preflight checks
+const (
+	CheckConfig     = "config"
+	CheckWorkingDir = "workingDir"
+	CheckDiskSpace  = "diskSpace"
+	CheckExecutable = "executable"
+	CheckPort       = "port"
+)
+
+// Preflight checks that the server can be started, returning the result of
+// every check. Start fails if any do not pass.
+func (m *Manager) Preflight() []pickaxx.Check {
+	l, err := m.deriveLaunch(false)
+	if err != nil {
+		return []pickaxx.Check{failed(CheckConfig, err.Error(), "Check the server type & command in the configuration file.")}
+	}
+
+	return m.preflightChecks(l)
+}
+
+// preflightChecks checks that the server can be started as given, with any
+// checks of its hooks (see Checker).
+func (m *Manager) preflightChecks(l launch) []pickaxx.Check {
+	checks := []pickaxx.Check{
+		checkWorkingDir(m.WorkingDir),
+		checkDiskSpace(m.WorkingDir, minFreeDisk),
+		checkExecutable(m.WorkingDir, l.command[0]),
+	}
+
+	if c, ok := m.Hooks().(Checker); ok {
+		checks = append(checks, c.Checks(l.command)...)
+	}
+
+	// the server is expected to be using its port, until stopped
+	if m.currentStateIn(Unknown, Stopped, Failed) {
+		checks = append(checks, checkPortFree(m.Type.network(), l.port))
+	}
+
+	return checks
+}
+
+// preflight returns an error if any preflight check of how the server is
+// to be started fails.
+func (m *Manager) preflight(l launch) error {
+	if failed := pickaxx.FailedChecks(m.preflightChecks(l)); len(failed) > 0 {
+		return &pickaxx.PreflightError{Failed: failed}
+	}
+	return nil
+}
+
+func passed(name, message string) pickaxx.Check {
+	return pickaxx.Check{Name: name, Passed: true, Message: message}
+}
+
+func failed(name, message, fix string) pickaxx.Check {
+	return pickaxx.Check{Name: name, Message: message, Fix: fix}
+}
+
+func checkWorkingDir(dir string) pickaxx.Check {
+	fix := fmt.Sprintf("Create the directory '%s', and make sure it is writable by the user running pickaxx.", dir)
+
+	if info, err := os.Stat(dir); err != nil {
+		return failed(CheckWorkingDir, fmt.Sprintf("working directory '%s' does not exist", dir), fix)
+	} else if !info.IsDir() {
+		return failed(CheckWorkingDir, fmt.Sprintf("working directory '%s' is not a directory", dir), fix)
+	}
+
+	if err := dirWritable(dir); err != nil {
+		return failed(CheckWorkingDir, fmt.Sprintf("working directory '%s' is not writable", dir), fix)
+	}
+
+	return passed(CheckWorkingDir, fmt.Sprintf("working directory '%s' is writable", dir))
+}
+
+// dirWritable returns an error if a file can not be created in dir.
+func dirWritable(dir string) error {
+	f, err := ioutil.TempFile(dir, ".pickaxx-ready-*")
+	if err != nil {
+		return err
+	}
+
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func checkDiskSpace(dir string, needed uint64) pickaxx.Check {
+	var fs syscall.Statfs_t
+
+	if err := syscall.Statfs(dir, &fs); err != nil {
+		return failed(CheckDiskSpace, fmt.Sprintf("unable to check free disk space: %v", err), "Make sure the working directory exists.")
+	}
+
+	free := fs.Bavail * uint64(fs.Bsize)
+
+	if free < needed {
+		return failed(CheckDiskSpace,
+			fmt.Sprintf("only %s of disk space is free", formatBytes(free)),
+			fmt.Sprintf("Free up disk space on the volume holding '%s'; at least %s is needed.", dir, formatBytes(needed)))
+	}
+
+	return passed(CheckDiskSpace, fmt.Sprintf("%s of disk space is free", formatBytes(free)))
+}
+
+func checkExecutable(dir, name string) pickaxx.Check {
+	path := name
+
+	// relative paths are run from the working directory
+	if strings.Contains(name, "/") && !filepath.IsAbs(name) {
+		path = filepath.Join(dir, name)
+	}
+
+	found, err := exec.LookPath(path)
+	if err != nil {
+		return failed(CheckExecutable, fmt.Sprintf("'%s' not found", name),
+			fmt.Sprintf("Install '%s', or change the server command in the configuration file.", name))
+	}
+
+	return passed(CheckExecutable, fmt.Sprintf("found '%s'", found))
+}
+
+func checkPortFree(network string, port int) pickaxx.Check {
+	var (
+		addr = fmt.Sprintf(":%d", port)
+		err  error
+	)
+
+	if network == "udp" {
+		var conn net.PacketConn
+		if conn, err = net.ListenPacket(network, addr); err == nil {
+			conn.Close()
+		}
+	} else {
+		var l net.Listener
+		if l, err = net.Listen(network, addr); err == nil {
+			l.Close()
+		}
+	}
+
+	if err != nil {
+		return failed(CheckPort, fmt.Sprintf("%s port %d is in use", network, port),
+			fmt.Sprintf("Stop the process using port %d (e.g. another server), or configure the server with another port.", port))
+	}
+
+	return passed(CheckPort, fmt.Sprintf("%s port %d is free", network, port))
+}
+
+// formatBytes returns a size in human-readable form, e.g. "1.5 GB".
+func formatBytes(b uint64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,81 @@
+package gameserver
+
+import (
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPortFree(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+
+	assert.False(t, checkPortFree("tcp", port).Passed)
+	l.Close()
+	assert.True(t, checkPortFree("tcp", port).Passed)
+
+	conn, err := net.ListenPacket("udp", ":0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.False(t, checkPortFree("udp", conn.LocalAddr().(*net.UDPAddr).Port).Passed)
+}
+
+func TestPreflightPortInUse(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	m := New(l.Addr().(*net.TCPAddr).Port, WithType(ServerType{Name: "echo", Command: []string{"cat"}}), WithWorkingDir(t.TempDir()))
+
+	hasPortCheck := func() bool {
+		for _, c := range m.Preflight() {
+			if c.Name == CheckPort {
+				assert.False(t, c.Passed)
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, state := range []ServerState{Unknown, Stopped, Failed} {
+		m.setState(state)
+		assert.True(t, hasPortCheck(), state.String())
+	}
+
+	// the server is still using its port
+	for _, state := range []ServerState{Starting, Running, Stopping} {
+		m.setState(state)
+		assert.False(t, hasPortCheck(), state.String())
+	}
+}
+
+func TestCheckWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.True(t, checkWorkingDir(dir).Passed)
+	assert.False(t, checkWorkingDir(filepath.Join(dir, "missing")).Passed)
+
+	assert.True(t, checkDiskSpace(dir, 1).Passed)
+	assert.False(t, checkDiskSpace(dir, 1<<62).Passed)
+}
+
+func TestCheckExecutable(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bedrock_server"), []byte("#!/bin/sh\n"), 0755))
+
+	assert.True(t, checkExecutable(dir, "./bedrock_server").Passed, "relative to working directory")
+	assert.True(t, checkExecutable(dir, "sh").Passed, "on path")
+	assert.False(t, checkExecutable(dir, "./missing").Passed)
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KB", formatBytes(1536))
+	assert.Equal(t, "1.0 GB", formatBytes(1<<30))
+}
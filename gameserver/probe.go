@@ -0,0 +1,71 @@
+package gameserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+)
+
+const (
+	// probeInterval is how often a running server is probed.
+	probeInterval = time.Second * 2
+
+	// defaultProbeDelay is how long a server with no ready pattern may take
+	// to load, before it is probed.
+	defaultProbeDelay = time.Second * 15
+)
+
+// LivenessProbe checks that a server running on the local host is responding.
+type LivenessProbe interface {
+
+	// Probe returns an error if the server does not respond on the given
+	// port. Probes which query the server's status also return it; others
+	// return nil.
+	Probe(ctx context.Context, port int) (*pickaxx.ServerInfo, error)
+}
+
+// Probes are the liveness probes registered, by kind.
+var Probes = map[ProbeKind]LivenessProbe{
+	ProbeTCP: TCPProbe{},
+}
+
+// RegisterProbe makes a liveness probe available to server types, as the
+// given kind. This is meant to be called as a package is initialized, before
+// registering types which use it.
+func RegisterProbe(kind ProbeKind, p LivenessProbe) {
+	Probes[kind] = p
+}
+
+// TCPProbe checks that a TCP port accepts connections.
+type TCPProbe struct{}
+
+// Probe connects to the port, and closes the connection.
+func (TCPProbe) Probe(ctx context.Context, port int) (*pickaxx.ServerInfo, error) {
+	if !portOpen("localhost", port) {
+		return nil, ErrNoResponse
+	}
+	return nil, nil
+}
+
+// WithProbe checks the liveness of the server with a probe other than that
+// of its type (see ServerType.Probe).
+func WithProbe(p LivenessProbe) Option {
+	return func(m *Manager) {
+		m.probe = p
+	}
+}
+
+// livenessProbe returns the probe used to check the server, or nil if it is not checked.
+func (m *Manager) livenessProbe() LivenessProbe {
+	if m.probe != nil {
+		return m.probe
+	}
+
+	kind := m.Type.Probe
+	if kind == "" {
+		kind = ProbeTCP
+	}
+
+	return Probes[kind]
+}
@@ -1,4 +1,4 @@
-package minecraft
+package gameserver
 
 import (
 	"context"
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/apex/log"
+	"github.com/ivan3bx/pickaxx"
 )
 
 // ErrNoResponse is returned when a response is not provided within a certain period of time.
@@ -21,6 +22,11 @@ type StatusNotifier struct {
 	observers map[ServerState][]chan ServerState
 }
 
+// Notifier returns the registry of observers of state transitions.
+func (m *Manager) Notifier() *StatusNotifier {
+	return &m.notifier
+}
+
 // Register will register a new observer for the given states.
 // Returns a new channel which will receive messages when the server changes
 // to any of the state(s) provided.
@@ -80,15 +86,75 @@ func (n *StatusNotifier) Notify(st ServerState) {
 	}
 }
 
+// readyTracker recognizes the server has finished loading, in console
+// output. This implementation can be accessed concurrently by multiple
+// goroutines.
+type readyTracker struct {
+	sync.Mutex
+	pattern *Pattern
+	ready   bool
+}
+
+// Reset clears the state of a previous run. The server is ready once output
+// matches the given pattern (or immediately, if nil).
+func (t *readyTracker) Reset(pattern *Pattern) {
+	t.Lock()
+	defer t.Unlock()
+	t.pattern, t.ready = pattern, pattern == nil
+}
+
+// Observe inspects a line of console output. No data is sent to clients.
+func (t *readyTracker) Observe(line string) []pickaxx.Data {
+	t.Lock()
+	defer t.Unlock()
+
+	if !t.ready && t.pattern != nil && t.pattern.MatchString(line) {
+		t.ready = true
+	}
+
+	return nil
+}
+
+// Ready returns true once the server has finished loading.
+func (t *readyTracker) Ready() bool {
+	t.Lock()
+	defer t.Unlock()
+	return t.ready
+}
+
+// Loaded returns true once the running server has finished loading (see
+// ServerType.Ready).
+func (m *Manager) Loaded() bool {
+	return m.ready.Ready()
+}
+
 // checkPort will continually check for 'liveness' on the given port on localhost.
 // This loop will return in one of two cases:
 //
-// 1. If host does not respond (i.e. port is not open), returns an error.
+// 1. If host does not respond (i.e. the probe fails), returns an error.
 // 2. If the provided channel receives a message, will quit (no error).
-func checkPort(ctx context.Context, port int, initialDelay time.Duration, interval time.Duration) error {
+//
+// If provided, checks begin once 'ready' returns true (checked each
+// interval), and after the initial delay. If provided, 'probed' is called
+// after each successful check, with any status reported by the server.
+func checkPort(ctx context.Context, probe LivenessProbe, port int, ready func() bool, initialDelay time.Duration, interval time.Duration, probed func(*pickaxx.ServerInfo)) error {
 	log := log.WithField("action", "checkPort()")
 
-	time.Sleep(initialDelay) // initial delay
+	// wait until the server has loaded
+	for ready != nil && !ready() {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+
+	// initial delay
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-time.After(initialDelay):
+	}
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -98,10 +164,20 @@ func checkPort(ctx context.Context, port int, initialDelay time.Duration, interv
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			if !portOpen("localhost", port) {
-				log.Warn("probe failed")
+			info, err := probe.Probe(ctx, port)
+
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if err != nil {
+				log.WithError(err).Warn("probe failed")
 				return ErrNoResponse
 			}
+
+			if probed != nil {
+				probed(info)
+			}
 		}
 	}
 }
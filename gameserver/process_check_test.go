@@ -0,0 +1,105 @@
+package gameserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortOpenSuccess(t *testing.T) {
+
+	// set up a dummy server on a dummy port
+	ts := httptest.NewServer(http.DefaultServeMux)
+	defer ts.Close()
+
+	url, _ := url.Parse(ts.URL)
+	testPort, _ := strconv.Atoi(url.Port())
+
+	tests := []struct {
+		name     string
+		port     int
+		expected error
+	}{
+		{
+			name:     "successful check",
+			port:     testPort,
+			expected: nil,
+		},
+		{
+			name:     "unsuccessful check",
+			port:     -99,
+			expected: ErrNoResponse,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+
+			go func() {
+				time.Sleep(time.Millisecond * 20)
+				cancel()
+			}()
+
+			assert.Equal(t, tc.expected, checkPort(ctx, TCPProbe{}, tc.port, nil, 0, time.Millisecond*5, nil))
+		})
+	}
+}
+
+func TestCheckPortWaitsUntilReady(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ready int32
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		atomic.StoreInt32(&ready, 1)
+	}()
+
+	start := time.Now()
+	err := checkPort(ctx, TCPProbe{}, -99, func() bool { return atomic.LoadInt32(&ready) == 1 }, 0, time.Millisecond*5, nil)
+
+	assert.Equal(t, ErrNoResponse, err)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(time.Millisecond*50), "not probed before ready")
+}
+
+func TestReadyTracker(t *testing.T) {
+	tr := readyTracker{}
+	tr.Reset(MustPattern(`Server started\.$`))
+
+	tr.Observe("Starting Server")
+	assert.False(t, tr.Ready())
+	tr.Observe("Server started.")
+	assert.True(t, tr.Ready())
+
+	// ready once started, without a pattern
+	tr.Reset(nil)
+	assert.True(t, tr.Ready())
+}
+
+func TestCheckPortReportsInfo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		cancel()
+	}()
+
+	var reported *pickaxx.ServerInfo
+
+	err := checkPort(ctx, infoProbe{&pickaxx.ServerInfo{Players: 2}}, testPort, nil, 0, time.Millisecond*10, func(info *pickaxx.ServerInfo) {
+		reported = info
+	})
+
+	assert.NoError(t, err)
+	require.NotNil(t, reported)
+	assert.Equal(t, 2, reported.Players)
+}
@@ -0,0 +1,411 @@
+package gameserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ivan3bx/pickaxx"
+)
+
+var _ pickaxx.Detacher = &Manager{}
+
+// DataDir holds files used by pickaxx itself (e.g. to reconnect to a
+// detached server), within the working directory. It is not backed up.
+const DataDir = ".pickaxx"
+
+const (
+	consoleInFile  = "console.in"  // named pipe, read by the server as stdin
+	consoleOutFile = "console.log" // written by the server as stdout
+	consoleErrFile = "console.err" // written by the server as stderr
+	processFile    = "server.json" // describes the running process
+
+	tailInterval = time.Millisecond * 100 // how often to check for new console output
+	consoleLimit = 10 << 20               // console files are emptied once read past this size
+	pollInterval = time.Millisecond * 500 // how often to check an adopted process is alive
+)
+
+// processInfo is persisted while a detached server runs, so that it can be
+// found again after pickaxx restarts.
+type processInfo struct {
+	PID       int       `json:"pid"`
+	Command   []string  `json:"command"`
+	StartedAt time.Time `json:"startedAt"`
+
+	// console files are appended to, so may be emptied once read (servers
+	// started by earlier versions of pickaxx write at an offset)
+	Appending bool `json:"appending"`
+}
+
+// adoptedProcess is a server process started by a previous instance of pickaxx.
+type adoptedProcess struct {
+	pid       int
+	startedAt time.Time
+}
+
+func (p *adoptedProcess) Pid() int             { return p.pid }
+func (p *adoptedProcess) StartedAt() time.Time { return p.startedAt }
+func (p *adoptedProcess) Kill() error          { return syscall.Kill(p.pid, syscall.SIGKILL) }
+
+func (p *adoptedProcess) Signal(sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return errors.New("unsupported signal")
+	}
+	return syscall.Kill(p.pid, s)
+}
+
+// Wait blocks until the process exits. Only a parent may wait for a process
+// to exit, so this polls for the process instead.
+func (p *adoptedProcess) Wait() error {
+	for processAlive(p.pid) {
+		time.Sleep(pollInterval)
+	}
+	return nil
+}
+
+// processAlive returns true if a process exists, and has not exited. Exited
+// processes which have not yet been reaped by their parent are not alive.
+func processAlive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+
+	stat, err := ioutil.ReadFile(filepath.Join(procFS, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return true // exists, but state unknown
+	}
+
+	// state follows the command name, e.g. "1234 (java) S ..."
+	if i := bytes.LastIndexByte(stat, ')'); i >= 0 && i+2 < len(stat) {
+		return stat[i+2] != 'Z'
+	}
+
+	return true
+}
+
+// detachedConsole connects to the console of a detached server, through
+// files in its working directory rather than pipes (which would close when
+// pickaxx exits).
+type detachedConsole struct {
+	dir string
+	in  *os.File    // named pipe, written with commands
+	out *tailReader // console output
+	err *tailReader // console errors
+}
+
+// Close disconnects from the console. Output already written may still be read.
+func (c *detachedConsole) Close() error {
+	c.out.Close()
+	c.err.Close()
+	return c.in.Close()
+}
+
+// remove deletes the process file, so the server is not adopted again.
+func (c *detachedConsole) remove() {
+	if err := os.Remove(filepath.Join(c.dir, processFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.WithError(err).Warn("unable to remove process file")
+	}
+}
+
+// startDetached starts a command in a new session, with its console
+// connected to files in dir. The process file is written once started.
+func startDetached(cmd *exec.Cmd, dir string) (*detachedConsole, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var (
+		inPath  = filepath.Join(dir, consoleInFile)
+		outPath = filepath.Join(dir, consoleOutFile)
+		errPath = filepath.Join(dir, consoleErrFile)
+	)
+
+	os.Remove(inPath)
+	if err := syscall.Mkfifo(inPath, 0600); err != nil {
+		return nil, fmt.Errorf("unable to create console pipe: %w", err)
+	}
+
+	// The server holds the pipe open for writing as well as reading, so that
+	// it never sees EOF while pickaxx is not connected.
+	stdin, err := os.OpenFile(inPath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer stdin.Close()
+
+	// appending, so writes continue from the start once files are emptied
+	stdout, err := os.OpenFile(outPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer stdout.Close()
+
+	stderr, err := os.OpenFile(errPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer stderr.Close()
+
+	console, err := openConsole(dir, false, consoleLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true} // not signalled with pickaxx's process group
+
+	if err := cmd.Start(); err != nil {
+		console.Close()
+		return nil, err
+	}
+
+	info := processInfo{PID: cmd.Process.Pid, Command: cmd.Args, StartedAt: time.Now(), Appending: true}
+
+	if err := writeProcessInfo(dir, info); err != nil {
+		log.WithError(err).Warn("unable to write process file; server can not be reattached")
+	}
+
+	return console, nil
+}
+
+// openConsole connects to the console files in dir. If fromEnd is true,
+// only output written from now on is read. If limit is set, files are
+// emptied once read past limit bytes (see tailReader).
+func openConsole(dir string, fromEnd bool, limit int64) (*detachedConsole, error) {
+	// non-blocking, so this fails rather than waiting if no server is reading
+	in, err := os.OpenFile(filepath.Join(dir, consoleInFile), os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open console pipe: %w", err)
+	}
+
+	out, err := openTail(filepath.Join(dir, consoleOutFile), fromEnd, limit)
+	if err != nil {
+		in.Close()
+		return nil, err
+	}
+
+	errOut, err := openTail(filepath.Join(dir, consoleErrFile), fromEnd, limit)
+	if err != nil {
+		in.Close()
+		out.file.Close()
+		return nil, err
+	}
+
+	return &detachedConsole{dir: dir, in: in, out: out, err: errOut}, nil
+}
+
+// openTail opens a file of console output for reading, as it is written.
+func openTail(path string, fromEnd bool, limit int64) (*tailReader, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromEnd {
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	t := newTailReader(f)
+	t.limit = limit
+	return t, nil
+}
+
+func writeProcessInfo(dir string, info processInfo) error {
+	b, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, processFile), b, 0644)
+}
+
+// findDetached returns a process left running in dir, if any. Stale process
+// files (e.g. naming a process which has since exited) are removed.
+func findDetached(dir string) (*processInfo, error) {
+	path := filepath.Join(dir, processFile)
+
+	b, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var info processInfo
+
+	if err := json.Unmarshal(b, &info); err != nil || !sameProcess(info) {
+		log.WithField("file", path).Info("removing stale process file")
+		os.Remove(path)
+		return nil, nil
+	}
+
+	return &info, nil
+}
+
+// sameProcess returns true if the process is alive, and running the recorded
+// command (and not another process which has since been given the same PID).
+func sameProcess(info processInfo) bool {
+	if info.PID <= 0 || !processAlive(info.PID) {
+		return false
+	}
+
+	cmdline, err := ioutil.ReadFile(filepath.Join(procFS, strconv.Itoa(info.PID), "cmdline"))
+	if err != nil {
+		return false
+	}
+
+	args := strings.Split(string(bytes.TrimRight(cmdline, "\x00")), "\x00")
+	return strings.Join(args, " ") == strings.Join(info.Command, " ")
+}
+
+// tailReader reads a file which is still being written, waiting for more
+// data at the end of the file until closed. If limit is set, the file is
+// emptied once all of it has been read, and it is larger than limit; the
+// writer must be appending to the file. This implementation can be accessed
+// concurrently by multiple goroutines.
+type tailReader struct {
+	file   *os.File
+	limit  int64
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newTailReader(f *os.File) *tailReader {
+	return &tailReader{file: f, closed: make(chan struct{})}
+}
+
+func (t *tailReader) Read(p []byte) (int, error) {
+	for {
+		closed := t.isClosed() // before reading, so data written before closing is read
+		n, err := t.file.Read(p)
+
+		if n > 0 || (err != nil && err != io.EOF) {
+			return n, err
+		}
+
+		if closed {
+			t.file.Close()
+			return 0, io.EOF
+		}
+
+		if t.limit > 0 {
+			t.truncate()
+		}
+
+		select {
+		case <-t.closed:
+		case <-time.After(tailInterval):
+		}
+	}
+}
+
+// truncate empties the file if it is larger than the limit, and has been
+// read to the end. Output written between checking & emptying the file is
+// lost, though this is unlikely as it was just read to the end.
+func (t *tailReader) truncate() {
+	offset, err := t.file.Seek(0, io.SeekCurrent)
+	if err != nil || offset < t.limit {
+		return
+	}
+
+	if info, err := t.file.Stat(); err != nil || info.Size() != offset {
+		return // more to read
+	}
+
+	if err := os.Truncate(t.file.Name(), 0); err != nil {
+		log.WithError(err).WithField("file", t.file.Name()).Warn("unable to empty console file")
+		return
+	}
+
+	t.file.Seek(0, io.SeekStart)
+}
+
+func (t *tailReader) isClosed() bool {
+	select {
+	case <-t.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops waiting for more data. Reads return EOF once the data already
+// written has been read.
+func (t *tailReader) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return nil
+}
+
+// Detach stops managing the running server, leaving it running. This is
+// only possible for servers started detached (see WithDetach).
+func (m *Manager) Detach() error {
+	if !m.currentStateIn(Running) {
+		return ErrNoProcess
+	}
+
+	result := make(chan error, 1)
+	m.detach <- result
+
+	return <-result
+}
+
+// Reattach adopts a server left running by a previous instance of pickaxx,
+// resuming in the Running state. The channel is nil if there is no server
+// to adopt.
+func (m *Manager) Reattach() (<-chan pickaxx.Data, error) {
+	if m.Running() {
+		return nil, fmt.Errorf("server already running: %w", pickaxx.ErrProcessExists)
+	}
+
+	m.setDefaults()
+
+	if _, err := m.prepareLaunch(); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(m.WorkingDir, DataDir)
+
+	info, err := findDetached(dir)
+	if info == nil || err != nil {
+		return nil, err
+	}
+
+	var limit int64
+	if info.Appending {
+		limit = consoleLimit
+	}
+
+	console, err := openConsole(dir, true, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	m.proc = &adoptedProcess{pid: info.PID, startedAt: info.StartedAt}
+	m.console, m.cmdIn, m.cmdOut, m.cmdErr = console, console.in, console.out, console.err
+
+	m.nextState = make(chan ServerState, 1)
+	m.detach = make(chan chan error)
+	m.resetStop()
+	activity := make(chan pickaxx.Data, 10)
+
+	go eventLoop(m, activity)
+
+	m.nextState <- Running
+
+	return activity, nil
+}
@@ -0,0 +1,217 @@
+package gameserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetachAndReattach(t *testing.T) {
+	dir := t.TempDir()
+
+	first := New(testPort, WithDetach())
+	first.Command = []string{"cat"}
+	first.WorkingDir = dir
+
+	isRunning := first.notifier.Register(Running)
+	defer first.notifier.Unregister(isRunning)
+
+	activity, err := first.Start()
+	require.NoError(t, err)
+	<-isRunning
+
+	done := drain(activity)
+
+	proc := first.proc
+	defer proc.Kill()
+
+	// the process file identifies the running server
+	info, err := findDetached(filepath.Join(dir, DataDir))
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	assert.Equal(t, proc.Pid(), info.PID)
+
+	require.NoError(t, first.Detach())
+	<-done
+	assert.False(t, first.Running())
+	assert.True(t, processAlive(proc.Pid()), "server should still be running")
+
+	// reap the process once it exits, as pickaxx's parent (e.g. init) would
+	go proc.Wait()
+
+	// a new instance adopts the running server
+	second := New(testPort)
+	second.Command = []string{"cat"}
+	second.WorkingDir = dir
+
+	adopted, err := second.Reattach()
+	require.NoError(t, err)
+	require.NotNil(t, adopted)
+
+	assertAsync(t, second.Running)
+	assert.Equal(t, proc.Pid(), second.proc.Pid())
+
+	require.NoError(t, second.Submit("still-connected-123"))
+
+	found := false
+	for data := range adopted {
+		if out, _ := json.Marshal(data); strings.Contains(string(out), "still-connected-123") {
+			found = true
+			break
+		}
+	}
+	assert.True(t, found, "expected output from adopted server")
+
+	done = drain(adopted)
+
+	// 'cat' ignores the stop command; force a quick kill
+	require.NoError(t, second.Stop(pickaxx.WithKillTimeout(time.Millisecond*50)))
+	<-done
+
+	assert.NoFileExists(t, filepath.Join(dir, DataDir, processFile))
+}
+
+func TestDetachNotDetachable(t *testing.T) {
+	m := &Manager{
+		Command:    []string{"cat"},
+		WorkingDir: t.TempDir(),
+	}
+
+	isRunning := m.notifier.Register(Running)
+	defer m.notifier.Unregister(isRunning)
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+	<-isRunning
+
+	done := drain(activity)
+
+	assert.Error(t, m.Detach())
+	assert.True(t, m.Running())
+
+	m.Stop(pickaxx.WithKillTimeout(time.Millisecond * 50))
+	<-done
+}
+
+// drain discards activity, returning a channel closed once activity ends.
+func drain(activity <-chan pickaxx.Data) <-chan bool {
+	done := make(chan bool)
+	go func() {
+		for range activity {
+		}
+		close(done)
+	}()
+	return done
+}
+
+func TestReattachNothingRunning(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+
+	// stale process file, naming a process which doesn't exist
+	stale := filepath.Join(dir, DataDir, processFile)
+	require.NoError(t, ioutil.WriteFile(stale, []byte(`{"pid": 1073741824, "command": ["java"]}`), 0644))
+
+	m := &Manager{Command: []string{"cat"}, WorkingDir: dir}
+
+	activity, err := m.Reattach()
+	assert.NoError(t, err)
+	assert.Nil(t, activity)
+	assert.NoFileExists(t, stale)
+}
+
+func TestTailReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.log")
+
+	w, err := os.Create(path)
+	require.NoError(t, err)
+	defer w.Close()
+
+	r, err := os.Open(path)
+	require.NoError(t, err)
+
+	tail := newTailReader(r)
+	lines := make(chan string)
+
+	go func() {
+		b, _ := ioutil.ReadAll(tail)
+		lines <- string(b)
+	}()
+
+	w.WriteString("first\n")
+	time.Sleep(tailInterval * 2)
+	w.WriteString("second\n")
+
+	tail.Close()
+
+	assert.Equal(t, "first\nsecond\n", <-lines)
+}
+
+func TestTailReaderLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.log")
+
+	w, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	defer w.Close()
+
+	tail, err := openTail(path, false, 10)
+	require.NoError(t, err)
+
+	lines := make(chan string)
+
+	go func() {
+		b, _ := ioutil.ReadAll(tail)
+		lines <- string(b)
+	}()
+
+	size := func() int64 {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return info.Size()
+	}
+
+	// under the limit, the file is kept
+	w.WriteString("first\n")
+	time.Sleep(tailInterval * 2)
+	assert.Equal(t, int64(6), size())
+
+	// emptied once read past the limit, and written from the start again
+	w.WriteString("second\n")
+	assert.Eventually(t, func() bool { return size() == 0 }, time.Second, time.Millisecond*10)
+
+	w.WriteString("third\n")
+	time.Sleep(tailInterval * 2)
+	tail.Close()
+
+	assert.Equal(t, "first\nsecond\nthird\n", <-lines)
+	assert.Equal(t, int64(6), size())
+}
+
+func TestDetachedStderr(t *testing.T) {
+	m := New(testPort, WithDetach(), WithWorkingDir(t.TempDir()))
+	m.Command = []string{"sh", "-c", "echo oops >&2; exec cat"}
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+
+	var stderr consoleOutput
+	for data := range activity {
+		if out, ok := data.(consoleOutput); ok && out.Stream == pickaxx.Stderr {
+			stderr = out
+			break
+		}
+	}
+	assert.Equal(t, "oops", stderr.Text)
+
+	done := drain(activity)
+	require.NoError(t, m.Stop(pickaxx.WithKillTimeout(time.Millisecond*50)))
+	<-done
+}
@@ -0,0 +1,185 @@
+package gameserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ivan3bx/pickaxx"
+)
+
+var (
+	_ pickaxx.Data  = &consoleOutput{}
+	_ pickaxx.Event = &stateChangeEvent{}
+	_ pickaxx.Event = &crashEvent{}
+	_ pickaxx.Event = &Alert{}
+)
+
+// consoleOutput represents console output (free-form text data).
+type consoleOutput struct {
+	Text   string
+	Stream string // pickaxx.Stdout or pickaxx.Stderr; empty for messages from pickaxx itself.
+}
+
+func (d consoleOutput) String() string {
+	if d.Stream == pickaxx.Stderr {
+		return pickaxx.StderrPrefix + d.Text
+	}
+	return d.Text
+}
+
+// MarshalJSON converts this output to valid JSON.
+func (d consoleOutput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Output string `json:"output"`
+		Stream string `json:"stream,omitempty"`
+	}{d.Text, d.Stream})
+}
+
+// stateChangeEvent represents a state transition event.
+type stateChangeEvent struct {
+	State   ServerState
+	Reason  string                  // Optional reason for the transition (e.g. why a server is stopping).
+	Failure *pickaxx.StartupFailure // Why the server failed to start, if the state is Failed.
+}
+
+// MarshalJSON converts this output to valid JSON.
+func (d stateChangeEvent) MarshalJSON() ([]byte, error) {
+	if d.Failure != nil {
+		return json.Marshal(struct {
+			Status  string                  `json:"status"`
+			Reason  string                  `json:"reason"`
+			Failure *pickaxx.StartupFailure `json:"failure"`
+		}{d.State.String(), d.Reason, d.Failure})
+	}
+
+	if d.Reason == "" {
+		jsonString := fmt.Sprintf(`{"status":"%s"}`, d.State.String())
+		return []byte(jsonString), nil
+	}
+
+	return json.Marshal(map[string]string{
+		"status": d.State.String(),
+		"reason": d.Reason,
+	})
+}
+
+// EventType identifies this kind of event.
+func (d stateChangeEvent) EventType() string { return "state" }
+
+// crashEvent represents a server which stopped unexpectedly.
+type crashEvent struct {
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
+}
+
+// MarshalJSON converts this output to valid JSON.
+func (d crashEvent) MarshalJSON() ([]byte, error) {
+	type crash crashEvent // avoid recursion
+	return json.Marshal(map[string]crash{"crash": crash(d)})
+}
+
+// EventType identifies this kind of event.
+func (d crashEvent) EventType() string { return "crash" }
+
+// Alert notifies clients of a condition requiring attention (e.g. a startup
+// failure, or a lagging server).
+type Alert struct {
+	Kind    string  `json:"kind"`
+	Message string  `json:"message"`
+	Value   float64 `json:"value"`
+}
+
+func (d Alert) String() string { return d.Message }
+
+// EventType identifies this kind of event.
+func (d Alert) EventType() string { return "alert" }
+
+// MarshalJSON converts this output to valid JSON.
+func (d Alert) MarshalJSON() ([]byte, error) {
+	type alert Alert // avoid recursion
+	return json.Marshal(map[string]alert{"alert": alert(d)})
+}
+
+// pipeOutput will send all input from the reader as data through the provided channel,
+// tagged with the stream it was read from. Each line is passed to any observers, and
+// data they return is sent after the line.
+func pipeOutput(r io.Reader, stream string, out chan<- pickaxx.Data, observers ...func(string) []pickaxx.Data) {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		out <- consoleOutput{s.Text(), stream}
+
+		for _, observe := range observers {
+			for _, data := range observe(s.Text()) {
+				out <- data
+			}
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		log.WithError(err).Error("pipeOutput() closed with error")
+		return
+	}
+}
+
+// outputWatch notifies callers waiting for a line of console output. This
+// implementation can be accessed concurrently by multiple goroutines.
+type outputWatch struct {
+	sync.Mutex
+	waiting map[chan struct{}]*Pattern
+}
+
+// Wait returns a channel which is closed once a line matches the pattern.
+// Calling stop stops waiting, and must be called once done.
+func (w *outputWatch) Wait(p *Pattern) (matched <-chan struct{}, stop func()) {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.waiting == nil {
+		w.waiting = map[chan struct{}]*Pattern{}
+	}
+
+	ch := make(chan struct{})
+	w.waiting[ch] = p
+
+	return ch, func() {
+		w.Lock()
+		defer w.Unlock()
+		delete(w.waiting, ch)
+	}
+}
+
+// Observe inspects a line of console output, notifying those waiting for it.
+func (w *outputWatch) Observe(line string) []pickaxx.Data {
+	w.Lock()
+	defer w.Unlock()
+
+	for ch, p := range w.waiting {
+		if p.MatchString(line) {
+			close(ch)
+			delete(w.waiting, ch)
+		}
+	}
+
+	return nil
+}
+
+// pipeCommandOutput returns readers of stdout & stderr from the given command.
+// Both must be read concurrently, so that the command does not block writing
+// to one while the other is being read.
+func pipeCommandOutput(cmd *exec.Cmd) (stdout, stderr io.Reader, err error) {
+	if stdout, err = cmd.StdoutPipe(); err != nil {
+		return nil, nil, err
+	}
+
+	if stderr, err = cmd.StderrPipe(); err != nil {
+		return nil, nil, err
+	}
+
+	return stdout, stderr, nil
+}
@@ -0,0 +1,127 @@
+package gameserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsoleOutput(t *testing.T) {
+	d := consoleOutput{Text: "sample text"}
+
+	bo, _ := json.Marshal(&d)
+
+	assert.Equal(t, `{"output":"sample text"}`, string(bo))
+	assert.Equal(t, "sample text", d.String())
+}
+
+func TestConsoleOutputStream(t *testing.T) {
+	d := consoleOutput{`Exception in thread "main"`, pickaxx.Stderr}
+
+	bo, _ := json.Marshal(&d)
+
+	assert.JSONEq(t, `{"output":"Exception in thread \"main\"","stream":"stderr"}`, string(bo))
+	assert.Equal(t, `[stderr] Exception in thread "main"`, d.String())
+
+	d.Stream = pickaxx.Stdout
+	assert.Equal(t, `Exception in thread "main"`, d.String())
+}
+
+func TestStateChangeEvent(t *testing.T) {
+	d := stateChangeEvent{State: Running}
+
+	bo, _ := json.Marshal(&d)
+
+	assert.Equal(t, `{"status":"Running"}`, string(bo))
+}
+
+func TestPipeCommandOutput(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cmd := exec.Command("cat")
+		stdout, stderr, err := pipeCommandOutput(cmd)
+		assert.NoError(t, err)
+		assert.NotNil(t, stdout)
+		assert.NotNil(t, stderr)
+	})
+
+	t.Run("fails when stdout set", func(t *testing.T) {
+		cmd := exec.Command("cat")
+		cmd.Stdout = &bytes.Buffer{}
+
+		_, _, err := pipeCommandOutput(cmd)
+		assert.Error(t, err)
+	})
+
+	t.Run("fails when stderr set", func(t *testing.T) {
+		cmd := exec.Command("cat")
+		cmd.Stderr = &bytes.Buffer{}
+
+		_, _, err := pipeCommandOutput(cmd)
+		assert.Error(t, err)
+	})
+
+}
+
+func TestStateChangeEventWithReason(t *testing.T) {
+	d := stateChangeEvent{State: Stopping, Reason: `say "bye"`}
+	bo, _ := json.Marshal(&d)
+	assert.JSONEq(t, `{"status":"Stopping","reason":"say \"bye\""}`, string(bo))
+}
+
+func TestPipeOutputStreamsConcurrently(t *testing.T) {
+	// more stderr than fits in a pipe buffer, before any stdout
+	cmd := exec.Command("sh", "-c", `i=0; while [ $i -lt 2000 ]; do echo "error line $i with some padding to fill the pipe" >&2; i=$((i+1)); done; echo done`)
+
+	stdout, stderr, err := pipeCommandOutput(cmd)
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+
+	out := make(chan pickaxx.Data, 10)
+	wg := sync.WaitGroup{}
+
+	for stream, r := range map[string]io.Reader{pickaxx.Stdout: stdout, pickaxx.Stderr: stderr} {
+		wg.Add(1)
+		go func(r io.Reader, stream string) {
+			defer wg.Done()
+			pipeOutput(r, stream, out)
+		}(r, stream)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	counts := map[string]int{}
+	timeout := time.After(time.Second * 10)
+
+	for {
+		select {
+		case data, ok := <-out:
+			if !ok {
+				assert.Equal(t, 2000, counts[pickaxx.Stderr])
+				assert.Equal(t, 1, counts[pickaxx.Stdout])
+				cmd.Wait()
+				return
+			}
+
+			line := data.(consoleOutput)
+			counts[line.Stream]++
+
+			if line.Stream == pickaxx.Stdout {
+				assert.Equal(t, "done", line.Text)
+			}
+		case <-timeout:
+			cmd.Process.Kill()
+			t.Fatal("output blocked")
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package gameserver
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// process is a running server process.
+type process interface {
+	Pid() int
+	StartedAt() time.Time
+	Wait() error // blocks until the process exits
+	Signal(sig os.Signal) error
+	Kill() error
+}
+
+// childProcess is a server process started by this instance of pickaxx.
+type childProcess struct {
+	*os.Process
+	startedAt time.Time
+
+	// a process is waited for once; later calls return the same result
+	wait    sync.Once
+	waitErr error
+}
+
+func (p *childProcess) Pid() int             { return p.Process.Pid }
+func (p *childProcess) StartedAt() time.Time { return p.startedAt }
+
+// Wait blocks until the process exits. This may be called more than once,
+// and concurrently (e.g. while stopping the server, and to notice it exit).
+func (p *childProcess) Wait() error {
+	p.wait.Do(func() {
+		_, p.waitErr = p.Process.Wait()
+	})
+	return p.waitErr
+}
+
+func startServer(ctx context.Context, m *Manager) (*exec.Cmd, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	log := log.WithField("action", "ProcessManager.startServer()")
+
+	m.lock.RLock()
+	command := m.launched.command
+	m.lock.RUnlock()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = m.WorkingDir
+
+	if len(m.Type.Env) > 0 {
+		cmd.Env = append(os.Environ(), m.Type.Env...)
+	}
+
+	if s, ok := m.Hooks().(Starter); ok {
+		if err := s.Starting(); err != nil {
+			log.WithError(err).Warn("unable to prepare server")
+		}
+	}
+	m.proc, m.console = nil, nil
+
+	defer func() {
+		switch {
+		case cmd.Process == nil:
+			cancel()
+			m.nextState <- Stopping
+		default:
+			m.proc = &childProcess{Process: cmd.Process, startedAt: time.Now()}
+			m.nextState <- Running
+		}
+	}()
+
+	if m.detachable {
+		console, err := startDetached(cmd, filepath.Join(m.WorkingDir, DataDir))
+
+		if err != nil {
+			log.WithError(err).Error("command failed")
+			return cmd, err
+		}
+
+		m.console, m.cmdIn, m.cmdOut, m.cmdErr = console, console.in, console.out, console.err
+		return cmd, nil
+	}
+
+	stdout, stderr, err := pipeCommandOutput(cmd)
+
+	if err != nil {
+		log.WithError(err).Error("unable to pipe output")
+		return cmd, err
+	}
+
+	m.cmdOut, m.cmdErr = stdout, stderr
+
+	pipin, err := cmd.StdinPipe()
+
+	if err != nil {
+		log.WithError(err).Error("unable to pipe input")
+		return cmd, err
+	}
+
+	m.cmdIn = pipin
+
+	if err := cmd.Start(); err != nil {
+		log.WithError(err).Error("command failed")
+		return cmd, err
+	}
+
+	return cmd, nil
+}
@@ -0,0 +1,250 @@
+package gameserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ivan3bx/pickaxx"
+)
+
+const (
+	// statsInterval is the time between samples of process resource usage.
+	statsInterval = time.Second * 5
+
+	// statsHistorySize is the number of samples kept (10 minutes at the default interval).
+	statsHistorySize = 120
+
+	// clockTicks is the kernel's USER_HZ, used to convert CPU time in /proc/<pid>/stat.
+	clockTicks = 100
+)
+
+// procFS is the mount point of the proc filesystem.
+var procFS = "/proc"
+
+var _ pickaxx.Data = &statsEvent{}
+
+// statsEvent carries a resource usage sample to clients.
+type statsEvent struct {
+	Stats pickaxx.ProcessStats
+}
+
+// MarshalJSON converts this output to valid JSON.
+func (d statsEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]pickaxx.ProcessStats{"stats": d.Stats})
+}
+
+// statsHistory is a fixed-size, rolling history of samples. This
+// implementation can be accessed concurrently by multiple goroutines.
+type statsHistory struct {
+	sync.Mutex
+	samples []pickaxx.ProcessStats
+}
+
+// Add appends a sample, discarding the oldest sample when full.
+func (h *statsHistory) Add(s pickaxx.ProcessStats) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.samples = append(h.samples, s)
+
+	if len(h.samples) > statsHistorySize {
+		h.samples = h.samples[len(h.samples)-statsHistorySize:]
+	}
+}
+
+// List returns a copy of all samples, oldest first.
+func (h *statsHistory) List() []pickaxx.ProcessStats {
+	h.Lock()
+	defer h.Unlock()
+
+	return append([]pickaxx.ProcessStats{}, h.samples...)
+}
+
+// sampleStats will sample resource usage of the given process on an interval,
+// recording each sample and sending it through the provided channel.
+// This loop returns when the context is cancelled or the process is gone.
+func sampleStats(ctx context.Context, pid int, history *statsHistory, interval time.Duration, out chan<- pickaxx.Data) {
+	var (
+		log  = log.WithField("action", "sampleStats()")
+		prev procSample
+	)
+
+	// baseline for CPU usage
+	prev, _ = readProcSample(pid)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sample, err := readProcSample(pid)
+
+			if err != nil {
+				log.WithError(err).Debug("unable to sample process")
+				return
+			}
+
+			stats := sample.stats(prev)
+			prev = sample
+
+			history.Add(stats)
+
+			select {
+			case out <- statsEvent{stats}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// procSample is a raw reading of a process from the proc filesystem.
+type procSample struct {
+	pickaxx.ProcessStats
+	cpuTicks uint64 // user + system time, in clock ticks
+}
+
+// stats converts this sample to ProcessStats, using a previous
+// sample to determine CPU usage.
+func (s procSample) stats(prev procSample) pickaxx.ProcessStats {
+	stats := s.ProcessStats
+
+	if elapsed := s.Time.Sub(prev.Time).Seconds(); !prev.Time.IsZero() && elapsed > 0 && s.cpuTicks >= prev.cpuTicks {
+		cpuSeconds := float64(s.cpuTicks-prev.cpuTicks) / clockTicks
+		stats.CPUPercent = cpuSeconds / elapsed * 100
+	}
+
+	return stats
+}
+
+// readProcSample reads /proc/<pid>/{stat,status,io,fd} for the given process.
+func readProcSample(pid int) (procSample, error) {
+	var (
+		dir    = filepath.Join(procFS, strconv.Itoa(pid))
+		sample = procSample{}
+		err    error
+	)
+
+	sample.Time = time.Now()
+
+	if sample.cpuTicks, sample.Threads, err = readProcStat(filepath.Join(dir, "stat")); err != nil {
+		return sample, err
+	}
+
+	if sample.RSS, err = readProcStatus(filepath.Join(dir, "status")); err != nil {
+		return sample, err
+	}
+
+	// io & fd may not be readable, depending on permissions
+	sample.ReadBytes, sample.WriteBytes, _ = readProcIO(filepath.Join(dir, "io"))
+
+	if fds, err := ioutil.ReadDir(filepath.Join(dir, "fd")); err == nil {
+		sample.OpenFDs = len(fds)
+	}
+
+	return sample, nil
+}
+
+// readProcStat returns cpu time (user + system, in clock ticks) and thread count.
+func readProcStat(path string) (ticks uint64, threads int, err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// the command name may contain spaces; fields start after the last ')'
+	idx := strings.LastIndexByte(string(content), ')')
+	if idx < 0 {
+		return 0, 0, errors.New("malformed stat file")
+	}
+
+	// fields[0] is 'state' (field 3 in proc(5))
+	fields := strings.Fields(string(content[idx+1:]))
+	if len(fields) < 18 {
+		return 0, 0, errors.New("malformed stat file")
+	}
+
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid utime: %w", err)
+	}
+
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid stime: %w", err)
+	}
+
+	if threads, err = strconv.Atoi(fields[17]); err != nil {
+		return 0, 0, fmt.Errorf("invalid num_threads: %w", err)
+	}
+
+	return utime + stime, threads, nil
+}
+
+// readProcStatus returns the resident set size, in bytes.
+func readProcStatus(path string) (uint64, error) {
+	values, err := readProcKeyValues(path)
+	if err != nil {
+		return 0, err
+	}
+
+	// e.g. "VmRSS:	  123456 kB"
+	fields := strings.Fields(values["VmRSS"])
+	if len(fields) == 0 {
+		return 0, nil // kernel threads & zombies have no RSS
+	}
+
+	kb, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid VmRSS: %w", err)
+	}
+
+	return kb * 1024, nil
+}
+
+// readProcIO returns total bytes read & written to storage.
+func readProcIO(path string) (read, write uint64, err error) {
+	values, err := readProcKeyValues(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	read, _ = strconv.ParseUint(values["read_bytes"], 10, 64)
+	write, _ = strconv.ParseUint(values["write_bytes"], 10, 64)
+
+	return read, write, nil
+}
+
+// readProcKeyValues parses files of the form "key: value" per line.
+func readProcKeyValues(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	s := bufio.NewScanner(f)
+
+	for s.Scan() {
+		if parts := strings.SplitN(s.Text(), ":", 2); len(parts) == 2 {
+			values[parts[0]] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	return values, s.Err()
+}
@@ -0,0 +1,104 @@
+package gameserver
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProc writes a minimal proc filesystem entry for pid 42.
+func fakeProc(t *testing.T, utime, stime int) {
+	t.Helper()
+
+	dir := filepath.Join(procFS, "42")
+	os.MkdirAll(filepath.Join(dir, "fd"), 0755)
+
+	stat := "42 (java (server)) S 1 42 42 0 -1 4194560 100 0 0 0 " +
+		strconv.Itoa(utime) + " " + strconv.Itoa(stime) + " 0 0 20 0 37 0 1000 1000000 100 18446744073709551615"
+	status := "Name:\tjava\nVmRSS:\t  2048 kB\nThreads:\t37\n"
+	io := "rchar: 1\nwchar: 2\nread_bytes: 4096\nwrite_bytes: 8192\n"
+
+	ioutil.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "status"), []byte(status), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "io"), []byte(io), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "fd", "0"), nil, 0644)
+	ioutil.WriteFile(filepath.Join(dir, "fd", "1"), nil, 0644)
+}
+
+func useFakeProcFS(t *testing.T) {
+	orig := procFS
+	procFS = t.TempDir()
+	t.Cleanup(func() { procFS = orig })
+}
+
+func TestReadProcSample(t *testing.T) {
+	useFakeProcFS(t)
+	fakeProc(t, 150, 50)
+
+	sample, err := readProcSample(42)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, uint64(200), sample.cpuTicks)
+	assert.Equal(t, 37, sample.Threads)
+	assert.Equal(t, uint64(2048*1024), sample.RSS)
+	assert.Equal(t, uint64(4096), sample.ReadBytes)
+	assert.Equal(t, uint64(8192), sample.WriteBytes)
+	assert.Equal(t, 2, sample.OpenFDs)
+
+	t.Run("missing process", func(t *testing.T) {
+		_, err := readProcSample(99)
+		assert.Error(t, err)
+	})
+}
+
+func TestProcSampleCPU(t *testing.T) {
+	now := time.Now()
+	prev := procSample{cpuTicks: 100}
+	prev.Time = now
+
+	cur := procSample{cpuTicks: 300}
+	cur.Time = now.Add(time.Second * 4)
+
+	// 200 ticks = 2 seconds of CPU over 4 seconds
+	assert.InDelta(t, 50.0, cur.stats(prev).CPUPercent, 0.001)
+	assert.Zero(t, cur.stats(procSample{}).CPUPercent, "no previous sample")
+}
+
+func TestStatsHistory(t *testing.T) {
+	h := statsHistory{}
+
+	for i := 0; i < statsHistorySize+5; i++ {
+		h.Add(pickaxx.ProcessStats{Threads: i})
+	}
+
+	list := h.List()
+	assert.Len(t, list, statsHistorySize)
+	assert.Equal(t, 5, list[0].Threads)
+	assert.Equal(t, statsHistorySize+4, list[len(list)-1].Threads)
+}
+
+func TestSampleStats(t *testing.T) {
+	var (
+		h   = statsHistory{}
+		out = make(chan pickaxx.Data, 10)
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go sampleStats(ctx, os.Getpid(), &h, time.Millisecond*5, out)
+
+	data, _ := json.Marshal(<-out)
+	assert.Contains(t, string(data), `{"stats":{`)
+	assert.NotEmpty(t, h.List())
+}
@@ -0,0 +1,133 @@
+package gameserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ivan3bx/pickaxx"
+)
+
+func stopServer(ctx context.Context, m *Manager, opts pickaxx.StopOptions) {
+	var (
+		log     = log.WithField("action", "ProcessManager.stopServer()")
+		proc    = m.proc
+		console = m.console
+		wg      = sync.WaitGroup{}
+	)
+
+	defer func() {
+		wg.Wait()              // wait for routines to stop
+		m.nextState <- Stopped // set terminal state
+	}()
+
+	if proc == nil {
+		log.Warn("no process to stop")
+		return
+	}
+
+	// warn players ahead of time; Stop skips the rest of the countdown
+	countdown, skip := context.WithCancel(ctx)
+	m.lock.Lock()
+	m.skipCountdown = skip
+	m.lock.Unlock()
+
+	announceStop(countdown, m, opts)
+
+	m.lock.Lock()
+	m.skipCountdown = nil
+	m.lock.Unlock()
+	skip()
+
+	if opts.Save && m.Type.SaveCommand != "" {
+		if err := m.Send(m.Type.SaveCommand); err != nil {
+			log.WithError(err).Warn("unable to send save command")
+		}
+	}
+
+	// context used to halt process if clean exit does not complete
+	ctx, cancelTimer := context.WithTimeout(ctx, opts.KillTimeout)
+	defer cancelTimer()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		waitForTermination(ctx, proc)
+	}()
+
+	log.Info("clean shutdown starting")
+
+	if m.Type.StopCommand == "" {
+		if err := proc.Signal(os.Interrupt); err != nil {
+			log.WithError(err).Warn("unable to interrupt process")
+		}
+	} else if err := m.Send(m.Type.StopCommand); err != nil {
+		log.WithError(err).Warn("unable to send stop command")
+	}
+
+	if err := proc.Wait(); err != nil {
+		log.WithError(err).Warn("clean shutdown failed")
+	}
+
+	if console != nil {
+		console.Close()
+		console.remove()
+	}
+}
+
+// announceStop broadcasts a countdown to players, blocking until the
+// countdown completes or the context is cancelled.
+func announceStop(ctx context.Context, m *Manager, opts pickaxx.StopOptions) {
+	if opts.Countdown <= 0 {
+		return
+	}
+
+	// e.g. a title shown once, with the reason
+	if a, ok := m.Hooks().(Announcer); ok {
+		a.AnnounceStop(opts.Reason)
+	}
+
+	for remaining := opts.Countdown; remaining > 0; remaining -= opts.Interval {
+		if m.Type.SayCommand != "" {
+			m.Send(stopMessage(m.Type.SayCommand, remaining, opts.Reason))
+		}
+
+		// the last wait may be shorter than an interval
+		wait := opts.Interval
+		if wait > remaining {
+			wait = remaining
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// stopMessage is the message broadcast to players during a countdown.
+func stopMessage(say string, remaining time.Duration, reason string) string {
+	msg := fmt.Sprintf("%s Server stopping in %d seconds", say, int(remaining.Round(time.Second).Seconds()))
+
+	if reason != "" {
+		msg = fmt.Sprintf("%s: %s", msg, reason)
+	}
+
+	return msg
+}
+
+func waitForTermination(ctx context.Context, proc process) {
+	<-ctx.Done()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		log.Debug("deadline expired. force quit.")
+		proc.Kill()
+	}
+}
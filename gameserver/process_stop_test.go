@@ -0,0 +1,151 @@
+package gameserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopMessage(t *testing.T) {
+	assert.Equal(t, "say Server stopping in 30 seconds", stopMessage("say", time.Second*30, ""))
+	assert.Equal(t, "say Server stopping in 5 seconds: restarting", stopMessage("say", time.Second*5, "restarting"))
+}
+
+func TestStopOptions(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		o := pickaxx.NewStopOptions()
+		assert.Equal(t, pickaxx.DefaultKillTimeout, o.KillTimeout)
+		assert.Zero(t, o.Countdown)
+		assert.False(t, o.Save)
+	})
+
+	t.Run("interval bounded by countdown", func(t *testing.T) {
+		o := pickaxx.NewStopOptions(pickaxx.WithCountdown(time.Second*10, 0))
+		assert.Equal(t, time.Second*10, o.Interval)
+	})
+}
+
+func TestAnnounceStop(t *testing.T) {
+	m := &Manager{}
+
+	// the countdown is not a multiple of the interval; the last wait is shorter
+	start := time.Now()
+	announceStop(context.Background(), m, pickaxx.NewStopOptions(pickaxx.WithCountdown(time.Millisecond*300, time.Millisecond*200)))
+	elapsed := time.Since(start)
+
+	assert.True(t, elapsed >= time.Millisecond*300, "countdown cut short: %v", elapsed)
+	assert.True(t, elapsed < time.Millisecond*390, "countdown overshot: %v", elapsed)
+
+	// cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*20, cancel)
+
+	start = time.Now()
+	announceStop(ctx, m, pickaxx.NewStopOptions(pickaxx.WithCountdown(time.Minute, time.Second*10)))
+	assert.True(t, time.Since(start) < time.Second, "countdown not cancelled")
+}
+
+func TestStopSkipsCountdown(t *testing.T) {
+	m := &Manager{
+		Command:    []string{"cat"},
+		WorkingDir: t.TempDir(),
+	}
+
+	isRunning := m.notifier.Register(Running)
+	defer m.notifier.Unregister(isRunning)
+
+	isStopping := m.notifier.Register(Stopping)
+	defer m.notifier.Unregister(isStopping)
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+	<-isRunning
+
+	done := drain(activity)
+
+	require.NoError(t, m.Stop(pickaxx.WithCountdown(time.Minute, time.Second*10), pickaxx.WithKillTimeout(time.Millisecond*50)))
+	<-isStopping
+
+	// wait for the countdown to begin, then stop again
+	assert.Eventually(t, func() bool {
+		m.lock.RLock()
+		defer m.lock.RUnlock()
+		return m.skipCountdown != nil
+	}, time.Second, time.Millisecond*5)
+
+	require.NoError(t, m.Stop())
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		assert.Fail(t, "server still stopping after countdown skipped")
+	}
+}
+
+func TestStopWithReason(t *testing.T) {
+	m := &Manager{
+		Command:    []string{"cat"},
+		WorkingDir: t.TempDir(),
+	}
+
+	isRunning := m.notifier.Register(Running)
+	defer m.notifier.Unregister(isRunning)
+
+	activity, err := m.Start()
+	if !assert.NoError(t, err) {
+		return
+	}
+	<-isRunning
+
+	// 'cat' ignores the stop command; force a quick kill
+	assert.NoError(t, m.Stop(pickaxx.WithReason("maintenance"), pickaxx.WithKillTimeout(time.Millisecond*50)))
+
+	found := false
+	for data := range activity {
+		if ev, ok := data.(stateChangeEvent); ok && ev.State == Stopped {
+			found = ev.Reason == "maintenance"
+		}
+	}
+	assert.True(t, found, "expected reason on stop event")
+}
+
+func TestConcurrentStop(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		m := &Manager{
+			Command:    []string{"cat"},
+			WorkingDir: t.TempDir(),
+		}
+
+		isRunning := m.notifier.Register(Running)
+
+		activity, err := m.Start()
+		require.NoError(t, err)
+		<-isRunning
+		m.notifier.Unregister(isRunning)
+
+		done := drain(activity)
+
+		// e.g. a user request, and the liveness probe
+		errs := make(chan error, 2)
+		for j := 0; j < 2; j++ {
+			go func() {
+				errs <- m.Stop(pickaxx.WithKillTimeout(time.Millisecond * 50))
+			}()
+		}
+
+		first, second := <-errs, <-errs
+		assert.True(t, first == nil || second == nil, "neither stop succeeded: %v, %v", first, second)
+
+		select {
+		case <-done:
+		case <-time.After(time.Second * 5):
+			require.Fail(t, "server still stopping after concurrent stops")
+		}
+
+		assert.Equal(t, Stopped, m.State())
+	}
+}
@@ -0,0 +1,198 @@
+package gameserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"text/template"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+)
+
+// ProbeKind identifies how the liveness of a server is checked.
+type ProbeKind string
+
+// Liveness probes
+const (
+	ProbeTCP  ProbeKind = "tcp"  // connect to the server port
+	ProbeNone ProbeKind = "none" // not checked
+)
+
+// Events recognized in console output by a LineParser.
+const (
+	EventPlayerJoined = "playerJoined"
+	EventPlayerLeft   = "playerLeft"
+)
+
+// Pattern is a regular expression, encoded in JSON as a string.
+type Pattern struct {
+	*regexp.Regexp
+}
+
+// MustPattern compiles a pattern, and panics if it is invalid.
+func MustPattern(expr string) *Pattern {
+	return &Pattern{regexp.MustCompile(expr)}
+}
+
+// MarshalJSON converts this pattern to a JSON string.
+func (p Pattern) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON compiles a pattern from a JSON string.
+func (p *Pattern) UnmarshalJSON(b []byte) error {
+	var expr string
+
+	if err := json.Unmarshal(b, &expr); err != nil {
+		return err
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+
+	p.Regexp = re
+	return nil
+}
+
+// LineParser recognizes an event in a line of console output.
+type LineParser struct {
+	Pattern *Pattern `json:"pattern"` // The first submatch is the subject of the event (e.g. a player name).
+	Event   string   `json:"event"`   // One of the Event* constants.
+}
+
+// ServerType describes how to run and manage a kind of game server.
+// Anything particular to a game (e.g. its settings files) is left to the
+// hooks of the type.
+type ServerType struct {
+	Name           string             `json:"name"`
+	Command        []string           `json:"command"`        // Each argument is a template, which may use {{.Port}} and {{.WorkingDir}}.
+	Env            []string           `json:"env"`            // Environment variables (e.g. "KEY=value"), in addition to pickaxx's own.
+	DefaultPort    int                `json:"defaultPort"`    // Port used if neither configured, nor read by the hooks of the type.
+	Network        string             `json:"network"`        // "tcp" (the default) or "udp".
+	StopCommand    string             `json:"stopCommand"`    // Console command for a clean shutdown. If empty, the process is interrupted.
+	SaveCommand    string             `json:"saveCommand"`    // Console command which saves the world, if supported.
+	SaveOffCommand string             `json:"saveOffCommand"` // Console command which stops the server writing the world (e.g. during a backup), if supported.
+	SaveOnCommand  string             `json:"saveOnCommand"`  // Console command which resumes writing the world.
+	Saved          *Pattern           `json:"saved"`          // Matches output once the world is saved. If not set, saving is not waited for.
+	SayCommand     string             `json:"sayCommand"`     // Console command broadcasting a message to players, if supported.
+	Ready          *Pattern           `json:"ready"`          // Matches output once the server has loaded. If not set, ready once started.
+	Probe          ProbeKind          `json:"probe"`          // Defaults to ProbeTCP if not set.
+	ProbeDelay     pickaxx.Duration   `json:"probeDelay"`     // Wait once ready before probing. Defaults to 15s if 'Ready' is not set.
+	Parsers        []LineParser       `json:"parsers"`        // Recognize events in console output.
+	Failures       []FailureSignature `json:"failures"`       // Recognize startup failures in console output.
+
+	// Hooks creates the hooks of a manager of this type (see Hooks), if set.
+	Hooks func(m *Manager) Hooks `json:"-"`
+}
+
+// Types are the server types registered, by name.
+var Types = map[string]ServerType{}
+
+// Register makes a server type available by name (see Types), replacing any
+// of the same name. This is meant to be called as a package is initialized,
+// and panics if the type is invalid.
+func Register(t ServerType) {
+	if err := t.Validate(); err != nil {
+		panic(err)
+	}
+	Types[t.Name] = t
+}
+
+// Validate returns an error if this type can not be used to run a server.
+func (t ServerType) Validate() error {
+	if t.Name == "" {
+		return errors.New("server type has no name")
+	}
+
+	if len(t.Command) == 0 {
+		return fmt.Errorf("server type '%s' has no command", t.Name)
+	}
+
+	if _, ok := Probes[t.Probe]; !ok && t.Probe != "" && t.Probe != ProbeNone {
+		return fmt.Errorf("server type '%s' has unknown probe '%s'", t.Name, t.Probe)
+	}
+
+	if t.ProbeDelay < 0 {
+		return fmt.Errorf("server type '%s' has a negative probe delay", t.Name)
+	}
+
+	switch t.Network {
+	case "", "tcp", "udp":
+	default:
+		return fmt.Errorf("server type '%s' has unknown network '%s'", t.Name, t.Network)
+	}
+
+	for _, p := range t.Parsers {
+		if p.Pattern == nil {
+			return fmt.Errorf("server type '%s' has a parser with no pattern", t.Name)
+		}
+
+		switch p.Event {
+		case EventPlayerJoined, EventPlayerLeft:
+		default:
+			return fmt.Errorf("server type '%s' has a parser with unknown event '%s'", t.Name, p.Event)
+		}
+	}
+
+	for _, f := range t.Failures {
+		if f.Pattern == nil || f.Code == "" {
+			return fmt.Errorf("server type '%s' has a failure with no pattern or code", t.Name)
+		}
+	}
+
+	_, err := t.command(t.DefaultPort, DefaultWorkingDir)
+	return err
+}
+
+// network returns the network of the server port.
+func (t ServerType) network() string {
+	if t.Network == "" {
+		return "tcp"
+	}
+	return t.Network
+}
+
+// probeDelay returns how long to wait, once the server is ready, before
+// probing it. Servers with no ready pattern are ready once started, so are
+// given time to load.
+func (t ServerType) probeDelay() time.Duration {
+	switch {
+	case t.ProbeDelay > 0:
+		return time.Duration(t.ProbeDelay)
+	case t.Ready == nil:
+		return defaultProbeDelay
+	}
+	return 0
+}
+
+// command expands the command template for a server.
+func (t ServerType) command(port int, workingDir string) ([]string, error) {
+	var (
+		args = make([]string, len(t.Command))
+		data = struct {
+			Port       int
+			WorkingDir string
+		}{port, workingDir}
+	)
+
+	for i, arg := range t.Command {
+		tmpl, err := template.New("arg").Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command argument '%s': %w", arg, err)
+		}
+
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("invalid command argument '%s': %w", arg, err)
+		}
+
+		args[i] = b.String()
+	}
+
+	return args, nil
+}
@@ -0,0 +1,106 @@
+package gameserver
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const terrariaJSON = `{
+	"name": "terraria",
+	"command": ["./TerrariaServer", "-port", "{{.Port}}", "-world", "{{.WorkingDir}}/worlds/world.wld"],
+	"stopCommand": "exit",
+	"saveCommand": "save",
+	"sayCommand": "say",
+	"ready": "Server started",
+	"probe": "tcp",
+	"parsers": [
+		{"pattern": "^(\\w+) has joined\\.$", "event": "playerJoined"},
+		{"pattern": "^(\\w+) has left\\.$", "event": "playerLeft"}
+	]
+}`
+
+func TestServerTypeJSON(t *testing.T) {
+	var st ServerType
+	require.NoError(t, json.Unmarshal([]byte(terrariaJSON), &st))
+	require.NoError(t, st.Validate())
+
+	cmd, err := st.command(7777, "/srv/terraria")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"./TerrariaServer", "-port", "7777", "-world", "/srv/terraria/worlds/world.wld"}, cmd)
+
+	assert.True(t, st.Ready.MatchString("Server started"))
+
+	tr := playerTracker{}
+	tr.Reset(st.Parsers)
+
+	data := tr.Observe("Steve has joined.")
+	require.Len(t, data, 1)
+	assert.Equal(t, playerEvent{Name: "Steve", Action: "joined"}, data[0])
+	assert.Nil(t, tr.Observe("[12:00:00] [Server thread/INFO]: Alex joined the game"), "minecraft output is ignored")
+	assert.Equal(t, []string{"Steve"}, tr.List())
+
+	// round trip
+	b, err := json.Marshal(st)
+	require.NoError(t, err)
+
+	var decoded ServerType
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, st.Ready.String(), decoded.Ready.String())
+}
+
+func TestServerTypeValidate(t *testing.T) {
+	assert.NoError(t, ServerType{Name: "x", Command: []string{"true"}}.Validate())
+
+	tests := []struct {
+		name string
+		st   ServerType
+	}{
+		{"no name", ServerType{Command: []string{"true"}}},
+		{"no command", ServerType{Name: "x"}},
+		{"unknown probe", ServerType{Name: "x", Command: []string{"true"}, Probe: "icmp"}},
+		{"bad template", ServerType{Name: "x", Command: []string{"{{.Nope}}"}}},
+		{"unknown event", ServerType{Name: "x", Command: []string{"true"}, Parsers: []LineParser{{MustPattern("x"), "died"}}}},
+		{"negative probe delay", ServerType{Name: "x", Command: []string{"true"}, ProbeDelay: pickaxx.Duration(-time.Second)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, tt.st.Validate())
+		})
+	}
+}
+
+func TestServerTypeProbeDelay(t *testing.T) {
+	assert.Equal(t, defaultProbeDelay, ServerType{}.probeDelay(), "no ready pattern")
+	assert.Zero(t, ServerType{Ready: MustPattern("x")}.probeDelay(), "probed once ready")
+	assert.Equal(t, time.Minute, ServerType{Ready: MustPattern("x"), ProbeDelay: pickaxx.Duration(time.Minute)}.probeDelay())
+}
+
+func TestServerTypeInterruptedWithoutStopCommand(t *testing.T) {
+	m := New(testPort, WithType(ServerType{
+		Name:    "sleeper",
+		Command: []string{"sleep", "30"},
+		Probe:   ProbeNone,
+	}), WithWorkingDir(t.TempDir()))
+
+	isRunning := m.notifier.Register(Running)
+	defer m.notifier.Unregister(isRunning)
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+	<-isRunning
+
+	done := drain(activity)
+	start := time.Now()
+
+	require.NoError(t, m.Stop())
+	<-done
+
+	assert.Less(t, int64(time.Since(start)), int64(time.Second*5), "expected interrupt, not kill timeout")
+	assert.Equal(t, Stopped, m.State())
+}
@@ -0,0 +1,66 @@
+package pickaxx
+
+import "time"
+
+// HealthChecker is implemented by components which can report on their own health.
+type HealthChecker interface {
+
+	// Healthy returns an error if this component is not functioning (e.g. wedged).
+	Healthy() error
+
+	// Ready returns an error if this component is not yet able to do work.
+	Ready() error
+}
+
+// ServerHealth summarizes the health of a managed server.
+type ServerHealth struct {
+	State     string     `json:"state"`
+	Healthy   bool       `json:"healthy"`             // Running, and responding to liveness probes.
+	LastProbe *time.Time `json:"lastProbe,omitempty"` // Last successful liveness probe.
+	LastCrash *time.Time `json:"lastCrash,omitempty"` // Last time the server stopped responding.
+	Crashes   uint64     `json:"crashes"`
+
+	// Why the server failed to start, if it did.
+	Failure *StartupFailure `json:"failure,omitempty"`
+}
+
+// StartupFailure describes why a server failed to start, as recognized in
+// its console output.
+type StartupFailure struct {
+	Code    string    `json:"code"`          // Identifies the failure (e.g. "eula").
+	Message string    `json:"message"`       // What went wrong.
+	Fix     string    `json:"fix,omitempty"` // How to fix it.
+	Line    string    `json:"line"`          // The line of output recognized.
+	Time    time.Time `json:"time"`
+}
+
+// HealthReporter is implemented by process managers that summarize server health.
+type HealthReporter interface {
+
+	// Health returns a summary of the server's current health.
+	Health() ServerHealth
+}
+
+// ServerInfo is reported by a server in response to a status query (e.g. a
+// liveness probe).
+type ServerInfo struct {
+	MOTD        string    `json:"motd"`
+	Version     string    `json:"version"`
+	Players     int       `json:"players"` // Number of players online.
+	MaxPlayers  int       `json:"maxPlayers"`
+	PlayerNames []string  `json:"playerNames,omitempty"` // Every player online, if reported.
+	World       string    `json:"world,omitempty"`
+	GameMode    string    `json:"gameMode,omitempty"`
+	GameType    string    `json:"gameType,omitempty"` // e.g. "SMP"
+	Software    string    `json:"software,omitempty"` // Server software, if modified (e.g. "Paper on 1.20.1").
+	Plugins     []string  `json:"plugins,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// InfoReporter is implemented by process managers able to query the status
+// of a running server.
+type InfoReporter interface {
+
+	// Info returns the latest status reported by the server, or nil if not known.
+	Info() *ServerInfo
+}
@@ -0,0 +1,18 @@
+package pickaxx
+
+// JarInfo describes a server jar, as read from its contents.
+type JarInfo struct {
+	Path        string `json:"path"`
+	Flavor      string `json:"flavor"`                // Server software, e.g. "vanilla", "paper" or "fabric".
+	Version     string `json:"version,omitempty"`     // Game version, e.g. "1.20.1".
+	Protocol    int    `json:"protocol,omitempty"`    // Network protocol version, e.g. 763.
+	JavaVersion int    `json:"javaVersion,omitempty"` // Java version required, e.g. 17.
+}
+
+// JarReporter is implemented by process managers able to read the jar a
+// server is launched from.
+type JarReporter interface {
+
+	// JarInfo returns a description of the server jar.
+	JarInfo() (*JarInfo, error)
+}
@@ -0,0 +1,16 @@
+package pickaxx
+
+// JavaRuntime is an installed Java runtime (JDK or JRE).
+type JavaRuntime struct {
+	Path    string `json:"path"`    // The java executable.
+	Version string `json:"version"` // e.g. "17.0.2", or "1.8.0_292".
+	Major   int    `json:"major"`   // e.g. 17, or 8.
+}
+
+// JavaLister is implemented by process managers able to find the Java
+// runtimes installed on this host.
+type JavaLister interface {
+
+	// JavaRuntimes returns the Java runtimes found, newest first.
+	JavaRuntimes() []JavaRuntime
+}
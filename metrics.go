@@ -0,0 +1,199 @@
+package pickaxx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric types, as described by the Prometheus text exposition format.
+const (
+	Counter   = "counter"
+	Gauge     = "gauge"
+	Histogram = "histogram"
+)
+
+// Labels are name/value pairs which identify a sample.
+type Labels map[string]string
+
+// Sample is a single value of a metric.
+type Sample struct {
+	Suffix string // Appended to the metric name (e.g. "_bucket" for histograms).
+	Labels Labels
+	Value  float64
+}
+
+// Metric is a named set of samples.
+type Metric struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// MetricsCollector is implemented by anything which reports metrics.
+type MetricsCollector interface {
+
+	// Collect returns the current value of all metrics.
+	Collect() []Metric
+}
+
+// WriteMetrics writes metrics from all collectors to w, in the Prometheus
+// text exposition format.
+func WriteMetrics(w io.Writer, collectors ...MetricsCollector) error {
+	bw := bufio.NewWriter(w)
+
+	for _, c := range collectors {
+		for _, m := range c.Collect() {
+			fmt.Fprintf(bw, "# HELP %s %s\n", m.Name, escapeHelp(m.Help))
+			fmt.Fprintf(bw, "# TYPE %s %s\n", m.Name, m.Type)
+
+			for _, s := range m.Samples {
+				fmt.Fprintf(bw, "%s%s%s %s\n", m.Name, s.Suffix, formatLabels(s.Labels), formatValue(s.Value))
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+func formatLabels(l Labels) string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, escapeLabel(l[name]))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// DefaultBuckets are histogram buckets (in seconds) suited to request latencies.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// LatencyHistogram tracks durations, partitioned by labels. This
+// implementation can be accessed concurrently by multiple goroutines.
+type LatencyHistogram struct {
+	Name    string
+	Help    string
+	Buckets []float64 // Defaults to 'DefaultBuckets' if not set.
+
+	mutex  sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels Labels
+	counts []uint64 // cumulative count per bucket
+	count  uint64
+	sum    float64
+}
+
+// Observe records a duration for the given labels.
+func (h *LatencyHistogram) Observe(d time.Duration, labels Labels) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.Buckets == nil {
+		h.Buckets = DefaultBuckets
+	}
+
+	if h.series == nil {
+		h.series = map[string]*histogramSeries{}
+	}
+
+	key := formatLabels(labels)
+	s, ok := h.series[key]
+
+	if !ok {
+		s = &histogramSeries{labels: labels, counts: make([]uint64, len(h.Buckets))}
+		h.series[key] = s
+	}
+
+	v := d.Seconds()
+	for i, upper := range h.Buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+
+	s.count++
+	s.sum += v
+}
+
+// Collect returns the current value of all series.
+func (h *LatencyHistogram) Collect() []Metric {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	m := Metric{Name: h.Name, Help: h.Help, Type: Histogram}
+
+	for _, k := range keys {
+		s := h.series[k]
+
+		for i, upper := range h.Buckets {
+			m.Samples = append(m.Samples, Sample{
+				Suffix: "_bucket",
+				Labels: withLabel(s.labels, "le", formatValue(upper)),
+				Value:  float64(s.counts[i]),
+			})
+		}
+
+		m.Samples = append(m.Samples,
+			Sample{Suffix: "_bucket", Labels: withLabel(s.labels, "le", "+Inf"), Value: float64(s.count)},
+			Sample{Suffix: "_sum", Labels: s.labels, Value: s.sum},
+			Sample{Suffix: "_count", Labels: s.labels, Value: float64(s.count)},
+		)
+	}
+
+	return []Metric{m}
+}
+
+// withLabel returns a copy of labels with an additional name/value.
+func withLabel(l Labels, name, value string) Labels {
+	copy := Labels{name: value}
+	for k, v := range l {
+		copy[k] = v
+	}
+	return copy
+}
@@ -0,0 +1,67 @@
+package pickaxx
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type staticCollector []Metric
+
+func (c staticCollector) Collect() []Metric { return c }
+
+func TestWriteMetrics(t *testing.T) {
+	c := staticCollector{
+		{
+			Name: "test_state",
+			Help: "A test gauge.\nSecond line.",
+			Type: Gauge,
+			Samples: []Sample{
+				{Labels: Labels{"state": "Running", "server": `a "quoted" name`}, Value: 1},
+				{Labels: Labels{"state": "Stopped"}, Value: 0},
+			},
+		},
+		{
+			Name:    "test_total",
+			Help:    "A test counter.",
+			Type:    Counter,
+			Samples: []Sample{{Value: 42}},
+		},
+	}
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, WriteMetrics(&buf, c))
+	assert.Equal(t, `# HELP test_state A test gauge.\nSecond line.
+# TYPE test_state gauge
+test_state{server="a \"quoted\" name",state="Running"} 1
+test_state{state="Stopped"} 0
+# HELP test_total A test counter.
+# TYPE test_total counter
+test_total 42
+`, buf.String())
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	h := LatencyHistogram{
+		Name:    "test_seconds",
+		Help:    "Test latency.",
+		Buckets: []float64{0.1, 1},
+	}
+
+	h.Observe(time.Millisecond*50, Labels{"path": "/"})
+	h.Observe(time.Millisecond*500, Labels{"path": "/"})
+	h.Observe(time.Second*2, Labels{"path": "/"})
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, WriteMetrics(&buf, &h))
+	assert.Equal(t, `# HELP test_seconds Test latency.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1",path="/"} 1
+test_seconds_bucket{le="1",path="/"} 2
+test_seconds_bucket{le="+Inf",path="/"} 3
+test_seconds_sum{path="/"} 2.55
+test_seconds_count{path="/"} 3
+`, buf.String())
+}
@@ -0,0 +1,104 @@
+package minecraft
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ivan3bx/pickaxx"
+)
+
+var _ pickaxx.AllowlistManager = &server{}
+
+// ErrInvalidPlayer is returned for a player name which can not be sent to the server.
+var ErrInvalidPlayer = errors.New("invalid player name")
+
+// anyPlayer matches names which can be sent to the console, for types which
+// do not describe valid player names.
+var anyPlayer = regexp.MustCompile(`^\w[\w ]{0,31}$`)
+
+// allowlistEntry is an entry of the server's allowlist file. Other fields
+// (e.g. uuid, xuid) are managed by the server.
+type allowlistEntry struct {
+	Name string `json:"name"`
+}
+
+// Allowlist returns the players allowed to join, as last saved by the server.
+func (s *server) Allowlist() (pickaxx.Allowlist, error) {
+	var (
+		list = pickaxx.Allowlist{Players: []string{}}
+		e    = s.edition
+	)
+
+	if e.AllowlistFile == "" {
+		return list, pickaxx.ErrNotSupported
+	}
+
+	if key := e.Properties.Allowlist; key != "" {
+		props, err := s.properties()
+		if err != nil {
+			return list, err
+		}
+		list.Enabled = props[key] == "true"
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(s.m.WorkingDir, e.AllowlistFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return list, nil
+	} else if err != nil {
+		return list, err
+	}
+
+	var entries []allowlistEntry
+
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return list, fmt.Errorf("invalid allowlist file: %w", err)
+	}
+
+	for _, e := range entries {
+		list.Players = append(list.Players, e.Name)
+	}
+	sort.Strings(list.Players)
+
+	return list, nil
+}
+
+// Allow adds a player to the allowlist. The server saves the allowlist
+// itself, so it must be running.
+func (s *server) Allow(name string) error {
+	return s.submitAllowlist("add", name)
+}
+
+// Disallow removes a player from the allowlist. The server must be running.
+func (s *server) Disallow(name string) error {
+	return s.submitAllowlist("remove", name)
+}
+
+func (s *server) submitAllowlist(action, name string) error {
+	e := s.edition
+
+	if e.AllowlistCommand == "" {
+		return pickaxx.ErrNotSupported
+	}
+
+	valid := anyPlayer // also checked, in case a configured pattern is looser
+	if e.PlayerName != nil {
+		valid = e.PlayerName.Regexp
+	}
+
+	if !valid.MatchString(name) || !anyPlayer.MatchString(name) {
+		return fmt.Errorf("%w: '%s'", ErrInvalidPlayer, name)
+	}
+
+	if strings.Contains(name, " ") {
+		name = fmt.Sprintf(`"%s"`, name)
+	}
+
+	return s.m.Send(fmt.Sprintf("%s %s %s", e.AllowlistCommand, action, name))
+}
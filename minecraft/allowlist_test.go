@@ -0,0 +1,122 @@
+package minecraft
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowlist(t *testing.T) {
+	dir := t.TempDir()
+
+	m := New(0, gameserver.WithType(Bedrock), gameserver.WithWorkingDir(dir))
+
+	list, err := m.Allowlist()
+	require.NoError(t, err)
+	assert.False(t, list.Enabled)
+	assert.Empty(t, list.Players)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, PropertiesFile), []byte("allow-list=true\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "allowlist.json"), []byte(`[
+		{"ignoresPlayerLimit": false, "name": "Steve Two", "xuid": "2535416409681153"},
+		{"ignoresPlayerLimit": false, "name": "Alex"}
+	]`), 0644))
+
+	list, err = m.Allowlist()
+	require.NoError(t, err)
+	assert.True(t, list.Enabled)
+	assert.Equal(t, []string{"Alex", "Steve Two"}, list.Players)
+
+	// java edition uses its own file & property
+	m = New(0, gameserver.WithWorkingDir(dir))
+
+	list, err = m.Allowlist()
+	require.NoError(t, err)
+	assert.False(t, list.Enabled)
+	assert.Empty(t, list.Players)
+
+	// other types have no allowlist
+	m = gameserver.New(0, gameserver.WithType(gameserver.ServerType{Name: "other", Command: []string{"true"}}), gameserver.WithWorkingDir(dir))
+	_, err = m.Allowlist()
+	assert.True(t, errors.Is(err, pickaxx.ErrNotSupported))
+}
+
+func TestPlayerNames(t *testing.T) {
+	tests := []struct {
+		typ   gameserver.ServerType
+		name  string
+		valid bool
+	}{
+		{JavaEdition, "Steve", true},
+		{JavaEdition, "Notch_1999", true},
+		{JavaEdition, "Al", false},
+		{JavaEdition, "AVeryLongPlayerName", false},
+		{JavaEdition, "Steve Two", false},
+		{Bedrock, "Steve Two", true},
+		{Bedrock, "Gamer 123", true},
+		{Bedrock, "1Steve", false},
+		{Bedrock, "Steve_Two", false},
+		{Bedrock, "Steve\nstop", false},
+		{gameserver.ServerType{Name: "custom", Hooks: Edition{AllowlistCommand: "allowlist"}.Hooks}, "any_name with spaces", true},
+		{gameserver.ServerType{Name: "custom", Hooks: Edition{AllowlistCommand: "allowlist", PlayerName: gameserver.MustPattern(`.+`)}.Hooks}, "Steve\"; stop", false},
+	}
+
+	for _, tt := range tests {
+		tt.typ.Command = []string{"true"}
+		m := New(0, gameserver.WithType(tt.typ), gameserver.WithWorkingDir(t.TempDir()))
+
+		// a valid name is sent, failing as the server is not running
+		err := m.Allow(tt.name)
+		assert.Equal(t, !tt.valid, errors.Is(err, ErrInvalidPlayer), "%s: '%s'", tt.typ.Name, tt.name)
+	}
+}
+
+func TestAllowCommand(t *testing.T) {
+	m := New(0, gameserver.WithType(gameserver.ServerType{
+		Name:    "echo",
+		Command: []string{"cat"},
+		Probe:   gameserver.ProbeNone,
+		Hooks:   Edition{AllowlistCommand: "allowlist"}.Hooks,
+	}), gameserver.WithWorkingDir(t.TempDir()))
+
+	assert.Equal(t, gameserver.ErrNoProcess, m.Allow("Steve"), "server must be running")
+
+	isRunning := m.Notifier().Register(gameserver.Running)
+	defer m.Notifier().Unregister(isRunning)
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+	<-isRunning
+
+	assert.True(t, errors.Is(m.Allow("Steve\nstop"), ErrInvalidPlayer))
+
+	require.NoError(t, m.Allow("Steve Two"))
+	require.NoError(t, m.Disallow("Alex"))
+
+	var output []string
+	for data := range activity {
+		if out, ok := data.(fmt.Stringer); ok {
+			output = append(output, out.String())
+		}
+
+		if len(output) > 0 && strings.HasPrefix(output[len(output)-1], "allowlist remove") {
+			break
+		}
+	}
+
+	assert.Contains(t, output, `allowlist add "Steve Two"`)
+	assert.Contains(t, output, "allowlist remove Alex")
+
+	done := drain(activity)
+	m.Stop(pickaxx.WithKillTimeout(time.Millisecond * 50))
+	<-done
+}
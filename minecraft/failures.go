@@ -0,0 +1,58 @@
+package minecraft
+
+import "github.com/ivan3bx/pickaxx/gameserver"
+
+// Startup failures
+const (
+	FailureEULA        = "eula"
+	FailurePortInUse   = "portInUse"
+	FailureJavaVersion = "javaVersion"
+	FailureJarMissing  = "jarMissing"
+	FailureMemory      = "memory"
+)
+
+// JavaFailures are startup failures of Minecraft: Java Edition, and of the
+// JVM running it.
+var JavaFailures = []gameserver.FailureSignature{
+	{
+		Pattern: gameserver.MustPattern(`You need to agree to the EULA in order to run the server`),
+		Code:    FailureEULA,
+		Message: "EULA not accepted",
+		Fix:     "Read https://aka.ms/MinecraftEULA, then set eula=true in " + EULAFile,
+	},
+	{
+		Pattern: gameserver.MustPattern(`\*\*\*\* FAILED TO BIND TO PORT!`),
+		Code:    FailurePortInUse,
+		Message: "server port already in use",
+		Fix:     "Stop the process using the port, or change server-port in " + PropertiesFile,
+	},
+	{
+		Pattern: gameserver.MustPattern(`UnsupportedClassVersionError`),
+		Code:    FailureJavaVersion,
+		Message: "server jar requires a newer version of Java",
+		Fix:     "Install a newer Java runtime, or use a server jar built for this one",
+	},
+	{
+		Pattern: gameserver.MustPattern(`Error: Unable to access jarfile`),
+		Code:    FailureJarMissing,
+		Message: "server jar not found",
+		Fix:     "Check the server jar exists in the working directory, and is named by the command",
+	},
+	{
+		Pattern: gameserver.MustPattern(`Could not reserve enough space for .*object heap|Invalid (initial|maximum) heap size`),
+		Code:    FailureMemory,
+		Message: "unable to allocate memory for the server",
+		Fix:     "Lower the memory allocated (-Xmx), or free memory on this machine",
+	},
+}
+
+// BedrockFailures are startup failures of Bedrock Dedicated Server.
+var BedrockFailures = []gameserver.FailureSignature{
+	{
+		// e.g. "[2024-01-01 12:00:00:000 ERROR] Network port occupied, can't start server."
+		Pattern: gameserver.MustPattern(`Network port occupied, can't start server`),
+		Code:    FailurePortInUse,
+		Message: "server port already in use",
+		Fix:     "Stop the process using the port, or change server-port in " + PropertiesFile,
+	},
+}
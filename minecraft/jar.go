@@ -0,0 +1,308 @@
+package minecraft
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ivan3bx/pickaxx"
+)
+
+var _ pickaxx.JarReporter = &server{}
+
+// classMagic begins every Java class file.
+const classMagic = 0xcafebabe
+
+// Server flavors, recognized in a server jar
+const (
+	FlavorVanilla = "vanilla"
+	FlavorPaper   = "paper"
+	FlavorSpigot  = "spigot"
+	FlavorFabric  = "fabric"
+	FlavorForge   = "forge"
+	FlavorUnknown = "unknown"
+)
+
+// ErrNotJar is returned when a server is not launched from a jar.
+var ErrNotJar = errors.New("server is not launched from a jar")
+
+// versionJSON is written to the root of vanilla server jars (1.14 and
+// later), and those built from them (e.g. Paper).
+type versionJSON struct {
+	ID       string `json:"id"`               // e.g. "1.20.1"
+	Protocol int    `json:"protocol_version"` // e.g. 763
+	Java     int    `json:"java_version"`     // e.g. 17 (1.17 and later)
+}
+
+// jarCache caches the description of the server jar, until it changes.
+type jarCache struct {
+	sync.Mutex
+	key  string // path, modification time & size of the jar
+	info pickaxx.JarInfo
+}
+
+// JarInfo returns a description of the server jar. This is an error if the
+// server is not launched from a jar.
+func (s *server) JarInfo() (*pickaxx.JarInfo, error) {
+	command, err := s.m.CommandLine()
+	if err != nil {
+		return nil, err
+	}
+
+	jar := jarArg(command)
+	if jar == "" {
+		return nil, ErrNotJar
+	}
+
+	path := filepath.Join(s.m.WorkingDir, jar)
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s\x00%d\x00%d", path, stat.ModTime().UnixNano(), stat.Size())
+
+	s.jarInfo.Lock()
+	defer s.jarInfo.Unlock()
+
+	if s.jarInfo.key != key {
+		info, err := readJarInfo(path)
+		if err != nil {
+			return nil, err
+		}
+
+		s.jarInfo.key, s.jarInfo.info = key, *info
+	}
+
+	info := s.jarInfo.info
+	return &info, nil
+}
+
+// readJarInfo reads the version & flavor of a server jar.
+func readJarInfo(path string) (*pickaxx.JarInfo, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	files := map[string]*zip.File{}
+	for _, f := range r.File {
+		files[f.Name] = f
+	}
+
+	manifest, err := jarManifest(files)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &pickaxx.JarInfo{Path: path, Flavor: jarFlavor(files, manifest)}
+
+	if f, ok := files["version.json"]; ok {
+		var v versionJSON
+		if err := readJSON(f, &v); err == nil {
+			info.Version, info.Protocol, info.JavaVersion = v.ID, v.Protocol, v.Java
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = jarGameVersion(files, manifest, info.Flavor)
+	}
+
+	// older jars only record the Java version they were compiled for
+	if main := manifest["Main-Class"]; main != "" {
+		name := strings.ReplaceAll(main, ".", "/") + ".class"
+
+		if f, ok := files[name]; ok {
+			if v, err := classJavaVersion(f); err == nil && v > info.JavaVersion {
+				info.JavaVersion = v
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// jarJavaVersion returns the Java version required to run a jar, from its
+// version.json, or the class file version of its main class (e.g. 61 is
+// Java 17), whichever is later.
+func jarJavaVersion(path string) (int, error) {
+	info, err := readJarInfo(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if info.JavaVersion == 0 {
+		return 0, errors.New("java version not found in jar")
+	}
+
+	return info.JavaVersion, nil
+}
+
+// jarFlavor recognizes the server software of a jar, from its main class and
+// the packages it contains.
+func jarFlavor(files map[string]*zip.File, manifest map[string]string) string {
+	main := manifest["Main-Class"]
+
+	hasPrefix := func(prefixes ...string) bool {
+		for name := range files {
+			for _, p := range prefixes {
+				if strings.HasPrefix(name, p) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(main, "io.papermc.") || strings.HasPrefix(main, "com.destroystokyo.paperclip."),
+		hasPrefix("io/papermc/paper/", "com/destroystokyo/paper/"):
+		return FlavorPaper
+	case strings.HasPrefix(main, "net.fabricmc."):
+		return FlavorFabric
+	case strings.HasPrefix(main, "net.minecraftforge.") || strings.HasPrefix(main, "net.neoforged."),
+		hasPrefix("net/minecraftforge/"):
+		return FlavorForge
+	case strings.HasPrefix(main, "org.bukkit.craftbukkit."):
+		return FlavorSpigot
+	case strings.HasPrefix(main, "net.minecraft."):
+		return FlavorVanilla
+	}
+
+	return FlavorUnknown
+}
+
+// jarGameVersion returns the game version of a jar without a version.json,
+// from files left by the installers of other server software.
+func jarGameVersion(files map[string]*zip.File, manifest map[string]string, flavor string) string {
+	// Fabric's server launcher: "game-version=1.20.1"
+	if f, ok := files["install.properties"]; ok {
+		if v := readKeyValues(f, "=")["game-version"]; v != "" {
+			return v
+		}
+	}
+
+	// Paperclip & Spigot bundles: "<hash>\t1.20.1-R0.1-SNAPSHOT\tpaper-1.20.1.jar"
+	if f, ok := files["META-INF/versions.list"]; ok {
+		for _, line := range readLines(f) {
+			if fields := strings.Split(line, "\t"); len(fields) == 3 {
+				return strings.SplitN(fields[1], "-", 2)[0]
+			}
+		}
+	}
+
+	// Forge: "Implementation-Version: 1.20.1-47.1.0"
+	if v := manifest["Implementation-Version"]; flavor == FlavorForge && strings.Contains(v, "-") {
+		return strings.SplitN(v, "-", 2)[0]
+	}
+
+	return ""
+}
+
+// jarManifest returns the main attributes of a jar's manifest.
+func jarManifest(files map[string]*zip.File) (map[string]string, error) {
+	f, ok := files["META-INF/MANIFEST.MF"]
+	if !ok {
+		return nil, errors.New("jar has no manifest")
+	}
+
+	return readKeyValues(f, ":"), nil
+}
+
+// readKeyValues reads a file of the form "key<sep>value" per line.
+func readKeyValues(f *zip.File, sep string) map[string]string {
+	values := map[string]string{}
+
+	for _, line := range readLines(f) {
+		if parts := strings.SplitN(line, sep, 2); len(parts) == 2 {
+			values[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	return values
+}
+
+// readLines reads the lines of a file, in order.
+func readLines(f *zip.File) []string {
+	rc, err := f.Open()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+
+	var lines []string
+
+	s := bufio.NewScanner(rc)
+	for s.Scan() {
+		lines = append(lines, strings.TrimRight(s.Text(), "\r"))
+	}
+
+	return lines
+}
+
+func readJSON(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return json.NewDecoder(rc).Decode(v)
+}
+
+// classJavaVersion reads the header of a class file:
+//
+//	uint32 magic
+//	uint16 minor version
+//	uint16 major version (45 is Java 1.1, 52 is Java 8)
+func classJavaVersion(f *zip.File) (int, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	var header [8]byte
+	if _, err := io.ReadFull(rc, header[:]); err != nil {
+		return 0, err
+	}
+
+	if binary.BigEndian.Uint32(header[:4]) != classMagic {
+		return 0, errors.New("not a class file")
+	}
+
+	major := int(binary.BigEndian.Uint16(header[6:]))
+	if major < 49 {
+		return 5, nil // 1.5 or earlier
+	}
+
+	return major - 44, nil
+}
+
+// DescribeJar summarizes a server jar, e.g. "Paper 1.20.1 (Java 17)".
+func DescribeJar(info *pickaxx.JarInfo) string {
+	desc := info.Flavor
+	if desc != "" {
+		desc = strings.ToUpper(desc[:1]) + desc[1:]
+	}
+
+	if info.Version != "" {
+		desc += " " + info.Version
+	}
+
+	if info.JavaVersion > 0 {
+		desc += fmt.Sprintf(" (Java %d)", info.JavaVersion)
+	}
+
+	return desc
+}
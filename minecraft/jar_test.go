@@ -0,0 +1,127 @@
+package minecraft
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// class returns the header of a class file of the given major version.
+func class(major byte) string {
+	return string([]byte{0xca, 0xfe, 0xba, 0xbe, 0x00, 0x00, 0x00, major})
+}
+
+func TestReadJarInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected pickaxx.JarInfo
+	}{
+		{
+			name: "vanilla",
+			files: map[string]string{
+				"META-INF/MANIFEST.MF":             "Manifest-Version: 1.0\r\nMain-Class: net.minecraft.bundler.Main\r\n",
+				"net/minecraft/bundler/Main.class": class(52),
+				"version.json":                     `{"id": "1.20.1", "name": "1.20.1", "protocol_version": 763, "java_version": 17}`,
+			},
+			expected: pickaxx.JarInfo{Flavor: FlavorVanilla, Version: "1.20.1", Protocol: 763, JavaVersion: 17},
+		},
+		{
+			name: "vanilla 1.12",
+			files: map[string]string{
+				"META-INF/MANIFEST.MF":                       "Main-Class: net.minecraft.server.MinecraftServer\n",
+				"net/minecraft/server/MinecraftServer.class": class(52),
+			},
+			expected: pickaxx.JarInfo{Flavor: FlavorVanilla, JavaVersion: 8},
+		},
+		{
+			name: "paper",
+			files: map[string]string{
+				"META-INF/MANIFEST.MF":            "Main-Class: io.papermc.paperclip.Main\n",
+				"io/papermc/paperclip/Main.class": class(52),
+				"META-INF/versions.list":          "abc123\t1.20.1-R0.1-SNAPSHOT\tpaper-1.20.1.jar\ndef456\t1.19.4-R0.1-SNAPSHOT\tpaper-1.19.4.jar\n",
+				"io/papermc/paperclip/Util.class": class(52),
+			},
+			expected: pickaxx.JarInfo{Flavor: FlavorPaper, Version: "1.20.1", JavaVersion: 8},
+		},
+		{
+			name: "spigot",
+			files: map[string]string{
+				"META-INF/MANIFEST.MF":              "Main-Class: org.bukkit.craftbukkit.Main\n",
+				"org/bukkit/craftbukkit/Main.class": class(61),
+				"version.json":                      `{"id": "1.19.4", "protocol_version": 762, "java_version": 17}`,
+			},
+			expected: pickaxx.JarInfo{Flavor: FlavorSpigot, Version: "1.19.4", Protocol: 762, JavaVersion: 17},
+		},
+		{
+			name: "fabric",
+			files: map[string]string{
+				"META-INF/MANIFEST.MF": "Main-Class: net.fabricmc.installer.ServerLauncher\n",
+				"install.properties":   "fabric-loader-version=0.14.21\ngame-version=1.20.1\n",
+			},
+			expected: pickaxx.JarInfo{Flavor: FlavorFabric, Version: "1.20.1"},
+		},
+		{
+			name: "forge",
+			files: map[string]string{
+				"META-INF/MANIFEST.MF": "Main-Class: net.minecraftforge.server.ServerMain\nImplementation-Version: 1.20.1-47.1.0\n",
+			},
+			expected: pickaxx.JarInfo{Flavor: FlavorForge, Version: "1.20.1"},
+		},
+		{
+			name:     "unknown",
+			files:    map[string]string{"META-INF/MANIFEST.MF": "Main-Class: com.example.Server\n"},
+			expected: pickaxx.JarInfo{Flavor: FlavorUnknown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), JarFile)
+			writeJarFiles(t, path, tt.files)
+
+			info, err := readJarInfo(path)
+			require.NoError(t, err)
+
+			tt.expected.Path = path
+			assert.Equal(t, tt.expected, *info)
+		})
+	}
+}
+
+func TestJarInfo(t *testing.T) {
+	dir := t.TempDir()
+	writeJar(t, filepath.Join(dir, JarFile), 61)
+
+	m := New(DefaultPort, gameserver.WithWorkingDir(dir))
+	m.Command = []string{"java", "-jar", JarFile, "nogui"}
+
+	info, err := m.JarInfo()
+	require.NoError(t, err)
+	assert.Equal(t, FlavorVanilla, info.Flavor)
+	assert.Equal(t, 17, info.JavaVersion)
+
+	// read again once the jar changes
+	writeJar(t, filepath.Join(dir, JarFile), 65)
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, JarFile), later, later))
+
+	info, err = m.JarInfo()
+	require.NoError(t, err)
+	assert.Equal(t, 21, info.JavaVersion)
+
+	m = New(DefaultPort, gameserver.WithType(Bedrock), gameserver.WithWorkingDir(dir))
+	_, err = m.JarInfo()
+	assert.Equal(t, ErrNotJar, err)
+}
+
+func TestDescribeJar(t *testing.T) {
+	assert.Equal(t, "Paper 1.20.1 (Java 17)", DescribeJar(&pickaxx.JarInfo{Flavor: FlavorPaper, Version: "1.20.1", JavaVersion: 17}))
+	assert.Equal(t, "Unknown", DescribeJar(&pickaxx.JarInfo{Flavor: FlavorUnknown}))
+}
@@ -0,0 +1,257 @@
+package minecraft
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+)
+
+var _ pickaxx.JavaLister = &server{}
+
+// JavaDirs are searched for Java runtimes, in addition to JAVA_HOME, the PATH
+// and any directories configured (see WithJavaDirs). Each is either a Java
+// home, or a directory of them.
+var JavaDirs = []string{
+	"/usr/lib/jvm",
+	"/usr/lib64/jvm",
+	"/usr/java",
+	"/usr/local/java",
+	"/opt/java",
+	"/opt/jdk",
+}
+
+// javaVersions caches the version of each java executable, as running
+// 'java -version' is slow.
+var javaVersions = struct {
+	sync.Mutex
+	byPath map[string]cachedVersion
+}{byPath: map[string]cachedVersion{}}
+
+type cachedVersion struct {
+	modTime time.Time // of the executable, when its version was read
+	version string
+}
+
+// javaChoice caches the Java runtime chosen to run a server jar, as choosing
+// one reads the jar, and searches for runtimes.
+type javaChoice struct {
+	sync.Mutex
+	key  string // the java executable & jar it was chosen for, and the jar's modification time
+	java string
+}
+
+// WithJava pins the Java runtime running the server: either the path to a
+// java executable or Java home, or a major version (e.g. "17") of a
+// runtime which is found. If not pinned, a runtime able to run the server
+// jar is chosen.
+func WithJava(java string) gameserver.Option {
+	return configure(func(s *server) {
+		s.javaPin = java
+	})
+}
+
+// WithJavaDirs searches the given directories for Java runtimes, before
+// those in JavaDirs.
+func WithJavaDirs(dirs ...string) gameserver.Option {
+	return configure(func(s *server) {
+		s.javaDirs = dirs
+	})
+}
+
+// JavaRuntimes returns the Java runtimes found on this host, newest first.
+func (s *server) JavaRuntimes() []pickaxx.JavaRuntime {
+	return findJava(append(append([]string{}, s.javaDirs...), JavaDirs...))
+}
+
+// findJava returns the Java runtimes in JAVA_HOME, on the PATH and in dirs,
+// newest first.
+func findJava(dirs []string) []pickaxx.JavaRuntime {
+	var candidates []string
+
+	if home := os.Getenv("JAVA_HOME"); home != "" {
+		candidates = append(candidates, filepath.Join(home, "bin", "java"))
+	}
+
+	if path, err := exec.LookPath("java"); err == nil {
+		candidates = append(candidates, path)
+	}
+
+	for _, dir := range dirs {
+		if isJavaHome(dir) {
+			candidates = append(candidates, filepath.Join(dir, "bin", "java"))
+			continue
+		}
+
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, e := range entries {
+			if home := filepath.Join(dir, e.Name()); isJavaHome(home) {
+				candidates = append(candidates, filepath.Join(home, "bin", "java"))
+			}
+		}
+	}
+
+	var (
+		runtimes = []pickaxx.JavaRuntime{}
+		seen     = map[string]bool{}
+	)
+
+	for _, c := range candidates {
+		// e.g. /usr/bin/java -> /usr/lib/jvm/java-17-openjdk-amd64/bin/java
+		path, err := filepath.EvalSymlinks(c)
+		if err != nil || seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		if rt, err := javaRuntime(path); err == nil {
+			runtimes = append(runtimes, rt)
+		}
+	}
+
+	sort.SliceStable(runtimes, func(i, j int) bool {
+		if runtimes[i].Major != runtimes[j].Major {
+			return runtimes[i].Major > runtimes[j].Major
+		}
+		return runtimes[i].Path < runtimes[j].Path
+	})
+
+	return runtimes
+}
+
+// isJavaHome returns true if dir contains a java executable.
+func isJavaHome(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, "bin", "java"))
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
+
+// javaRuntime returns the version of a java executable.
+func javaRuntime(path string) (pickaxx.JavaRuntime, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return pickaxx.JavaRuntime{}, err
+	}
+
+	javaVersions.Lock()
+	cached, ok := javaVersions.byPath[path]
+	javaVersions.Unlock()
+
+	if !ok || !cached.modTime.Equal(info.ModTime()) {
+		v, err := javaVersionString(path)
+		if err != nil {
+			return pickaxx.JavaRuntime{}, err
+		}
+
+		cached = cachedVersion{modTime: info.ModTime(), version: v}
+
+		javaVersions.Lock()
+		javaVersions.byPath[path] = cached
+		javaVersions.Unlock()
+	}
+
+	major, err := parseJavaVersion(cached.version)
+	if err != nil {
+		return pickaxx.JavaRuntime{}, err
+	}
+
+	return pickaxx.JavaRuntime{Path: path, Version: cached.version, Major: major}, nil
+}
+
+// pickJava returns the oldest runtime able to run a jar requiring the given
+// Java version, as servers (and especially mods) may not run on much newer
+// versions of Java than they were built for.
+func pickJava(runtimes []pickaxx.JavaRuntime, required int) (pickaxx.JavaRuntime, bool) {
+	var (
+		best  pickaxx.JavaRuntime
+		found bool
+	)
+
+	for _, rt := range runtimes {
+		if rt.Major >= required && (!found || rt.Major < best.Major) {
+			best, found = rt, true
+		}
+	}
+
+	return best, found
+}
+
+// chooseJava returns the java executable running a server jar, as
+// javaExecutable. The choice is cached until the jar changes, unless fresh
+// (as the server starts, in case runtimes have been installed since).
+func (s *server) chooseJava(java, jar string, fresh bool) (string, error) {
+	key := java + "\x00" + jar
+	if info, err := os.Stat(filepath.Join(s.m.WorkingDir, jar)); err == nil {
+		key += fmt.Sprintf("\x00%d\x00%d", info.ModTime().UnixNano(), info.Size())
+	}
+
+	s.chosenJava.Lock()
+	defer s.chosenJava.Unlock()
+
+	if !fresh && s.chosenJava.key == key {
+		return s.chosenJava.java, nil
+	}
+
+	exe, err := s.javaExecutable(java, jar)
+	if err != nil {
+		return "", err
+	}
+
+	s.chosenJava.key, s.chosenJava.java = key, exe
+	return exe, nil
+}
+
+// javaExecutable returns the java executable running a server jar. This is
+// the pinned runtime, if any, or else one able to run the jar. If none is
+// found, java is returned unchanged.
+func (s *server) javaExecutable(java, jar string) (string, error) {
+	if pin := s.javaPin; pin != "" {
+		if strings.ContainsRune(pin, os.PathSeparator) {
+			if isJavaHome(pin) {
+				return filepath.Join(pin, "bin", "java"), nil
+			}
+			return pin, nil
+		}
+
+		major, err := strconv.Atoi(pin)
+		if err != nil {
+			return "", fmt.Errorf("invalid java '%s': must be a path, or a major version (e.g. 17)", pin)
+		}
+
+		for _, rt := range s.JavaRuntimes() {
+			if rt.Major == major {
+				return rt.Path, nil
+			}
+		}
+
+		return "", fmt.Errorf("java %d not found; install it, or add its directory to javaDirs", major)
+	}
+
+	// only the default runtime is replaced (e.g. not a path chosen by the server type)
+	if java != "java" {
+		return java, nil
+	}
+
+	required, err := jarJavaVersion(filepath.Join(s.m.WorkingDir, jar))
+	if err != nil {
+		return java, nil // the jar preflight check reports a missing jar
+	}
+
+	if rt, ok := pickJava(s.JavaRuntimes(), required); ok {
+		return rt.Path, nil
+	}
+
+	return java, nil
+}
@@ -0,0 +1,123 @@
+package minecraft
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeJavaHome writes a Java home reporting the given version.
+func writeJavaHome(t *testing.T, home, version string) string {
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "bin"), 0755))
+	return writeJava(t, filepath.Join(home, "bin"), version)
+}
+
+func TestFindJava(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		java8  = writeJavaHome(t, filepath.Join(dir, "jvm", "java-8-openjdk"), "1.8.0_292")
+		java17 = writeJavaHome(t, filepath.Join(dir, "jvm", "java-17-openjdk"), "17.0.2")
+		java21 = writeJavaHome(t, filepath.Join(dir, "jdk-21"), "21.0.1")
+	)
+
+	// a link to another runtime is listed once
+	require.NoError(t, os.Symlink(filepath.Join(dir, "jvm", "java-17-openjdk"), filepath.Join(dir, "jvm", "default-java")))
+
+	runtimes := findJava([]string{filepath.Join(dir, "jvm"), filepath.Join(dir, "jdk-21"), filepath.Join(dir, "missing")})
+
+	var found []pickaxx.JavaRuntime
+	for _, rt := range runtimes {
+		if strings.HasPrefix(rt.Path, dir) {
+			found = append(found, rt)
+		}
+	}
+
+	assert.Equal(t, []pickaxx.JavaRuntime{
+		{Path: java21, Version: "21.0.1", Major: 21},
+		{Path: java17, Version: "17.0.2", Major: 17},
+		{Path: java8, Version: "1.8.0_292", Major: 8},
+	}, found)
+}
+
+func TestPickJava(t *testing.T) {
+	runtimes := []pickaxx.JavaRuntime{
+		{Path: "/jvm/21/bin/java", Major: 21},
+		{Path: "/jvm/17/bin/java", Major: 17},
+		{Path: "/jvm/8/bin/java", Major: 8},
+	}
+
+	rt, ok := pickJava(runtimes, 16)
+	assert.True(t, ok)
+	assert.Equal(t, 17, rt.Major, "the oldest compatible runtime")
+
+	rt, ok = pickJava(runtimes, 8)
+	assert.True(t, ok)
+	assert.Equal(t, 8, rt.Major)
+
+	_, ok = pickJava(runtimes, 22)
+	assert.False(t, ok)
+}
+
+func TestCommandLineJava(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		jvm    = filepath.Join(dir, "jvm")
+		java8  = writeJavaHome(t, filepath.Join(jvm, "java-8"), "1.8.0_292")
+		java17 = writeJavaHome(t, filepath.Join(jvm, "java-17"), "17.0.2")
+		java21 = writeJavaHome(t, filepath.Join(jvm, "java-21"), "21.0.1")
+	)
+
+	serverDir := filepath.Join(dir, "server")
+	require.NoError(t, os.MkdirAll(serverDir, 0755))
+	writeJar(t, filepath.Join(serverDir, JarFile), 61) // Java 17
+
+	m := New(DefaultPort, gameserver.WithWorkingDir(serverDir), WithJavaDirs(jvm))
+
+	cmd, err := m.CommandLine()
+	require.NoError(t, err)
+	assert.Equal(t, java17, cmd[0], "chosen for the jar")
+
+	// the choice is not made again, until the jar changes
+	require.NoError(t, os.Chmod(java17, 0644))
+
+	cmd, err = m.CommandLine()
+	require.NoError(t, err)
+	assert.Equal(t, java17, cmd[0], "cached")
+
+	cmd, err = m.Hooks().(*server).Launch(append([]string{}, DefaultCommand...), true)
+	require.NoError(t, err)
+	assert.Equal(t, java21, cmd[0], "chosen again as the server starts")
+
+	require.NoError(t, os.Chmod(java17, 0755))
+
+	// a new jar is checked before the next start
+	writeJar(t, filepath.Join(serverDir, JarFile), 65) // Java 21
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(serverDir, JarFile), later, later))
+
+	cmd, err = m.CommandLine()
+	require.NoError(t, err)
+	assert.Equal(t, java21, cmd[0])
+
+	// pinned by version, or path
+	m = New(DefaultPort, gameserver.WithWorkingDir(serverDir), WithJavaDirs(jvm), WithJava("8"))
+	cmd, err = m.CommandLine()
+	require.NoError(t, err)
+	assert.Equal(t, java8, cmd[0])
+
+	m = New(DefaultPort, gameserver.WithWorkingDir(serverDir), WithJava(filepath.Join(jvm, "java-17")))
+	cmd, err = m.CommandLine()
+	require.NoError(t, err)
+	assert.Equal(t, java17, cmd[0])
+
+	m = New(DefaultPort, gameserver.WithWorkingDir(serverDir), WithJavaDirs(jvm), WithJava("11"))
+	_, err = m.CommandLine()
+	assert.Error(t, err, "java 11 is not installed")
+}
@@ -0,0 +1,305 @@
+package minecraft
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ivan3bx/pickaxx/gameserver"
+)
+
+// GCs are the garbage collectors which may be chosen for a server, by name.
+var GCs = map[string]string{
+	"g1":         "-XX:+UseG1GC",
+	"zgc":        "-XX:+UseZGC",
+	"shenandoah": "-XX:+UseShenandoahGC",
+	"parallel":   "-XX:+UseParallelGC",
+	"serial":     "-XX:+UseSerialGC",
+}
+
+// Profiles are named JVM settings, which a server's own settings build on.
+var Profiles = map[string]JVMConfig{
+	// the settings of DefaultCommand
+	"default": {MinHeap: "512M", MaxHeap: "1024M"},
+
+	// G1 tuned for game servers with large heaps (6 GB or more), as
+	// recommended by Aikar (https://mcflags.emc.gs).
+	"g1": {
+		GC: "g1",
+		Flags: []string{
+			"-XX:+ParallelRefProcEnabled",
+			"-XX:MaxGCPauseMillis=200",
+			"-XX:+UnlockExperimentalVMOptions",
+			"-XX:+DisableExplicitGC",
+			"-XX:+AlwaysPreTouch",
+			"-XX:G1NewSizePercent=40",
+			"-XX:G1MaxNewSizePercent=50",
+			"-XX:G1HeapRegionSize=16M",
+			"-XX:G1ReservePercent=15",
+			"-XX:G1HeapWastePercent=5",
+			"-XX:G1MixedGCCountTarget=4",
+			"-XX:InitiatingHeapOccupancyPercent=20",
+			"-XX:G1MixedGCLiveThresholdPercent=90",
+			"-XX:G1RSetUpdatingPauseTimePercent=5",
+			"-XX:SurvivorRatio=32",
+			"-XX:+PerfDisableSharedMem",
+			"-XX:MaxTenuringThreshold=1",
+		},
+		Properties: map[string]string{
+			"using.aikars.flags": "https://mcflags.emc.gs",
+			"aikars.new.flags":   "true",
+		},
+	},
+
+	// ZGC, for short pauses on hosts with many cores (Java 17 or later).
+	"zgc": {
+		GC:    "zgc",
+		Flags: []string{"-XX:+AlwaysPreTouch", "-XX:+DisableExplicitGC", "-XX:+PerfDisableSharedMem"},
+	},
+}
+
+// procFS is the mount point of the proc filesystem.
+var procFS = "/proc"
+
+// heapSize is a size accepted by -Xms & -Xmx, e.g. "512M" or "8G".
+var heapSize = regexp.MustCompile(`^(\d+)([kKmMgGtT]?)$`)
+
+// JVMConfig configures the JVM running a server launched from a jar (e.g.
+// Java Edition). Settings left empty are taken from the profile, if any.
+type JVMConfig struct {
+	Profile    string            `json:"profile"`    // Name of a profile in Profiles.
+	MinHeap    string            `json:"minHeap"`    // Initial heap size (-Xms), e.g. "2G".
+	MaxHeap    string            `json:"maxHeap"`    // Maximum heap size (-Xmx), e.g. "8G".
+	GC         string            `json:"gc"`         // Name of a garbage collector in GCs.
+	Flags      []string          `json:"flags"`      // Additional -XX flags, after those of the profile.
+	Properties map[string]string `json:"properties"` // System properties (-D), overriding those of the profile.
+	Args       []string          `json:"args"`       // Arguments to the server, after the jar. Defaults to those of the server type.
+}
+
+// WithJVM runs the server's JVM with the given settings, rather than those
+// of its server type's command.
+func WithJVM(c JVMConfig) gameserver.Option {
+	return configure(func(s *server) {
+		s.jvm = &c
+	})
+}
+
+// resolve returns these settings applied to those of the profile.
+func (c JVMConfig) resolve() (JVMConfig, error) {
+	if c.Profile == "" {
+		return c, nil
+	}
+
+	p, ok := Profiles[c.Profile]
+	if !ok {
+		return c, fmt.Errorf("unknown jvm profile '%s'", c.Profile)
+	}
+
+	r := JVMConfig{
+		Profile:    c.Profile,
+		MinHeap:    p.MinHeap,
+		MaxHeap:    p.MaxHeap,
+		GC:         p.GC,
+		Flags:      append(append([]string{}, p.Flags...), c.Flags...),
+		Properties: map[string]string{},
+		Args:       p.Args,
+	}
+
+	for k, v := range p.Properties {
+		r.Properties[k] = v
+	}
+
+	for k, v := range c.Properties {
+		r.Properties[k] = v
+	}
+
+	if c.MinHeap != "" {
+		r.MinHeap = c.MinHeap
+	}
+
+	if c.MaxHeap != "" {
+		r.MaxHeap = c.MaxHeap
+	}
+
+	if c.GC != "" {
+		r.GC = c.GC
+	}
+
+	if len(c.Args) > 0 {
+		r.Args = c.Args
+	}
+
+	return r, nil
+}
+
+// Validate returns an error if these settings are invalid, or the maximum
+// heap is larger than the total memory of this host.
+func (c JVMConfig) Validate() error {
+	r, err := c.resolve()
+	if err != nil {
+		return err
+	}
+
+	var min, max uint64
+
+	if r.MinHeap != "" {
+		if min, err = parseHeapSize(r.MinHeap); err != nil {
+			return fmt.Errorf("invalid jvm minHeap: %w", err)
+		}
+	}
+
+	if r.MaxHeap != "" {
+		if max, err = parseHeapSize(r.MaxHeap); err != nil {
+			return fmt.Errorf("invalid jvm maxHeap: %w", err)
+		}
+	}
+
+	if min > 0 && max > 0 && min > max {
+		return fmt.Errorf("jvm minHeap (%s) is larger than maxHeap (%s)", r.MinHeap, r.MaxHeap)
+	}
+
+	if total, err := memTotal(); err == nil && total > 0 {
+		if max > total {
+			return fmt.Errorf("jvm maxHeap (%s) is larger than the total memory of this host (%s)", r.MaxHeap, formatBytes(total))
+		}
+		if min > total {
+			return fmt.Errorf("jvm minHeap (%s) is larger than the total memory of this host (%s)", r.MinHeap, formatBytes(total))
+		}
+	}
+
+	if _, ok := GCs[r.GC]; !ok && r.GC != "" {
+		return fmt.Errorf("unknown jvm gc '%s'", r.GC)
+	}
+
+	for _, f := range r.Flags {
+		if !strings.HasPrefix(f, "-XX:") {
+			return fmt.Errorf("invalid jvm flag '%s': only -XX flags are allowed", f)
+		}
+	}
+
+	for k := range r.Properties {
+		if k == "" || strings.ContainsAny(k, "= \t") {
+			return fmt.Errorf("invalid jvm property '%s'", k)
+		}
+	}
+
+	return nil
+}
+
+// command returns the command running jar with these settings. Server
+// arguments default to args, if not set.
+func (c JVMConfig) command(java, jar string, args []string) ([]string, error) {
+	r, err := c.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := []string{java}
+
+	if r.MinHeap != "" {
+		cmd = append(cmd, "-Xms"+r.MinHeap)
+	}
+
+	if r.MaxHeap != "" {
+		cmd = append(cmd, "-Xmx"+r.MaxHeap)
+	}
+
+	if r.GC != "" {
+		cmd = append(cmd, GCs[r.GC])
+	}
+
+	cmd = append(cmd, r.Flags...)
+
+	keys := make([]string, 0, len(r.Properties))
+	for k := range r.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		cmd = append(cmd, fmt.Sprintf("-D%s=%s", k, r.Properties[k]))
+	}
+
+	if len(r.Args) > 0 {
+		args = r.Args
+	}
+
+	return append(append(cmd, "-jar", jar), args...), nil
+}
+
+// jvmCommand returns the command running the server type's jar with the
+// configured JVM settings.
+func (s *server) jvmCommand(typeCommand []string) ([]string, error) {
+	i := jarIndex(typeCommand)
+	if i < 0 {
+		return nil, fmt.Errorf("server type '%s' is not launched from a jar; jvm settings do not apply", s.m.Type.Name)
+	}
+
+	if err := s.jvm.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.jvm.command(typeCommand[0], typeCommand[i+1], typeCommand[i+2:])
+}
+
+// parseHeapSize returns a heap size in bytes.
+func parseHeapSize(s string) (uint64, error) {
+	match := heapSize.FindStringSubmatch(s)
+	if match == nil {
+		return 0, fmt.Errorf("'%s' is not a size (e.g. 512M or 4G)", s)
+	}
+
+	n, err := strconv.ParseUint(match[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	switch strings.ToLower(match[2]) {
+	case "k":
+		n <<= 10
+	case "m":
+		n <<= 20
+	case "g":
+		n <<= 30
+	case "t":
+		n <<= 40
+	}
+
+	return n, nil
+}
+
+// memTotal returns the total memory of this host, in bytes.
+func memTotal() (uint64, error) {
+	f, err := os.Open(filepath.Join(procFS, "meminfo"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	// e.g. "MemTotal:       16318028 kB"
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid MemTotal: %w", err)
+		}
+
+		return kb * 1024, nil
+	}
+
+	if err := s.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("MemTotal not found")
+}
@@ -0,0 +1,105 @@
+package minecraft
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMemInfo reports the given total memory (in kB) from /proc/meminfo.
+func fakeMemInfo(t *testing.T, kb string) {
+	orig := procFS
+	procFS = t.TempDir()
+	t.Cleanup(func() { procFS = orig })
+
+	meminfo := "MemTotal:       " + kb + " kB\nMemFree:         1000000 kB\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(procFS, "meminfo"), []byte(meminfo), 0644))
+}
+
+func TestJVMConfigCommand(t *testing.T) {
+	c := JVMConfig{
+		Profile:    "g1",
+		MinHeap:    "8G",
+		MaxHeap:    "8G",
+		Flags:      []string{"-XX:+UseLargePages"},
+		Properties: map[string]string{"aikars.new.flags": "false", "log4j2.formatMsgNoLookups": "true"},
+	}
+
+	cmd, err := c.command("java", "server.jar", []string{"nogui"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"java", "-Xms8G", "-Xmx8G", "-XX:+UseG1GC"}, cmd[:4])
+	assert.Equal(t, "-XX:+UseLargePages", cmd[len(cmd)-7], "flags follow those of the profile")
+	assert.Equal(t, []string{
+		"-Daikars.new.flags=false",
+		"-Dlog4j2.formatMsgNoLookups=true",
+		"-Dusing.aikars.flags=https://mcflags.emc.gs",
+		"-jar", "server.jar", "nogui",
+	}, cmd[len(cmd)-6:])
+
+	c = JVMConfig{MaxHeap: "2G", Args: []string{"--nogui", "--forceUpgrade"}}
+	cmd, err = c.command("/opt/java/bin/java", "forge.jar", []string{"nogui"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/opt/java/bin/java", "-Xmx2G", "-jar", "forge.jar", "--nogui", "--forceUpgrade"}, cmd)
+}
+
+func TestJVMConfigValidate(t *testing.T) {
+	fakeMemInfo(t, "4194304") // 4 GB
+
+	assert.NoError(t, JVMConfig{Profile: "default"}.Validate())
+	assert.NoError(t, JVMConfig{Profile: "zgc", MinHeap: "1g", MaxHeap: "3072m"}.Validate())
+
+	tests := map[string]JVMConfig{
+		"unknown profile":  {Profile: "turbo"},
+		"invalid size":     {MaxHeap: "lots"},
+		"min above max":    {MinHeap: "2G", MaxHeap: "1G"},
+		"above total":      {MaxHeap: "8G"},
+		"unknown gc":       {GC: "cms"},
+		"not an -XX flag":  {Flags: []string{"-jar"}},
+		"invalid property": {Properties: map[string]string{"a=b": "c"}},
+	}
+
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, c.Validate())
+		})
+	}
+}
+
+func TestCommandLineWithJVM(t *testing.T) {
+	fakeMemInfo(t, "16777216") // 16 GB
+
+	m := New(DefaultPort, WithJVM(JVMConfig{Profile: "default", MaxHeap: "4G"}))
+
+	cmd, err := m.CommandLine()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"java", "-Xms512M", "-Xmx4G", "-jar", JarFile, "nogui"}, cmd)
+
+	m = New(DefaultPort, gameserver.WithType(Bedrock), WithJVM(JVMConfig{MaxHeap: "4G"}))
+
+	_, err = m.CommandLine()
+	assert.Error(t, err, "bedrock is not launched from a jar")
+}
+
+func TestParseHeapSize(t *testing.T) {
+	tests := map[string]uint64{
+		"1024": 1024,
+		"512k": 512 << 10,
+		"512M": 512 << 20,
+		"4G":   4 << 30,
+		"1t":   1 << 40,
+	}
+
+	for in, want := range tests {
+		got, err := parseHeapSize(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := parseHeapSize("4GB")
+	assert.Error(t, err)
+}
@@ -0,0 +1,252 @@
+package minecraft
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+)
+
+const (
+	// tickPollInterval is the time between performance commands sent to the server.
+	tickPollInterval = time.Second * 30
+
+	// tickHistorySize is the number of samples kept (1 hour at the default interval).
+	tickHistorySize = 120
+
+	// DefaultTPSThreshold is the TPS below which the server is considered lagging.
+	DefaultTPSThreshold = 15.0
+
+	// DefaultLagWindow is how long TPS must remain below the threshold before alerting.
+	DefaultLagWindow = time.Minute * 2
+
+	// idealTPS is the number of ticks per second of a server that is keeping up.
+	idealTPS = 20.0
+
+	// lagWarningWindow is the minimum time between "Can't keep up!" warnings.
+	lagWarningWindow = time.Second * 15
+)
+
+// TickCommands are commands tried, in order, to report tick performance.
+// The first one the server responds to is used for the rest of the session.
+var TickCommands = []string{"tps", "forge tps"}
+
+var (
+	_ pickaxx.PerformanceReporter = &server{}
+	_ pickaxx.Data                = &tickEvent{}
+)
+
+var (
+	formatCodes = regexp.MustCompile(`§.`)
+
+	// Paper/Spigot: "TPS from last 1m, 5m, 15m: 19.98, 20.0, 20.0"
+	paperTPS = regexp.MustCompile(`TPS from last 1m, 5m, 15m: \*?([\d.]+)`)
+
+	// Forge: "Overall: Mean tick time: 12.345 ms. Mean TPS: 20.000"
+	forgeTPS = regexp.MustCompile(`Overall\s*: Mean tick time: ([\d.]+) ms\. Mean TPS: ([\d.]+)`)
+
+	// Vanilla: "Can't keep up! Is the server overloaded? Running 2034ms or 40 ticks behind"
+	lagWarning = regexp.MustCompile(`Can't keep up!.*Running (\d+)ms or (\d+) ticks behind`)
+)
+
+// tickEvent carries a performance sample to clients.
+type tickEvent struct {
+	Stats pickaxx.TickStats
+}
+
+// MarshalJSON converts this output to valid JSON.
+func (d tickEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]pickaxx.TickStats{"tick": d.Stats})
+}
+
+// parseTickLine returns a performance sample if the line of console output contains one.
+func parseTickLine(line string) (pickaxx.TickStats, bool) {
+	var (
+		stats = pickaxx.TickStats{Time: time.Now()}
+		match []string
+	)
+
+	line = formatCodes.ReplaceAllString(line, "")
+
+	if match = paperTPS.FindStringSubmatch(line); match != nil {
+		stats.TPS, _ = strconv.ParseFloat(match[1], 64)
+		stats.Source = "tps"
+		return stats, true
+	}
+
+	if match = forgeTPS.FindStringSubmatch(line); match != nil {
+		stats.MSPT, _ = strconv.ParseFloat(match[1], 64)
+		stats.TPS, _ = strconv.ParseFloat(match[2], 64)
+		stats.Source = "forge tps"
+		return stats, true
+	}
+
+	if match = lagWarning.FindStringSubmatch(line); match != nil {
+		// estimate from ticks missed since the last possible warning
+		behind, _ := strconv.ParseFloat(match[2], 64)
+		expected := idealTPS * lagWarningWindow.Seconds()
+
+		stats.TPS = idealTPS * (expected - behind) / expected
+		if stats.TPS < 0 {
+			stats.TPS = 0
+		}
+		if stats.TPS > 0 {
+			stats.MSPT = 1000 / stats.TPS
+		}
+		stats.Source = "lag"
+		return stats, true
+	}
+
+	return stats, false
+}
+
+// tickTracker records performance samples, and tracks sustained low TPS.
+// This implementation can be accessed concurrently by multiple goroutines.
+type tickTracker struct {
+	sync.Mutex
+
+	Threshold float64       // Defaults to 'DefaultTPSThreshold' if not set.
+	Window    time.Duration // Defaults to 'DefaultLagWindow' if not set.
+
+	samples  []pickaxx.TickStats
+	polled   time.Time // time of last sample reported by a command
+	lowSince time.Time // time TPS first fell below threshold
+	alerted  bool      // alert sent for the current low period
+}
+
+// Reset clears any state from a previous run. History is retained.
+func (t *tickTracker) Reset() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.polled = time.Time{}
+	t.lowSince = time.Time{}
+	t.alerted = false
+}
+
+// Observe inspects a line of console output, returning any data to send to clients.
+func (t *tickTracker) Observe(line string) []pickaxx.Data {
+	stats, ok := parseTickLine(line)
+	if !ok {
+		return nil
+	}
+
+	out := []pickaxx.Data{tickEvent{stats}}
+
+	if alert := t.Record(stats); alert != nil {
+		out = append(out, *alert)
+	}
+
+	return out
+}
+
+// Record adds a sample, returning an alert if TPS has been below the
+// threshold for longer than the window.
+func (t *tickTracker) Record(s pickaxx.TickStats) *gameserver.Alert {
+	t.Lock()
+	defer t.Unlock()
+
+	t.samples = append(t.samples, s)
+	if len(t.samples) > tickHistorySize {
+		t.samples = t.samples[len(t.samples)-tickHistorySize:]
+	}
+
+	if s.Source != "lag" {
+		t.polled = s.Time
+	}
+
+	threshold, window := t.Threshold, t.Window
+	if threshold == 0 {
+		threshold = DefaultTPSThreshold
+	}
+	if window == 0 {
+		window = DefaultLagWindow
+	}
+
+	if s.TPS >= threshold {
+		t.lowSince = time.Time{}
+		t.alerted = false
+		return nil
+	}
+
+	if t.lowSince.IsZero() {
+		t.lowSince = s.Time
+	}
+
+	if t.alerted || s.Time.Sub(t.lowSince) < window {
+		return nil
+	}
+
+	t.alerted = true
+
+	return &gameserver.Alert{
+		Kind:    "lowTPS",
+		Message: fmt.Sprintf("Server is lagging: %.1f TPS for over %v", s.TPS, window),
+		Value:   s.TPS,
+	}
+}
+
+// LastPolled returns the time of the last sample reported by a command.
+func (t *tickTracker) LastPolled() time.Time {
+	t.Lock()
+	defer t.Unlock()
+	return t.polled
+}
+
+// List returns a copy of all samples, oldest first.
+func (t *tickTracker) List() []pickaxx.TickStats {
+	t.Lock()
+	defer t.Unlock()
+	return append([]pickaxx.TickStats{}, t.samples...)
+}
+
+// Performance returns recent in-game performance samples, oldest first.
+func (s *server) Performance() []pickaxx.TickStats {
+	return s.perf.List()
+}
+
+// pollTicks will periodically submit a command reporting tick performance,
+// once the server has finished loading. Commands are tried in order, and
+// if the server does not respond to any of them, polling stops.
+func pollTicks(ctx context.Context, s *server, commands []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var (
+		idx       = 0
+		lastPoll  time.Time
+		confirmed bool // server has responded to the current command
+	)
+
+	for idx < len(commands) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !s.m.Loaded() {
+			continue
+		}
+
+		if !lastPoll.IsZero() && !confirmed {
+			if s.perf.LastPolled().After(lastPoll) {
+				confirmed = true
+			} else if idx++; idx == len(commands) {
+				return // no response to any command
+			}
+		}
+
+		lastPoll = time.Now()
+
+		if err := s.m.Send(commands[idx]); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package minecraft
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTickLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		ok     bool
+		tps    float64
+		mspt   float64
+		source string
+	}{
+		{
+			name:   "paper",
+			line:   "[12:00:00] [Server thread/INFO]: §6TPS from last 1m, 5m, 15m: §a19.5, §a*20.0, §a20.0",
+			ok:     true,
+			tps:    19.5,
+			source: "tps",
+		},
+		{
+			name:   "forge",
+			line:   "[12:00:00] [Server thread/INFO]: Overall: Mean tick time: 25.000 ms. Mean TPS: 18.500",
+			ok:     true,
+			tps:    18.5,
+			mspt:   25,
+			source: "forge tps",
+		},
+		{
+			name:   "lag warning",
+			line:   "[12:00:00] [Server thread/WARN]: Can't keep up! Is the server overloaded? Running 7500ms or 150 ticks behind",
+			ok:     true,
+			tps:    10,
+			mspt:   100,
+			source: "lag",
+		},
+		{
+			name: "other output",
+			line: "[12:00:00] [Server thread/INFO]: Unknown or incomplete command",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stats, ok := parseTickLine(tc.line)
+			assert.Equal(t, tc.ok, ok)
+			if ok {
+				assert.InDelta(t, tc.tps, stats.TPS, 0.001)
+				assert.InDelta(t, tc.mspt, stats.MSPT, 0.001)
+				assert.Equal(t, tc.source, stats.Source)
+			}
+		})
+	}
+}
+
+func TestTickTrackerAlert(t *testing.T) {
+	var (
+		tr  = tickTracker{Threshold: 15, Window: time.Minute}
+		now = time.Now()
+	)
+
+	sample := func(offset time.Duration, tps float64) *gameserver.Alert {
+		return tr.Record(pickaxx.TickStats{Time: now.Add(offset), TPS: tps, Source: "tps"})
+	}
+
+	assert.Nil(t, sample(0, 12), "below threshold, window not reached")
+	assert.Nil(t, sample(time.Second*30, 10))
+
+	alert := sample(time.Second*61, 11)
+	if assert.NotNil(t, alert, "sustained low TPS") {
+		assert.Equal(t, "lowTPS", alert.Kind)
+		bo, _ := json.Marshal(alert)
+		assert.Contains(t, string(bo), `{"alert":{"kind":"lowTPS"`)
+	}
+
+	assert.Nil(t, sample(time.Second*90, 11), "alerts once per low period")
+	assert.Nil(t, sample(time.Second*120, 20), "recovered")
+	assert.Nil(t, sample(time.Second*130, 5), "new low period")
+	assert.Len(t, tr.List(), 6)
+}
+
+func TestTickTrackerObserve(t *testing.T) {
+	tr := tickTracker{}
+
+	assert.Nil(t, tr.Observe(`[12:00:00] [Server thread/INFO]: Done (3.456s)! For help, type "help"`))
+
+	data := tr.Observe("TPS from last 1m, 5m, 15m: 20.0, 20.0, 20.0")
+	if assert.Len(t, data, 1) {
+		bo, _ := json.Marshal(data[0])
+		assert.Contains(t, string(bo), `"tps":20`)
+	}
+	assert.False(t, tr.LastPolled().IsZero())
+}
@@ -0,0 +1,620 @@
+package minecraft
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"gopkg.in/yaml.v2"
+)
+
+var _ pickaxx.PluginManager = &server{}
+
+const (
+	// pluginsDir holds the plugins of Bukkit servers (e.g. Paper & Spigot).
+	pluginsDir = "plugins"
+
+	// modsDir holds the mods of Fabric & Forge servers.
+	modsDir = "mods"
+
+	// disabledSuffix is added to the name of a disabled plugin, which is
+	// then not loaded by the server.
+	disabledSuffix = ".disabled"
+)
+
+// ErrInvalidPlugin is returned when installing a jar which is not a plugin
+// (or mod).
+var ErrInvalidPlugin = errors.New("not a plugin or mod")
+
+// builtinMods are provided by the server or mod loader, rather than
+// installed as mods.
+var builtinMods = map[string]bool{
+	"minecraft":    true,
+	"java":         true,
+	"fabricloader": true,
+	"forge":        true,
+	"neoforge":     true,
+	"javafml":      true,
+}
+
+// pluginJar is a plugin, as read from its jar.
+type pluginJar struct {
+	pickaxx.Plugin
+	dir      string   // directory it is installed to: pluginsDir or modsDir
+	provides []string // other IDs it may be depended on by
+}
+
+// pluginYAML is the plugin.yml of Bukkit plugins, or the paper-plugin.yml of
+// Paper plugins.
+type pluginYAML struct {
+	Name     string   `yaml:"name"`
+	Version  string   `yaml:"version"`
+	Author   string   `yaml:"author"`
+	Authors  []string `yaml:"authors"`
+	Depend   []string `yaml:"depend"`
+	Provides []string `yaml:"provides"`
+
+	// paper-plugin.yml only; see paperDependencies
+	Dependencies interface{} `yaml:"dependencies"`
+}
+
+// fabricModJSON is the fabric.mod.json of Fabric mods.
+type fabricModJSON struct {
+	ID       string                 `json:"id"`
+	Name     string                 `json:"name"`
+	Version  string                 `json:"version"`
+	Authors  []interface{}          `json:"authors"` // names, or {"name": ...}
+	Depends  map[string]interface{} `json:"depends"` // version ranges, by mod ID
+	Provides []string               `json:"provides"`
+}
+
+// Plugins returns the plugins (and mods) installed, with warnings for any
+// missing dependencies.
+func (s *server) Plugins() (*pickaxx.PluginList, error) {
+	s.pluginLock.Lock()
+	defer s.pluginLock.Unlock()
+
+	return s.listPlugins()
+}
+
+// InstallPlugin installs the jar at path as name, in the plugins or mods
+// directory (by the metadata of the jar). Installed versions of the same
+// plugin are replaced.
+func (s *server) InstallPlugin(path, name string) (*pickaxx.PluginChange, error) {
+	s.pluginLock.Lock()
+	defer s.pluginLock.Unlock()
+
+	if filepath.Base(name) != name || strings.HasPrefix(name, ".") || !strings.HasSuffix(strings.ToLower(name), ".jar") {
+		return nil, fmt.Errorf("%w: '%s' is not a jar", ErrInvalidPlugin, name)
+	}
+
+	jar, err := readPluginJar(path)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPlugin) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPlugin, err)
+	}
+
+	list, err := s.listPlugins()
+	if err != nil {
+		return nil, err
+	}
+
+	file := jar.dir + "/" + name
+
+	if err := s.replacePluginFile(path, file); err != nil {
+		return nil, err
+	}
+
+	// other versions of the plugin, and a disabled copy of this one
+	for _, p := range list.Plugins {
+		var err error
+
+		switch {
+		case p.ID == jar.ID && pluginDir(p.File) == jar.dir && p.File != file:
+			err = s.removePluginFile(p.File)
+		case p.File == file && !p.Enabled:
+			err = os.Remove(s.pluginPath(file) + disabledSuffix)
+		}
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("installed '%s', but unable to remove '%s': %w", file, p.File, err)
+		}
+	}
+
+	return s.pluginChanged(file, true)
+}
+
+// replacePluginFile copies the jar at path to file (within the working
+// directory). It is copied alongside first, then renamed, so that an
+// installed plugin is only replaced once the copy is complete.
+func (s *server) replacePluginFile(path, file string) error {
+	dst := s.pluginPath(file)
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), ".install-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp.Chmod(0644) // as other plugins, rather than private to pickaxx
+	tmp.Close()
+
+	if err := copyFile(path, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// EnablePlugin enables (or disables) a plugin, by moving it aside.
+func (s *server) EnablePlugin(file string, enabled bool) (*pickaxx.PluginChange, error) {
+	s.pluginLock.Lock()
+	defer s.pluginLock.Unlock()
+
+	if !validPluginFile(file) {
+		return nil, pickaxx.ErrPluginNotFound
+	}
+
+	from, to := s.pluginPath(file)+disabledSuffix, s.pluginPath(file)
+	if !enabled {
+		from, to = to, from
+	}
+
+	if _, err := os.Stat(from); errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(to); err == nil {
+			return s.pluginChanged(file, false) // already done
+		}
+		return nil, pickaxx.ErrPluginNotFound
+	}
+
+	if err := os.Rename(from, to); err != nil {
+		return nil, err
+	}
+
+	return s.pluginChanged(file, true)
+}
+
+// RemovePlugin deletes a plugin, whether enabled or not.
+func (s *server) RemovePlugin(file string) (*pickaxx.PluginChange, error) {
+	s.pluginLock.Lock()
+	defer s.pluginLock.Unlock()
+
+	if !validPluginFile(file) {
+		return nil, pickaxx.ErrPluginNotFound
+	}
+
+	_, err1 := os.Stat(s.pluginPath(file))
+	_, err2 := os.Stat(s.pluginPath(file) + disabledSuffix)
+
+	if err1 != nil && err2 != nil {
+		return nil, pickaxx.ErrPluginNotFound
+	}
+
+	if err := s.removePluginFile(file); err != nil {
+		return nil, err
+	}
+
+	return s.pluginChanged(file, true)
+}
+
+// listPlugins reads the plugins installed, in the plugins & mods directories.
+func (s *server) listPlugins() (*pickaxx.PluginList, error) {
+	var (
+		list     = &pickaxx.PluginList{Plugins: []pickaxx.Plugin{}, Warnings: []string{}}
+		modified time.Time // of the latest plugin
+	)
+
+	for _, dir := range []string{pluginsDir, modsDir} {
+		entries, err := ioutil.ReadDir(filepath.Join(s.m.WorkingDir, dir))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var jars []pluginJar
+
+		for _, e := range entries {
+			name, enabled := e.Name(), true
+			if strings.HasSuffix(name, disabledSuffix) {
+				name, enabled = strings.TrimSuffix(name, disabledSuffix), false
+			}
+
+			if e.IsDir() || !strings.HasSuffix(strings.ToLower(name), ".jar") {
+				continue
+			}
+
+			if e.ModTime().After(modified) {
+				modified = e.ModTime()
+			}
+
+			jar, err := readPluginJar(filepath.Join(s.m.WorkingDir, dir, e.Name()))
+			if err != nil {
+				jar = pluginJar{Plugin: pickaxx.Plugin{ID: name, Name: name, Error: err.Error()}}
+			}
+
+			jar.File, jar.Enabled = dir+"/"+name, enabled
+			jars = append(jars, jar)
+		}
+
+		plugins, warnings := resolveDependencies(jars)
+		list.Plugins = append(list.Plugins, plugins...)
+		list.Warnings = append(list.Warnings, warnings...)
+	}
+
+	// changes take effect when the server next starts
+	if s.m.Running() {
+		startedAt := s.m.StartedAt()
+
+		s.lock.RLock()
+		list.RestartRequired = s.pluginsChanged.After(startedAt) || modified.After(startedAt)
+		s.lock.RUnlock()
+	}
+
+	return list, nil
+}
+
+// pluginChanged returns a change to a plugin, recording the time of the
+// change (if changed) so that the server is known to need a restart.
+func (s *server) pluginChanged(file string, changed bool) (*pickaxx.PluginChange, error) {
+	if changed {
+		s.lock.Lock()
+		s.pluginsChanged = time.Now()
+		s.lock.Unlock()
+	}
+
+	list, err := s.listPlugins()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pickaxx.PluginChange{Warnings: list.Warnings, RestartRequired: list.RestartRequired}
+
+	for i, p := range list.Plugins {
+		if p.File == file {
+			res.Plugin = &list.Plugins[i]
+		}
+	}
+
+	return res, nil
+}
+
+// resolveDependencies returns the plugins of a directory with missing
+// dependencies, and warnings for those of enabled plugins.
+func resolveDependencies(jars []pluginJar) ([]pickaxx.Plugin, []string) {
+	var (
+		plugins  = make([]pickaxx.Plugin, 0, len(jars))
+		warnings []string
+		enabled  = map[string][]string{} // files, by ID
+		disabled = map[string]bool{}
+	)
+
+	for _, j := range jars {
+		for _, id := range append([]string{j.ID}, j.provides...) {
+			if j.Enabled {
+				enabled[id] = append(enabled[id], j.File)
+			} else {
+				disabled[id] = true
+			}
+		}
+	}
+
+	for _, j := range jars {
+		p := j.Plugin
+
+		for _, dep := range p.Dependencies {
+			if len(enabled[dep]) > 0 {
+				continue
+			}
+
+			p.Missing = append(p.Missing, dep)
+
+			if !p.Enabled {
+				continue
+			}
+
+			if disabled[dep] {
+				warnings = append(warnings, fmt.Sprintf("%s requires %s, which is disabled", p.Name, dep))
+			} else {
+				warnings = append(warnings, fmt.Sprintf("%s requires %s, which is not installed", p.Name, dep))
+			}
+		}
+
+		if files := enabled[p.ID]; p.Enabled && p.Error == "" && len(files) > 1 && files[0] == p.File {
+			warnings = append(warnings, fmt.Sprintf("%s is installed more than once (%s)", p.Name, strings.Join(files, ", ")))
+		}
+
+		plugins = append(plugins, p)
+	}
+
+	return plugins, warnings
+}
+
+// readPluginJar reads the metadata of a plugin (or mod) jar.
+func readPluginJar(path string) (pluginJar, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return pluginJar{}, err
+	}
+	defer r.Close()
+
+	files := map[string]*zip.File{}
+	for _, f := range r.File {
+		files[f.Name] = f
+	}
+
+	// paper-plugin.yml takes precedence, as it does for Paper
+	for _, name := range []string{"paper-plugin.yml", "plugin.yml"} {
+		if f, ok := files[name]; ok {
+			return readPluginYAML(f)
+		}
+	}
+
+	if f, ok := files["fabric.mod.json"]; ok {
+		return readFabricMod(f)
+	}
+
+	for _, name := range []string{"META-INF/mods.toml", "META-INF/neoforge.mods.toml"} {
+		if f, ok := files[name]; ok {
+			return readForgeMod(f, files)
+		}
+	}
+
+	return pluginJar{}, fmt.Errorf("%w: no plugin.yml, paper-plugin.yml, fabric.mod.json or mods.toml found", ErrInvalidPlugin)
+}
+
+func readPluginYAML(f *zip.File) (pluginJar, error) {
+	b, err := readZipFile(f)
+	if err != nil {
+		return pluginJar{}, err
+	}
+
+	var y pluginYAML
+	if err := yaml.Unmarshal(b, &y); err != nil {
+		return pluginJar{}, fmt.Errorf("invalid %s: %w", f.Name, err)
+	}
+
+	if y.Name == "" {
+		return pluginJar{}, fmt.Errorf("invalid %s: no name", f.Name)
+	}
+
+	authors := y.Authors
+	if y.Author != "" {
+		authors = append([]string{y.Author}, authors...)
+	}
+
+	return pluginJar{
+		Plugin: pickaxx.Plugin{
+			ID:           y.Name,
+			Name:         y.Name,
+			Version:      y.Version,
+			Authors:      authors,
+			Dependencies: append(y.Depend, paperDependencies(y.Dependencies)...),
+		},
+		dir:      pluginsDir,
+		provides: y.Provides,
+	}, nil
+}
+
+// paperDependencies returns the required dependencies of a Paper plugin,
+// either of the form:
+//
+//	dependencies:
+//	  server:
+//	    Essentials:
+//	      required: true
+//
+// or, in early versions of paper-plugin.yml:
+//
+//	dependencies:
+//	  - name: Essentials
+//	    required: true
+func paperDependencies(v interface{}) []string {
+	var deps []string
+
+	required := func(dep interface{}) bool {
+		m, ok := dep.(map[interface{}]interface{})
+		if !ok {
+			return true
+		}
+		r, ok := m["required"].(bool)
+		return !ok || r // required by default
+	}
+
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		server, _ := v["server"].(map[interface{}]interface{})
+		for name, dep := range server {
+			if required(dep) {
+				deps = append(deps, fmt.Sprint(name))
+			}
+		}
+		sort.Strings(deps)
+	case []interface{}:
+		for _, dep := range v {
+			if m, ok := dep.(map[interface{}]interface{}); ok && m["name"] != nil && required(dep) {
+				deps = append(deps, fmt.Sprint(m["name"]))
+			}
+		}
+	}
+
+	return deps
+}
+
+func readFabricMod(f *zip.File) (pluginJar, error) {
+	var mod fabricModJSON
+	if err := readJSON(f, &mod); err != nil {
+		return pluginJar{}, fmt.Errorf("invalid %s: %w", f.Name, err)
+	}
+
+	if mod.ID == "" {
+		return pluginJar{}, fmt.Errorf("invalid %s: no id", f.Name)
+	}
+
+	p := pickaxx.Plugin{ID: mod.ID, Name: mod.Name, Version: mod.Version}
+	if p.Name == "" {
+		p.Name = mod.ID
+	}
+
+	for _, a := range mod.Authors {
+		switch a := a.(type) {
+		case string:
+			p.Authors = append(p.Authors, a)
+		case map[string]interface{}:
+			if name, ok := a["name"].(string); ok {
+				p.Authors = append(p.Authors, name)
+			}
+		}
+	}
+
+	for id := range mod.Depends {
+		if !builtinMods[id] {
+			p.Dependencies = append(p.Dependencies, id)
+		}
+	}
+	sort.Strings(p.Dependencies)
+
+	return pluginJar{Plugin: p, dir: modsDir, provides: mod.Provides}, nil
+}
+
+// readForgeMod reads the mods.toml of Forge (or NeoForge) mods. A jar may
+// contain several mods; the first is reported, and the rest may be depended
+// on as it is.
+func readForgeMod(f *zip.File, files map[string]*zip.File) (pluginJar, error) {
+	b, err := readZipFile(f)
+	if err != nil {
+		return pluginJar{}, err
+	}
+
+	tables, err := parseTOML(string(b))
+	if err != nil {
+		return pluginJar{}, fmt.Errorf("invalid %s: %w", f.Name, err)
+	}
+
+	var (
+		jar  = pluginJar{dir: modsDir}
+		ids  = map[string]bool{}
+		seen = map[string]bool{}
+	)
+
+	str := func(t tomlTable, key string) string {
+		s, _ := t.Values[key].(string)
+		return s
+	}
+
+	for _, t := range tables {
+		if t.Name != "mods" || str(t, "modId") == "" {
+			continue
+		}
+
+		ids[str(t, "modId")] = true
+
+		if jar.ID != "" {
+			jar.provides = append(jar.provides, str(t, "modId"))
+			continue
+		}
+
+		jar.ID, jar.Name, jar.Version = str(t, "modId"), str(t, "displayName"), str(t, "version")
+
+		switch a := t.Values["authors"].(type) {
+		case string:
+			jar.Authors = []string{a}
+		case []string:
+			jar.Authors = a
+		}
+	}
+
+	if jar.ID == "" {
+		return pluginJar{}, fmt.Errorf("invalid %s: no mods", f.Name)
+	}
+
+	if jar.Name == "" {
+		jar.Name = jar.ID
+	}
+
+	// e.g. "${file.jarVersion}", replaced by the version in the manifest
+	if strings.HasPrefix(jar.Version, "${") {
+		jar.Version = ""
+		if manifest, err := jarManifest(files); err == nil {
+			jar.Version = manifest["Implementation-Version"]
+		}
+	}
+
+	for _, t := range tables {
+		if !strings.HasPrefix(t.Name, "dependencies.") {
+			continue
+		}
+
+		// Forge: mandatory = true; NeoForge: type = "required"
+		mandatory, _ := t.Values["mandatory"].(bool)
+		if !mandatory && !strings.EqualFold(str(t, "type"), "required") {
+			continue
+		}
+
+		if id := str(t, "modId"); id != "" && !builtinMods[id] && !ids[id] && !seen[id] {
+			jar.Dependencies = append(jar.Dependencies, id)
+			seen[id] = true
+		}
+	}
+
+	return jar, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+// validPluginFile returns true if file names a jar in the plugins or mods
+// directory, e.g. "plugins/EssentialsX.jar".
+func validPluginFile(file string) bool {
+	parts := strings.Split(file, "/")
+	if len(parts) != 2 || (parts[0] != pluginsDir && parts[0] != modsDir) {
+		return false
+	}
+
+	name := parts[1]
+	return !strings.HasPrefix(name, ".") && !strings.ContainsRune(name, '\\') && strings.HasSuffix(strings.ToLower(name), ".jar")
+}
+
+// pluginDir returns the directory of a plugin file, e.g. "plugins".
+func pluginDir(file string) string {
+	return strings.SplitN(file, "/", 2)[0]
+}
+
+// pluginPath returns the path of an (enabled) plugin file.
+func (s *server) pluginPath(file string) string {
+	return filepath.Join(s.m.WorkingDir, filepath.FromSlash(file))
+}
+
+// removePluginFile deletes a plugin, whether enabled or not.
+func (s *server) removePluginFile(file string) error {
+	for _, path := range []string{s.pluginPath(file), s.pluginPath(file) + disabledSuffix} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package minecraft
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const modsTOML = `
+modLoader="javafml" #mandatory
+loaderVersion="[47,)"
+license="MIT"
+
+[[mods]]
+modId="examplemod"
+version="${file.jarVersion}"
+displayName="Example Mod" # shown in the mod list
+authors="Steve, Alex"
+description='''
+An example mod.
+'''
+
+[[dependencies.examplemod]]
+    modId="forge"
+    mandatory=true
+    versionRange="[47,)"
+
+[[dependencies.examplemod]]
+    modId="jei"
+    mandatory=false
+
+[[dependencies.examplemod]]
+    modId="geckolib"
+    mandatory=true
+    versionRange="[4.2,)"
+    ordering="AFTER"
+    side="BOTH"
+`
+
+func TestReadPluginJar(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		dir      string
+		expected pickaxx.Plugin
+	}{
+		{
+			name: "bukkit",
+			files: map[string]string{
+				"plugin.yml": "name: EssentialsChat\nversion: 2.20\nauthor: kenzie\nauthors: [md_5]\ndepend: [Essentials]\nsoftdepend: [Vault]\n",
+			},
+			dir: pluginsDir,
+			expected: pickaxx.Plugin{
+				ID: "EssentialsChat", Name: "EssentialsChat", Version: "2.20",
+				Authors: []string{"kenzie", "md_5"}, Dependencies: []string{"Essentials"},
+			},
+		},
+		{
+			name: "paper",
+			files: map[string]string{
+				"plugin.yml":       "name: Legacy\nversion: 1\n",
+				"paper-plugin.yml": "name: Chat\nversion: '1.0'\nauthors: [Alex]\ndependencies:\n  server:\n    LuckPerms:\n      load: BEFORE\n    Vault:\n      required: false\n    Essentials:\n      required: true\n",
+			},
+			dir: pluginsDir,
+			expected: pickaxx.Plugin{
+				ID: "Chat", Name: "Chat", Version: "1.0",
+				Authors: []string{"Alex"}, Dependencies: []string{"Essentials", "LuckPerms"},
+			},
+		},
+		{
+			name: "fabric",
+			files: map[string]string{
+				"fabric.mod.json": `{"id": "sodium", "name": "Sodium", "version": "0.5.3", "authors": ["jellysquid3", {"name": "IMS"}],
+					"depends": {"fabricloader": ">=0.12", "minecraft": "1.20.1", "fabric-api": "*"}}`,
+			},
+			dir: modsDir,
+			expected: pickaxx.Plugin{
+				ID: "sodium", Name: "Sodium", Version: "0.5.3",
+				Authors: []string{"jellysquid3", "IMS"}, Dependencies: []string{"fabric-api"},
+			},
+		},
+		{
+			name: "forge",
+			files: map[string]string{
+				"META-INF/MANIFEST.MF": "Implementation-Version: 1.2.3\n",
+				"META-INF/mods.toml":   modsTOML,
+			},
+			dir: modsDir,
+			expected: pickaxx.Plugin{
+				ID: "examplemod", Name: "Example Mod", Version: "1.2.3",
+				Authors: []string{"Steve, Alex"}, Dependencies: []string{"geckolib"},
+			},
+		},
+		{
+			name: "neoforge",
+			files: map[string]string{
+				"META-INF/neoforge.mods.toml": "[[mods]]\nmodId=\"jei\"\nversion=\"15.2\"\n[[dependencies.jei]]\nmodId=\"neoforge\"\ntype=\"required\"\n[[dependencies.jei]]\nmodId=\"architectury\"\ntype=\"required\"\n",
+			},
+			dir: modsDir,
+			expected: pickaxx.Plugin{
+				ID: "jei", Name: "jei", Version: "15.2", Dependencies: []string{"architectury"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "plugin.jar")
+			writeJarFiles(t, path, tt.files)
+
+			jar, err := readPluginJar(path)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.dir, jar.dir)
+			assert.Equal(t, tt.expected, jar.Plugin)
+		})
+	}
+
+	t.Run("not a plugin", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "server.jar")
+		writeJarFiles(t, path, map[string]string{"META-INF/MANIFEST.MF": "Main-Class: Main\n"})
+
+		_, err := readPluginJar(path)
+		assert.True(t, errors.Is(err, ErrInvalidPlugin))
+	})
+}
+
+func TestPluginManagement(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		staged = t.TempDir()
+		m      = New(DefaultPort, gameserver.WithType(gameserver.ServerType{
+			Name:    "echo",
+			Command: []string{"cat"},
+			Probe:   gameserver.ProbeNone,
+			Hooks:   Edition{}.Hooks,
+		}), gameserver.WithWorkingDir(dir))
+	)
+
+	stage := func(name, pluginYML string) string {
+		path := filepath.Join(staged, name)
+		writeJarFiles(t, path, map[string]string{"plugin.yml": pluginYML})
+		return path
+	}
+
+	list, err := m.Plugins()
+	require.NoError(t, err)
+	assert.Empty(t, list.Plugins)
+
+	// dependencies are reported missing, until installed
+	res, err := m.InstallPlugin(stage("chat.jar", "name: EssentialsChat\ndepend: [Essentials]\n"), "EssentialsChat-2.20.jar")
+	require.NoError(t, err)
+	require.NotNil(t, res.Plugin)
+	assert.Equal(t, "plugins/EssentialsChat-2.20.jar", res.Plugin.File)
+	assert.Equal(t, []string{"Essentials"}, res.Plugin.Missing)
+	assert.Equal(t, []string{"EssentialsChat requires Essentials, which is not installed"}, res.Warnings)
+	assert.False(t, res.RestartRequired, "server is not running")
+
+	res, err = m.InstallPlugin(stage("core.jar", "name: Essentials\nversion: 2.20\n"), "Essentials-2.20.jar")
+	require.NoError(t, err)
+	assert.Empty(t, res.Warnings)
+
+	// disabled plugins are moved aside
+	res, err = m.EnablePlugin("plugins/Essentials-2.20.jar", false)
+	require.NoError(t, err)
+	assert.False(t, res.Plugin.Enabled)
+	assert.FileExists(t, filepath.Join(dir, "plugins", "Essentials-2.20.jar.disabled"))
+	assert.Equal(t, []string{"EssentialsChat requires Essentials, which is disabled"}, res.Warnings)
+
+	res, err = m.EnablePlugin("plugins/Essentials-2.20.jar", true)
+	require.NoError(t, err)
+	assert.True(t, res.Plugin.Enabled)
+	assert.Empty(t, res.Warnings)
+
+	// new versions replace the old
+	_, err = m.InstallPlugin(stage("core.jar", "name: Essentials\nversion: 2.21\n"), "Essentials-2.21.jar")
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "plugins", "Essentials-2.20.jar"))
+
+	list, err = m.Plugins()
+	require.NoError(t, err)
+	require.Len(t, list.Plugins, 2)
+	assert.Equal(t, "2.21", list.Plugins[0].Version)
+
+	// reinstalling a disabled plugin replaces it, enabled
+	_, err = m.EnablePlugin("plugins/Essentials-2.21.jar", false)
+	require.NoError(t, err)
+
+	res, err = m.InstallPlugin(stage("core.jar", "name: Essentials\nversion: 2.21\n"), "Essentials-2.21.jar")
+	require.NoError(t, err)
+	assert.True(t, res.Plugin.Enabled)
+	assert.NoFileExists(t, filepath.Join(dir, "plugins", "Essentials-2.21.jar.disabled"))
+
+	info, err := os.Stat(filepath.Join(dir, "plugins", "Essentials-2.21.jar"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "plugins"))
+	require.NoError(t, err)
+	assert.Len(t, files, 2, "no temporary files are left behind")
+
+	res, err = m.RemovePlugin("plugins/EssentialsChat-2.20.jar")
+	require.NoError(t, err)
+	assert.Nil(t, res.Plugin)
+	assert.NoFileExists(t, filepath.Join(dir, "plugins", "EssentialsChat-2.20.jar"))
+
+	_, err = m.RemovePlugin("plugins/EssentialsChat-2.20.jar")
+	assert.Equal(t, pickaxx.ErrPluginNotFound, err)
+
+	_, err = m.EnablePlugin("../server.jar", false)
+	assert.Equal(t, pickaxx.ErrPluginNotFound, err)
+
+	_, err = m.InstallPlugin(stage("other.jar", "name: Other\n"), "../other.jar")
+	assert.True(t, errors.Is(err, ErrInvalidPlugin))
+
+	// unreadable jars are listed, with the error
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugins", "broken.jar"), []byte("not a zip"), 0644))
+
+	list, err = m.Plugins()
+	require.NoError(t, err)
+	require.Len(t, list.Plugins, 2)
+	assert.Equal(t, "plugins/broken.jar", list.Plugins[1].File)
+	assert.NotEmpty(t, list.Plugins[1].Error)
+
+	// changes since the server started require a restart
+	past := time.Now().Add(-time.Hour)
+	for _, name := range []string{"Essentials-2.21.jar", "broken.jar"} {
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "plugins", name), past, past))
+	}
+
+	isRunning := m.Notifier().Register(gameserver.Running)
+	defer m.Notifier().Unregister(isRunning)
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+	<-isRunning
+
+	done := drain(activity)
+	defer func() {
+		m.Stop(pickaxx.WithKillTimeout(time.Millisecond * 50))
+		<-done
+	}()
+
+	list, err = m.Plugins()
+	require.NoError(t, err)
+	assert.False(t, list.RestartRequired)
+
+	res, err = m.EnablePlugin("plugins/Essentials-2.21.jar", false)
+	require.NoError(t, err)
+	assert.True(t, res.RestartRequired)
+}
@@ -0,0 +1,175 @@
+package minecraft
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+)
+
+const (
+	// EULAFile records acceptance of the Minecraft EULA, within the working directory.
+	EULAFile = "eula.txt"
+
+	// javaVersionTimeout is how long 'java -version' may take.
+	javaVersionTimeout = time.Second * 10
+)
+
+// Names of preflight checks, in addition to those of gameserver.Manager
+const (
+	CheckJar         = "jar"
+	CheckJavaVersion = "javaVersion"
+	CheckEULA        = "eula"
+)
+
+// e.g. `openjdk version "17.0.2" 2022-01-18` or `java version "1.8.0_292"`
+var javaVersionOutput = regexp.MustCompile(`version "([^"]+)"`)
+
+func passed(name, message string) pickaxx.Check {
+	return pickaxx.Check{Name: name, Passed: true, Message: message}
+}
+
+func failed(name, message, fix string) pickaxx.Check {
+	return pickaxx.Check{Name: name, Message: message, Fix: fix}
+}
+
+// jarArg returns the jar run by a command, if any (e.g. "java -jar server.jar").
+func jarArg(command []string) string {
+	if i := jarIndex(command); i >= 0 {
+		return command[i+1]
+	}
+	return ""
+}
+
+// jarIndex returns the index of "-jar" in a command, followed by the jar, or -1.
+func jarIndex(command []string) int {
+	for i, arg := range command {
+		if arg == "-jar" && i+1 < len(command) {
+			return i
+		}
+	}
+	return -1
+}
+
+func checkJar(path string) pickaxx.Check {
+	if _, err := os.Stat(path); err != nil {
+		return failed(CheckJar, fmt.Sprintf("server jar '%s' not found", path),
+			fmt.Sprintf("Upload a server jar, or copy one to '%s'.", path))
+	}
+
+	if info, err := readJarInfo(path); err == nil {
+		return passed(CheckJar, fmt.Sprintf("found %s server jar '%s'", DescribeJar(info), path))
+	}
+
+	return passed(CheckJar, fmt.Sprintf("found server jar '%s'", path))
+}
+
+func checkJavaVersion(java, jar string) pickaxx.Check {
+	version, err := javaVersion(java)
+	if err != nil {
+		return failed(CheckJavaVersion, fmt.Sprintf("unable to determine the version of '%s': %v", java, err),
+			"Make sure the Java runtime is installed correctly.")
+	}
+
+	required, err := jarJavaVersion(jar)
+	if err != nil {
+		// the jar check reports a missing jar
+		return passed(CheckJavaVersion, fmt.Sprintf("found Java %d", version))
+	}
+
+	if version < required {
+		return failed(CheckJavaVersion,
+			fmt.Sprintf("server requires Java %d, but found Java %d", required, version),
+			fmt.Sprintf("Install Java %d or later, or pin a compatible runtime with 'java' in the server configuration.", required))
+	}
+
+	return passed(CheckJavaVersion, fmt.Sprintf("found Java %d; server requires Java %d", version, required))
+}
+
+// javaVersion runs 'java -version', returning the major version.
+func javaVersion(java string) (int, error) {
+	v, err := javaVersionString(java)
+	if err != nil {
+		return 0, err
+	}
+	return parseJavaVersion(v)
+}
+
+// javaVersionString runs 'java -version', returning the version reported
+// (e.g. "17.0.2").
+func javaVersionString(java string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), javaVersionTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, java, "-version").CombinedOutput()
+	if err != nil {
+		return "", err
+	}
+
+	match := javaVersionOutput.FindSubmatch(out)
+	if match == nil {
+		return "", errors.New("version not found in output")
+	}
+
+	return string(match[1]), nil
+}
+
+// parseJavaVersion returns the major version of a Java version string,
+// e.g. 8 for "1.8.0_292", or 17 for "17.0.2".
+func parseJavaVersion(v string) (int, error) {
+	parts := strings.FieldsFunc(v, func(r rune) bool { return r == '.' || r == '_' || r == '-' || r == '+' })
+
+	if len(parts) > 1 && parts[0] == "1" {
+		parts = parts[1:]
+	}
+
+	if len(parts) == 0 {
+		return 0, fmt.Errorf("invalid version '%s'", v)
+	}
+
+	return strconv.Atoi(parts[0])
+}
+
+func checkEULA(path string) pickaxx.Check {
+	fix := fmt.Sprintf("Read the Minecraft EULA (https://aka.ms/MinecraftEULA), then set 'eula=true' in '%s'.", path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return failed(CheckEULA, "EULA has not been accepted", fix)
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if strings.EqualFold(strings.ReplaceAll(s.Text(), " ", ""), "eula=true") {
+			return passed(CheckEULA, "EULA accepted")
+		}
+	}
+
+	return failed(CheckEULA, "EULA has not been accepted", fix)
+}
+
+// formatBytes returns a size in human-readable form, e.g. "1.5 GB".
+func formatBytes(b uint64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,135 @@
+package minecraft
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeJar writes a jar whose main class has the given class file major version.
+func writeJar(t *testing.T, path string, major uint16) {
+	writeJarFiles(t, path, map[string]string{
+		"META-INF/MANIFEST.MF":             "Manifest-Version: 1.0\r\nMain-Class: net.minecraft.bundler.Main\r\n",
+		"net/minecraft/bundler/Main.class": class(byte(major)),
+	})
+}
+
+// writeJarFiles writes a jar containing the given files, by name.
+func writeJarFiles(t *testing.T, path string, files map[string]string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+
+	for name, content := range files {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		fw.Write([]byte(content))
+	}
+
+	require.NoError(t, w.Close())
+}
+
+// writeJava writes a script reporting the given java version.
+func writeJava(t *testing.T, dir, version string) string {
+	path := filepath.Join(dir, "java")
+	script := fmt.Sprintf("#!/bin/sh\necho 'openjdk version \"%s\" 2022-01-18' >&2\n", version)
+	require.NoError(t, ioutil.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func TestParseJavaVersion(t *testing.T) {
+	tests := map[string]int{
+		"1.8.0_292": 8,
+		"11.0.2":    11,
+		"17.0.2":    17,
+		"21":        21,
+		"9-ea":      9,
+		"17+35":     17,
+	}
+
+	for v, expected := range tests {
+		actual, err := parseJavaVersion(v)
+		assert.NoError(t, err, v)
+		assert.Equal(t, expected, actual, v)
+	}
+
+	_, err := parseJavaVersion("")
+	assert.Error(t, err)
+}
+
+func TestJarJavaVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), JarFile)
+	writeJar(t, path, 61)
+
+	v, err := jarJavaVersion(path)
+	require.NoError(t, err)
+	assert.Equal(t, 17, v)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("not a jar"), 0644))
+	_, err = jarJavaVersion(path)
+	assert.Error(t, err)
+}
+
+func TestCheckJavaVersion(t *testing.T) {
+	dir := t.TempDir()
+	jar := filepath.Join(dir, JarFile)
+	writeJar(t, jar, 61)
+
+	check := checkJavaVersion(writeJava(t, dir, "11.0.2"), jar)
+	assert.False(t, check.Passed)
+	assert.Equal(t, "server requires Java 17, but found Java 11", check.Message)
+	assert.Contains(t, check.Fix, "Install Java 17")
+
+	check = checkJavaVersion(writeJava(t, dir, "17.0.2"), jar)
+	assert.True(t, check.Passed, check.Message)
+}
+
+func TestCheckEULA(t *testing.T) {
+	path := filepath.Join(t.TempDir(), EULAFile)
+
+	assert.False(t, checkEULA(path).Passed, "missing")
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("#By changing the setting below to TRUE you are indicating your agreement to our EULA.\neula=false\n"), 0644))
+	assert.False(t, checkEULA(path).Passed)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("eula=TRUE\n"), 0644))
+	assert.True(t, checkEULA(path).Passed)
+}
+
+func TestStartPreflightFailed(t *testing.T) {
+	dir := t.TempDir()
+
+	m := New(0, gameserver.WithWorkingDir(dir))
+	m.Command = []string{writeJava(t, dir, "17.0.2"), "-jar", JarFile}
+
+	_, err := m.Start()
+
+	var pf *pickaxx.PreflightError
+	require.True(t, errors.As(err, &pf))
+	assert.False(t, m.Running())
+
+	names := []string{}
+	for _, c := range pf.Failed {
+		names = append(names, c.Name)
+		assert.NotEmpty(t, c.Fix)
+	}
+	assert.Equal(t, []string{CheckJar, CheckEULA}, names)
+
+	writeJar(t, filepath.Join(dir, JarFile), 52)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, EULAFile), []byte("eula=true\n"), 0644))
+
+	for _, c := range m.Preflight() {
+		assert.True(t, c.Passed, c.Message)
+	}
+}
@@ -0,0 +1,124 @@
+package minecraft
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PropertiesFile is the name of the server's settings file, within its working directory.
+const PropertiesFile = "server.properties"
+
+// readProperties reads settings from a properties file (as written by
+// Minecraft servers). A missing file has no settings.
+func readProperties(path string) (map[string]string, error) {
+	props := map[string]string{}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return props, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			props[line] = ""
+			continue
+		}
+
+		props[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+	}
+
+	return props, s.Err()
+}
+
+// writeProperties changes settings in a properties file, leaving others
+// (and comments) as they are. Settings not already in the file are added.
+func writeProperties(path string, values map[string]string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var (
+		lines   = strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+		written = map[string]bool{}
+	)
+
+	if len(b) == 0 {
+		lines = nil
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+			continue
+		}
+
+		key := trimmed
+		if j := strings.IndexAny(trimmed, "=:"); j >= 0 {
+			key = strings.TrimSpace(trimmed[:j])
+		}
+
+		if value, ok := values[key]; ok {
+			lines[i] = key + "=" + value
+			written[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if !written[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		lines = append(lines, key+"="+values[key])
+	}
+
+	return ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// propertiesPath returns the path of the server's properties file.
+func (s *server) propertiesPath() string {
+	return filepath.Join(s.m.WorkingDir, PropertiesFile)
+}
+
+// properties returns the settings of the server.
+func (s *server) properties() (map[string]string, error) {
+	return readProperties(s.propertiesPath())
+}
+
+// propertyPort returns the port set in the server's properties, if any.
+func (s *server) propertyPort() int {
+	key := s.edition.Properties.Port
+	if key == "" {
+		return 0
+	}
+
+	props, err := s.properties()
+	if err != nil {
+		return 0
+	}
+
+	port, _ := strconv.Atoi(props[key])
+	return port
+}
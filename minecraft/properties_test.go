@@ -0,0 +1,63 @@
+package minecraft
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadProperties(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, PropertiesFile)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`#Minecraft server properties
+#Mon Jan 01 12:00:00 UTC 2024
+server-port=25570
+motd = A Minecraft Server
+level-seed=
+! another comment
+allow-list:true
+`), 0644))
+
+	props, err := readProperties(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"server-port": "25570",
+		"motd":        "A Minecraft Server",
+		"level-seed":  "",
+		"allow-list":  "true",
+	}, props)
+
+	props, err = readProperties(filepath.Join(dir, "missing.properties"))
+	assert.NoError(t, err)
+	assert.Empty(t, props)
+}
+
+func TestPortFromProperties(t *testing.T) {
+	tests := []struct {
+		name       string
+		typ        gameserver.ServerType
+		properties string
+		expected   int
+	}{
+		{"from properties", JavaEdition, "server-port=25570\n", 25570},
+		{"not set", Bedrock, "", 0},
+		{"bedrock properties", Bedrock, "server-port=19200\n", 19200},
+		{"invalid", JavaEdition, "server-port=many\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, PropertiesFile), []byte(tt.properties), 0644))
+
+			s := New(0, gameserver.WithType(tt.typ), gameserver.WithWorkingDir(dir)).Hooks().(*server)
+			assert.Equal(t, tt.expected, s.ConfiguredPort())
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package minecraft
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+)
+
+const (
+	// queryInterval is the time between status queries sent to the server.
+	queryInterval = time.Second * 10
+
+	queryHandshake = 0x09
+	queryStat      = 0x00
+)
+
+var (
+	queryMagic = []byte{0xfe, 0xfd}
+
+	// padding before the players section of a full stat response
+	queryPlayersPadding = []byte("\x01player_\x00\x00")
+
+	errInvalidQuery = errors.New("invalid query response")
+)
+
+// FullStat is a server's response to a full stat query.
+type FullStat struct {
+	Values  map[string]string // e.g. "hostname", "version", "plugins", "map", "numplayers"
+	Players []string          // Every player online.
+}
+
+// Info converts this response to a status report.
+func (s *FullStat) Info() *pickaxx.ServerInfo {
+	info := &pickaxx.ServerInfo{
+		MOTD:        s.Values["hostname"],
+		Version:     s.Values["version"],
+		PlayerNames: append([]string{}, s.Players...),
+		World:       s.Values["map"],
+		GameType:    s.Values["gametype"],
+		UpdatedAt:   time.Now(),
+	}
+
+	info.Players, _ = strconv.Atoi(s.Values["numplayers"])
+	info.MaxPlayers, _ = strconv.Atoi(s.Values["maxplayers"])
+	sort.Strings(info.PlayerNames)
+
+	// e.g. "Paper on 1.20.1: WorldEdit 7.2.15; EssentialsX 2.20.1"
+	if plugins := s.Values["plugins"]; plugins != "" {
+		parts := strings.SplitN(plugins, ":", 2)
+		info.Software = strings.TrimSpace(parts[0])
+
+		if len(parts) > 1 {
+			for _, p := range strings.Split(parts[1], ";") {
+				if p = strings.TrimSpace(p); p != "" {
+					info.Plugins = append(info.Plugins, p)
+				}
+			}
+		}
+	}
+
+	return info
+}
+
+// Query requests the full status of a server, using the GameSpy4 query
+// protocol (enabled by 'enable-query' in server.properties). A challenge
+// token is requested with a handshake, then sent with the full stat request.
+func Query(ctx context.Context, addr string) (*FullStat, error) {
+	conn, err := dialUDP(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	session := rand.Int31() & 0x0f0f0f0f
+
+	token, err := queryToken(conn, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryFullStat(conn, session, token)
+}
+
+// queryRequest encodes a request of the given type.
+func queryRequest(kind byte, session int32, payload ...byte) []byte {
+	var b bytes.Buffer
+
+	b.Write(queryMagic)
+	b.WriteByte(kind)
+	binary.Write(&b, binary.BigEndian, session)
+	b.Write(payload)
+
+	return b.Bytes()
+}
+
+// queryExchange sends a request, returning the body of the response to it.
+func queryExchange(conn net.Conn, kind byte, session int32, payload ...byte) ([]byte, error) {
+	if _, err := conn.Write(queryRequest(kind, session, payload...)); err != nil {
+		return nil, fmt.Errorf("%w: %v", gameserver.ErrNoResponse, err)
+	}
+
+	buf := make([]byte, 65535)
+
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", gameserver.ErrNoResponse, err)
+	}
+
+	// type & session, as sent
+	if n < 5 || buf[0] != kind || int32(binary.BigEndian.Uint32(buf[1:5])) != session {
+		return nil, errInvalidQuery
+	}
+
+	return buf[5:n], nil
+}
+
+// queryToken performs a handshake, returning the challenge token.
+func queryToken(conn net.Conn, session int32) (int32, error) {
+	body, err := queryExchange(conn, queryHandshake, session)
+	if err != nil {
+		return 0, err
+	}
+
+	token, err := strconv.ParseInt(string(bytes.TrimRight(body, "\x00")), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: challenge token: %v", errInvalidQuery, err)
+	}
+
+	return int32(token), nil
+}
+
+// queryFullStat requests the full status of the server.
+func queryFullStat(conn net.Conn, session, token int32) (*FullStat, error) {
+	payload := make([]byte, 8) // token, and padding requesting a full stat
+	binary.BigEndian.PutUint32(payload, uint32(token))
+
+	body, err := queryExchange(conn, queryStat, session, payload...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFullStat(body)
+}
+
+// parseFullStat decodes the body of a full stat response:
+//
+//	"splitnum\x00\x80\x00"             padding
+//	key\x00value\x00 ... \x00          key-value pairs, ending with an empty key
+//	"\x01player_\x00\x00"              padding
+//	name\x00 ... \x00                  players, ending with an empty name
+func parseFullStat(body []byte) (*FullStat, error) {
+	const padding = 11
+
+	if len(body) < padding {
+		return nil, errInvalidQuery
+	}
+
+	var (
+		fields = bytes.Split(body[padding:], []byte{0})
+		stat   = &FullStat{Values: map[string]string{}, Players: []string{}}
+		i      = 0
+	)
+
+	for ; i+1 < len(fields) && len(fields[i]) > 0; i += 2 {
+		stat.Values[string(fields[i])] = string(fields[i+1])
+	}
+
+	if i >= len(fields) {
+		return nil, fmt.Errorf("%w: missing players", errInvalidQuery)
+	}
+
+	rest := bytes.Join(fields[i+1:], []byte{0})
+	if !bytes.HasPrefix(rest, queryPlayersPadding) {
+		return nil, fmt.Errorf("%w: missing players", errInvalidQuery)
+	}
+
+	for _, name := range bytes.Split(rest[len(queryPlayersPadding):], []byte{0}) {
+		if len(name) == 0 {
+			break
+		}
+		stat.Players = append(stat.Players, string(name))
+	}
+
+	return stat, nil
+}
+
+// WithQuery enables the query protocol in server.properties before the
+// server starts, so that its full status (e.g. every player online) can be
+// reported. This is only supported by server types with a query property.
+func WithQuery() gameserver.Option {
+	return configure(func(s *server) {
+		s.enableQuery = true
+	})
+}
+
+// prepareQuery enables the query protocol in server.properties, if configured.
+func (s *server) prepareQuery() error {
+	key := s.edition.Properties.Query
+
+	if !s.enableQuery || key == "" {
+		return nil
+	}
+
+	return writeProperties(s.propertiesPath(), map[string]string{key: "true"})
+}
+
+// queryPort returns the port on which the server answers queries, or 0 if
+// queries are not enabled.
+func (s *server) queryPort() int {
+	keys := s.edition.Properties
+
+	if keys.Query == "" {
+		return 0
+	}
+
+	props, err := s.properties()
+	if err != nil || props[keys.Query] != "true" {
+		return 0
+	}
+
+	if port, err := strconv.Atoi(props[keys.QueryPort]); err == nil {
+		return port
+	}
+
+	return s.m.Type.DefaultPort
+}
+
+// pollQuery queries the status of the server at regular intervals, once it
+// is ready.
+func pollQuery(ctx context.Context, s *server, port int, interval time.Duration) {
+	log := log.WithField("action", "pollQuery()")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !s.m.Loaded() {
+			continue
+		}
+
+		stat, err := Query(ctx, localUDP(port))
+		if err != nil {
+			log.WithError(err).Debug("query failed")
+			continue
+		}
+
+		s.queried(stat.Info())
+	}
+}
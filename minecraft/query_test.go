@@ -0,0 +1,166 @@
+package minecraft
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fullStatBody encodes the body of a full stat response.
+func fullStatBody(values [][2]string, players []string) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("splitnum\x00\x80\x00")
+	for _, kv := range values {
+		b.WriteString(kv[0] + "\x00" + kv[1] + "\x00")
+	}
+	b.WriteByte(0)
+
+	b.Write(queryPlayersPadding)
+	for _, name := range players {
+		b.WriteString(name + "\x00")
+	}
+	b.WriteByte(0)
+
+	return b.Bytes()
+}
+
+var testStat = [][2]string{
+	{"hostname", "A Minecraft Server"},
+	{"gametype", "SMP"},
+	{"game_id", "MINECRAFT"},
+	{"version", "1.20.1"},
+	{"plugins", "Paper on 1.20.1: WorldEdit 7.2.15; EssentialsX 2.20.1"},
+	{"map", "world"},
+	{"numplayers", "3"},
+	{"maxplayers", "100"},
+	{"hostport", "25565"},
+	{"hostip", "127.0.0.1"},
+}
+
+// fakeQueryServer answers queries on a local UDP port, returning the port.
+func fakeQueryServer(t *testing.T, players []string) int {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	const token = 9513307
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < 7 || !bytes.Equal(buf[:2], queryMagic) {
+				continue
+			}
+
+			var reply bytes.Buffer
+			reply.WriteByte(buf[2])
+			reply.Write(buf[3:7]) // session
+
+			switch {
+			case buf[2] == queryHandshake:
+				reply.WriteString(strconv.Itoa(token) + "\x00")
+			case buf[2] == queryStat && n == 15 && binary.BigEndian.Uint32(buf[7:11]) == token:
+				reply.Write(fullStatBody(testStat, players))
+			default:
+				continue
+			}
+
+			conn.WriteTo(reply.Bytes(), addr)
+		}
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestQuery(t *testing.T) {
+	port := fakeQueryServer(t, []string{"Steve", "Alex", "Notch"})
+
+	stat, err := Query(context.Background(), localUDP(port))
+	require.NoError(t, err)
+
+	assert.Equal(t, "A Minecraft Server", stat.Values["hostname"])
+	assert.Equal(t, []string{"Steve", "Alex", "Notch"}, stat.Players)
+
+	info := stat.Info()
+	assert.Equal(t, "A Minecraft Server", info.MOTD)
+	assert.Equal(t, "1.20.1", info.Version)
+	assert.Equal(t, 3, info.Players)
+	assert.Equal(t, 100, info.MaxPlayers)
+	assert.Equal(t, []string{"Alex", "Notch", "Steve"}, info.PlayerNames)
+	assert.Equal(t, "world", info.World)
+	assert.Equal(t, "SMP", info.GameType)
+	assert.Equal(t, "Paper on 1.20.1", info.Software)
+	assert.Equal(t, []string{"WorldEdit 7.2.15", "EssentialsX 2.20.1"}, info.Plugins)
+}
+
+func TestParseFullStat(t *testing.T) {
+	stat, err := parseFullStat(fullStatBody([][2]string{{"hostname", "x"}, {"plugins", ""}}, nil))
+	require.NoError(t, err)
+	assert.Empty(t, stat.Players)
+	assert.Empty(t, stat.Info().Plugins, "vanilla servers have no plugins")
+
+	for name, body := range map[string][]byte{
+		"empty":      {},
+		"no players": fullStatBody(testStat, nil)[:40],
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseFullStat(body)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestQueryNoResponse(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close() // bound, but never replies
+
+	_, err = Query(context.Background(), conn.LocalAddr().String())
+	assert.Error(t, err)
+}
+
+func TestQueryProperties(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, PropertiesFile)
+	require.NoError(t, ioutil.WriteFile(path, []byte("#Minecraft server properties\nenable-query=false\nmotd=hello\n"), 0644))
+
+	s := New(0, gameserver.WithWorkingDir(dir)).Hooks().(*server)
+
+	assert.NoError(t, s.Starting(), "not enabled")
+	assert.Equal(t, 0, s.queryPort())
+
+	s = New(0, gameserver.WithWorkingDir(dir), WithQuery()).Hooks().(*server)
+	require.NoError(t, s.Starting())
+	assert.Equal(t, DefaultPort, s.queryPort())
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "#Minecraft server properties\nenable-query=true\nmotd=hello\n", string(b))
+
+	require.NoError(t, writeProperties(path, map[string]string{"query.port": "25570"}))
+	assert.Equal(t, 25570, s.queryPort())
+}
+
+func TestWriteProperties(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PropertiesFile)
+
+	require.NoError(t, writeProperties(path, map[string]string{"b": "2", "a": "1"}))
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "a=1\nb=2\n", string(b))
+}
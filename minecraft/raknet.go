@@ -0,0 +1,147 @@
+package minecraft
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+)
+
+// probeTimeout is how long a single probe (or query) waits for a response.
+const probeTimeout = time.Second
+
+// RakNet packet IDs
+const (
+	rakNetUnconnectedPing = 0x01
+	rakNetUnconnectedPong = 0x1c
+)
+
+// rakNetMagic identifies offline (unconnected) RakNet messages.
+var rakNetMagic = []byte{0x00, 0xff, 0xff, 0x00, 0xfe, 0xfe, 0xfe, 0xfe, 0xfd, 0xfd, 0xfd, 0xfd, 0x12, 0x34, 0x56, 0x78}
+
+// errInvalidPong is returned for a response which is not a valid unconnected pong.
+var errInvalidPong = errors.New("invalid unconnected pong")
+
+// RakNetProbe sends a RakNet "unconnected ping" to a UDP port, as clients
+// do to list servers. Servers reply with their status (e.g. MOTD, version &
+// players online), as used by Minecraft: Bedrock Edition.
+type RakNetProbe struct{}
+
+// Probe pings the server, returning the status in its reply.
+func (RakNetProbe) Probe(ctx context.Context, port int) (*pickaxx.ServerInfo, error) {
+	conn, err := dialUDP(ctx, localUDP(port))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(unconnectedPing(time.Now(), rand.Uint64())); err != nil {
+		return nil, fmt.Errorf("%w: %v", gameserver.ErrNoResponse, err)
+	}
+
+	buf := make([]byte, 1500)
+
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", gameserver.ErrNoResponse, err)
+	}
+
+	return parseUnconnectedPong(buf[:n])
+}
+
+// unconnectedPing encodes a ping from a client.
+//
+//	byte     0x01
+//	int64    time (ms)
+//	[16]byte magic
+//	int64    client GUID
+func unconnectedPing(now time.Time, guid uint64) []byte {
+	var b bytes.Buffer
+
+	b.WriteByte(rakNetUnconnectedPing)
+	binary.Write(&b, binary.BigEndian, now.UnixNano()/int64(time.Millisecond))
+	b.Write(rakNetMagic)
+	binary.Write(&b, binary.BigEndian, guid)
+
+	return b.Bytes()
+}
+
+// parseUnconnectedPong decodes the status in a server's reply to a ping.
+//
+//	byte     0x1c
+//	int64    time (ms), as sent in the ping
+//	int64    server GUID
+//	[16]byte magic
+//	uint16   length of status
+//	string   status, e.g. "MCPE;MOTD;protocol;version;online;max;guid;world;game mode;..."
+func parseUnconnectedPong(b []byte) (*pickaxx.ServerInfo, error) {
+	const header = 1 + 8 + 8 + 16 + 2
+
+	if len(b) < header || b[0] != rakNetUnconnectedPong || !bytes.Equal(b[17:33], rakNetMagic) {
+		return nil, errInvalidPong
+	}
+
+	size := int(binary.BigEndian.Uint16(b[33:35]))
+	if len(b) < header+size {
+		return nil, errInvalidPong
+	}
+
+	fields := strings.Split(string(b[header:header+size]), ";")
+	if len(fields) < 6 {
+		return nil, fmt.Errorf("%w: status has %d fields", errInvalidPong, len(fields))
+	}
+
+	field := func(i int) string {
+		if i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+
+	info := &pickaxx.ServerInfo{
+		MOTD:      field(1),
+		Version:   field(3),
+		World:     field(7),
+		GameMode:  field(8),
+		UpdatedAt: time.Now(),
+	}
+
+	info.Players, _ = strconv.Atoi(field(4))
+	info.MaxPlayers, _ = strconv.Atoi(field(5))
+
+	return info, nil
+}
+
+// localUDP is the address of a UDP port on the local host. This is IPv4,
+// as some servers listen for IPv6 on a different port.
+func localUDP(port int) string {
+	return fmt.Sprintf("127.0.0.1:%d", port)
+}
+
+// dialUDP connects to a UDP address. Reads & writes fail once the context
+// is done, or the probe times out.
+func dialUDP(ctx context.Context, addr string) (net.Conn, error) {
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(probeTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	conn.SetDeadline(deadline)
+	return conn, nil
+}
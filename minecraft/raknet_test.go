@@ -0,0 +1,106 @@
+package minecraft
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bedrockStatus = "MCPE;Dedicated Server;589;1.20.0;2;10;13253860892328930865;Bedrock level;Survival;1;19132;19133;"
+
+// pong encodes a server's reply to a ping.
+func pong(ping []byte, status string) []byte {
+	var b bytes.Buffer
+
+	b.WriteByte(rakNetUnconnectedPong)
+	b.Write(ping[1:9]) // time, as sent
+	binary.Write(&b, binary.BigEndian, uint64(13253860892328930865))
+	b.Write(rakNetMagic)
+	binary.Write(&b, binary.BigEndian, uint16(len(status)))
+	b.WriteString(status)
+
+	return b.Bytes()
+}
+
+// fakeBedrockServer replies to pings on a local UDP port, returning the port.
+func fakeBedrockServer(t *testing.T, status string) int {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n == 33 && buf[0] == rakNetUnconnectedPing && bytes.Equal(buf[9:25], rakNetMagic) {
+				conn.WriteTo(pong(buf[:n], status), addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestUnconnectedPing(t *testing.T) {
+	b := unconnectedPing(time.Unix(1, 0), 42)
+
+	require.Len(t, b, 33)
+	assert.Equal(t, byte(rakNetUnconnectedPing), b[0])
+	assert.Equal(t, uint64(1000), binary.BigEndian.Uint64(b[1:9]))
+	assert.Equal(t, rakNetMagic, b[9:25])
+	assert.Equal(t, uint64(42), binary.BigEndian.Uint64(b[25:33]))
+}
+
+func TestParseUnconnectedPong(t *testing.T) {
+	ping := unconnectedPing(time.Now(), 42)
+
+	info, err := parseUnconnectedPong(pong(ping, bedrockStatus))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Dedicated Server", info.MOTD)
+	assert.Equal(t, "1.20.0", info.Version)
+	assert.Equal(t, 2, info.Players)
+	assert.Equal(t, 10, info.MaxPlayers)
+	assert.Equal(t, "Bedrock level", info.World)
+	assert.Equal(t, "Survival", info.GameMode)
+
+	tests := map[string][]byte{
+		"empty":     {},
+		"not pong":  ping,
+		"truncated": pong(ping, bedrockStatus)[:40],
+		"no fields": pong(ping, "MCPE;motd"),
+	}
+
+	for name, b := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseUnconnectedPong(b)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRakNetProbe(t *testing.T) {
+	port := fakeBedrockServer(t, bedrockStatus)
+
+	info, err := RakNetProbe{}.Probe(context.Background(), port)
+	require.NoError(t, err)
+	assert.Equal(t, "Dedicated Server", info.MOTD)
+
+	// nothing listening
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+
+	_, err = RakNetProbe{}.Probe(context.Background(), closed)
+	assert.Error(t, err)
+}
@@ -0,0 +1,199 @@
+package minecraft
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+)
+
+var (
+	_ gameserver.PortReader = &server{}
+	_ gameserver.Launcher   = &server{}
+	_ gameserver.Checker    = &server{}
+	_ gameserver.Starter    = &server{}
+	_ gameserver.Resetter   = &server{}
+	_ gameserver.Observer   = &server{}
+	_ gameserver.Runner     = &server{}
+	_ gameserver.Announcer  = &server{}
+	_ pickaxx.InfoReporter  = &server{}
+)
+
+// server is the hooks of a manager of a Minecraft server (see Edition).
+type server struct {
+	m       *gameserver.Manager
+	edition Edition
+
+	// enable the query protocol when starting
+	enableQuery bool
+
+	// JVM settings, overriding those of the command of the type if set
+	jvm *JVMConfig
+
+	// Java runtime pinned (see WithJava), and directories searched for runtimes
+	javaPin  string
+	javaDirs []string
+
+	// Java runtime last chosen to run the server jar
+	chosenJava javaChoice
+
+	// description of the server jar
+	jarInfo jarCache
+
+	// held while plugins are changed
+	pluginLock sync.Mutex
+
+	// in-game performance
+	perf tickTracker
+
+	lock           sync.RWMutex
+	pluginsChanged time.Time           // last time a plugin was changed
+	queryInfo      *pickaxx.ServerInfo // latest status reported by a query
+}
+
+// configure returns an option applying settings to the hooks of Minecraft
+// servers. Hooks of other types are left as they are.
+func configure(apply func(s *server)) gameserver.Option {
+	return gameserver.Configure(func(h gameserver.Hooks) {
+		if s, ok := h.(*server); ok {
+			apply(s)
+		}
+	})
+}
+
+// ConfiguredPort returns the port set in server.properties, if any.
+func (s *server) ConfiguredPort() int {
+	return s.propertyPort()
+}
+
+// Launch applies the JVM settings, if configured, and chooses the Java
+// runtime of servers launched from a jar. The runtime is chosen again
+// before each start (e.g. for a new jar).
+func (s *server) Launch(command []string, fresh bool) ([]string, error) {
+	var err error
+
+	if s.jvm != nil {
+		if command, err = s.jvmCommand(command); err != nil {
+			return nil, err
+		}
+	}
+
+	if jar := jarArg(command); jar != "" {
+		if command[0], err = s.chooseJava(command[0], jar, fresh); err != nil {
+			return nil, err
+		}
+	}
+
+	return command, nil
+}
+
+// Checks checks the server jar, the version of Java running it, and that
+// the EULA has been accepted, for servers launched from a jar.
+func (s *server) Checks(command []string) []pickaxx.Check {
+	jar := jarArg(command)
+	if jar == "" {
+		return nil
+	}
+
+	var (
+		dir    = s.m.WorkingDir
+		path   = filepath.Join(dir, jar)
+		checks = []pickaxx.Check{checkJar(path)}
+	)
+
+	// relative paths are run from the working directory
+	java := command[0]
+	if strings.Contains(java, "/") && !filepath.IsAbs(java) {
+		java = filepath.Join(dir, java)
+	}
+
+	// a missing runtime is reported by the executable check
+	if _, err := exec.LookPath(java); err == nil {
+		checks = append(checks, checkJavaVersion(command[0], path))
+	}
+
+	return append(checks, checkEULA(filepath.Join(dir, EULAFile)))
+}
+
+// Starting enables the query protocol, if configured.
+func (s *server) Starting() error {
+	return s.prepareQuery()
+}
+
+// Reset clears the status & performance state of the last run. History is
+// retained.
+func (s *server) Reset() {
+	s.perf.Reset()
+
+	s.lock.Lock()
+	s.queryInfo = nil
+	s.lock.Unlock()
+}
+
+// Observe records performance samples reported in console output.
+func (s *server) Observe(line string) []pickaxx.Data {
+	return s.perf.Observe(line)
+}
+
+// Run polls the server for its in-game performance, and queries its full
+// status (if enabled), until the context is done.
+func (s *server) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		pollTicks(ctx, s, s.edition.TickCommands, tickPollInterval)
+	}()
+
+	if port := s.queryPort(); port > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pollQuery(ctx, s, port, queryInterval)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// AnnounceStop shows a title to players, with the reason as subtitle, if
+// the edition supports titles.
+func (s *server) AnnounceStop(reason string) {
+	if !s.edition.Titles {
+		return
+	}
+
+	if reason != "" {
+		s.m.Send(fmt.Sprintf("title @a subtitle %s", textComponent(reason)))
+	}
+	s.m.Send(fmt.Sprintf("title @a title %s", textComponent("Server stopping")))
+}
+
+// Info returns the status last reported by a query, or nil if queries are
+// not enabled.
+func (s *server) Info() *pickaxx.ServerInfo {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.queryInfo
+}
+
+// queried records the status reported by a query.
+func (s *server) queried(info *pickaxx.ServerInfo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.queryInfo = info
+}
+
+// textComponent returns text as a JSON text component (e.g. for /title).
+func textComponent(text string) string {
+	b, _ := json.Marshal(map[string]string{"text": text})
+	return string(b)
+}
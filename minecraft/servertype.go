@@ -0,0 +1,135 @@
+package minecraft
+
+import (
+	"regexp"
+
+	"github.com/ivan3bx/pickaxx/gameserver"
+)
+
+const (
+	// MaxMem is the maximum allocated memory
+	MaxMem = "-Xmx1024m"
+
+	// MinMem is the minimum allocated memory
+	MinMem = "-Xms512m"
+
+	// JarFile is the name of the server jar as it exists on disk
+	JarFile = "server.jar"
+
+	// DefaultPort is the default minecraft server port
+	DefaultPort = 25565
+
+	// BedrockPort is the default port of Bedrock Dedicated Server (UDP)
+	BedrockPort = 19132
+)
+
+// ProbeRakNet pings the server port (UDP), see RakNetProbe.
+const ProbeRakNet gameserver.ProbeKind = "raknet"
+
+// DefaultCommand is the name of the executable.
+var DefaultCommand = []string{"java", MaxMem, MinMem, "-jar", JarFile, "nogui"}
+
+var (
+	serverReady  = regexp.MustCompile(`Done \([\d.,]+s\)! For help`)
+	playerJoined = regexp.MustCompile(`]: (\w{1,16}) joined the game$`)
+	playerLeft   = regexp.MustCompile(`]: (\w{1,16}) left the game$`)
+)
+
+// PropertyKeys name settings in server.properties, which differ between
+// editions.
+type PropertyKeys struct {
+	Port      string // The server port (e.g. "server-port").
+	Allowlist string // Enables the allowlist (e.g. "white-list").
+	Query     string // Enables the query protocol (e.g. "enable-query").
+	QueryPort string // The query port (e.g. "query.port"); defaults to the default port of the type.
+}
+
+// Edition describes what differs between editions of Minecraft, beyond how
+// they are run (see gameserver.ServerType). Its hooks read server.properties
+// & the allowlist, and report in-game performance, the server jar & its
+// plugins (for servers launched from a jar).
+type Edition struct {
+	Properties       PropertyKeys        // Settings read from server.properties, if present.
+	Titles           bool                // Supports the /title command, with JSON text.
+	AllowlistFile    string              // JSON file listing players allowed to join, if supported.
+	AllowlistCommand string              // Console command which adds & removes players from the allowlist.
+	PlayerName       *gameserver.Pattern // Matches valid player names. If not set, any name the console accepts.
+	TickCommands     []string            // Commands reporting tick performance, tried in order.
+}
+
+// Hooks creates the hooks of a manager of a server of this edition.
+func (e Edition) Hooks(m *gameserver.Manager) gameserver.Hooks {
+	return &server{m: m, edition: e}
+}
+
+// JavaEdition is Minecraft: Java Edition.
+var JavaEdition = gameserver.ServerType{
+	Name:           "minecraft",
+	Command:        DefaultCommand,
+	DefaultPort:    DefaultPort,
+	StopCommand:    "stop",
+	SaveCommand:    "save-all flush", // written to disk before responding
+	SaveOffCommand: "save-off",
+	SaveOnCommand:  "save-on",
+	Saved:          gameserver.MustPattern(`]: Saved the game$`),
+	SayCommand:     "say",
+	Ready:          &gameserver.Pattern{Regexp: serverReady},
+	Probe:          gameserver.ProbeTCP,
+	Failures:       JavaFailures,
+	Parsers: []gameserver.LineParser{
+		{Pattern: &gameserver.Pattern{Regexp: playerJoined}, Event: gameserver.EventPlayerJoined},
+		{Pattern: &gameserver.Pattern{Regexp: playerLeft}, Event: gameserver.EventPlayerLeft},
+	},
+	Hooks: Edition{
+		Titles:           true,
+		AllowlistFile:    "whitelist.json",
+		AllowlistCommand: "whitelist",
+		PlayerName:       gameserver.MustPattern(`^\w{3,16}$`),
+		TickCommands:     TickCommands,
+		Properties: PropertyKeys{
+			Port:      "server-port",
+			Allowlist: "white-list",
+			Query:     "enable-query",
+			QueryPort: "query.port",
+		},
+	}.Hooks,
+}
+
+// Bedrock is Minecraft: Bedrock Edition, run as Bedrock Dedicated Server.
+// It is a native executable, listening on a UDP port.
+var Bedrock = gameserver.ServerType{
+	Name:        "bedrock",
+	Command:     []string{"./bedrock_server"},
+	Env:         []string{"LD_LIBRARY_PATH=."}, // shared libraries are shipped alongside the server
+	DefaultPort: BedrockPort,
+	Network:     "udp",
+	StopCommand: "stop", // saves the world before exiting
+	SayCommand:  "say",
+	Ready:       gameserver.MustPattern(`INFO\] Server started\.$`),
+	Probe:       ProbeRakNet,
+	Failures:    BedrockFailures,
+	Parsers: []gameserver.LineParser{
+		// e.g. "[2024-01-01 12:00:00:000 INFO] Player connected: Steve, xuid: 2535416..."
+		{Pattern: gameserver.MustPattern(`INFO\] Player connected: ([^,]+), xuid:`), Event: gameserver.EventPlayerJoined},
+		{Pattern: gameserver.MustPattern(`INFO\] Player disconnected: ([^,]+), xuid:`), Event: gameserver.EventPlayerLeft},
+	},
+	Hooks: Edition{
+		Properties:       PropertyKeys{Port: "server-port", Allowlist: "allow-list"},
+		AllowlistFile:    "allowlist.json",
+		AllowlistCommand: "allowlist",
+		PlayerName:       gameserver.MustPattern(`^[A-Za-z][A-Za-z0-9 ]{2,15}$`), // Xbox gamertags
+	}.Hooks,
+}
+
+func init() {
+	gameserver.RegisterProbe(ProbeRakNet, RakNetProbe{})
+	gameserver.Register(JavaEdition)
+	gameserver.Register(Bedrock)
+}
+
+// New creates a new process manager for an instance of Minecraft: Java
+// Edition (or another type of server, see gameserver.WithType). If port is
+// 0, the port is read from the server's properties when started.
+func New(port int, opts ...gameserver.Option) *gameserver.Manager {
+	return gameserver.New(port, append([]gameserver.Option{gameserver.WithType(JavaEdition)}, opts...)...)
+}
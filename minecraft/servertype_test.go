@@ -0,0 +1,98 @@
+package minecraft
+
+import (
+	"testing"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerTypesRegistered(t *testing.T) {
+	for _, typ := range []gameserver.ServerType{JavaEdition, Bedrock} {
+		assert.NoError(t, typ.Validate())
+		assert.Contains(t, gameserver.Types, typ.Name)
+		assert.IsType(t, &server{}, typ.Hooks(&gameserver.Manager{}))
+	}
+
+	assert.Equal(t, RakNetProbe{}, gameserver.Probes[ProbeRakNet])
+}
+
+// parse returns the event recognized in a line of output by the parsers of
+// a type, and its subject.
+func parse(typ gameserver.ServerType, line string) (event, subject string) {
+	for _, p := range typ.Parsers {
+		if match := p.Pattern.FindStringSubmatch(line); match != nil {
+			return p.Event, match[1]
+		}
+	}
+	return "", ""
+}
+
+// failure returns the code of the failure recognized in a line of output by
+// the signatures of a type.
+func failure(typ gameserver.ServerType, line string) string {
+	for _, f := range typ.Failures {
+		if f.Pattern.MatchString(line) {
+			return f.Code
+		}
+	}
+	return ""
+}
+
+func TestJavaEditionOutput(t *testing.T) {
+	assert.False(t, JavaEdition.Ready.MatchString("[12:00:00] [Server thread/INFO]: Preparing level \"world\""))
+	assert.True(t, JavaEdition.Ready.MatchString("[12:00:05] [Server thread/INFO]: Done (4.321s)! For help, type \"help\""))
+
+	event, name := parse(JavaEdition, "[12:01:00] [Server thread/INFO]: Steve joined the game")
+	assert.Equal(t, gameserver.EventPlayerJoined, event)
+	assert.Equal(t, "Steve", name)
+
+	event, name = parse(JavaEdition, "[12:02:00] [Server thread/INFO]: Steve left the game")
+	assert.Equal(t, gameserver.EventPlayerLeft, event)
+	assert.Equal(t, "Steve", name)
+
+	event, _ = parse(JavaEdition, "[12:02:00] [Server thread/INFO]: <Steve> Steve joined the game?")
+	assert.Empty(t, event, "chat is ignored")
+
+	failures := map[string]string{
+		"[12:00:00] [Server thread/WARN]: You need to agree to the EULA in order to run the server. Go to eula.txt for more info.": FailureEULA,
+		"[12:00:00] [Server thread/WARN]: **** FAILED TO BIND TO PORT!":                                                            FailurePortInUse,
+		"Exception in thread \"main\" java.lang.UnsupportedClassVersionError: net/minecraft/server/Main":                           FailureJavaVersion,
+		"Error: Unable to access jarfile server.jar":                                                                               FailureJarMissing,
+		"Error occurred during initialization of VM: Could not reserve enough space for 8388608KB object heap":                     FailureMemory,
+		"[12:00:00] [Server thread/INFO]: Starting minecraft server version 1.20.1":                                                "",
+	}
+
+	for line, code := range failures {
+		assert.Equal(t, code, failure(JavaEdition, line), line)
+	}
+}
+
+func TestBedrockOutput(t *testing.T) {
+	assert.False(t, Bedrock.Ready.MatchString("[2024-01-01 12:00:00:000 INFO] Starting Server"))
+	assert.True(t, Bedrock.Ready.MatchString("[2024-01-01 12:00:05:000 INFO] Server started."))
+
+	event, name := parse(Bedrock, "[2024-01-01 12:01:00:000 INFO] Player connected: Steve Two, xuid: 2535416409681153")
+	assert.Equal(t, gameserver.EventPlayerJoined, event)
+	assert.Equal(t, "Steve Two", name)
+
+	event, name = parse(Bedrock, "[2024-01-01 12:02:00:000 INFO] Player disconnected: Steve Two, xuid: 2535416409681153, pfid: 1234")
+	assert.Equal(t, gameserver.EventPlayerLeft, event)
+	assert.Equal(t, "Steve Two", name)
+
+	require.Len(t, Bedrock.Failures, 1)
+	assert.Equal(t, FailurePortInUse, failure(Bedrock, "[2024-01-01 12:00:00:000 ERROR] Network port occupied, can't start server."))
+}
+
+// drain discards activity, returning a channel closed once activity ends.
+func drain(activity <-chan pickaxx.Data) <-chan bool {
+	done := make(chan bool)
+	go func() {
+		for range activity {
+		}
+		close(done)
+	}()
+	return done
+}
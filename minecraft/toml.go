@@ -0,0 +1,225 @@
+package minecraft
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tomlTable is a table of a TOML document, e.g. "[[mods]]".
+type tomlTable struct {
+	Name   string                 // e.g. "mods" or "dependencies.examplemod"; empty for the root table
+	Values map[string]interface{} // string, bool or []string, by key
+}
+
+// parseTOML reads the tables of a TOML document, in order. Only what is
+// found in mods.toml is supported: tables, arrays of tables, and keys with
+// string, boolean or array values. Numbers and dates are read as strings,
+// and inline tables are skipped.
+func parseTOML(doc string) ([]tomlTable, error) {
+	var (
+		p      = &tomlParser{s: doc}
+		tables = []tomlTable{{Values: map[string]interface{}{}}}
+	)
+
+	for {
+		p.skipSpace(true)
+		if p.done() {
+			return tables, nil
+		}
+
+		if p.peek() == '[' {
+			end := "]"
+			if strings.HasPrefix(p.s[p.i:], "[[") {
+				end = "]]"
+			}
+
+			p.i += len(end)
+
+			n := strings.Index(p.s[p.i:], end)
+			if n < 0 {
+				return nil, p.errorf("unterminated table header")
+			}
+
+			name := strings.ReplaceAll(strings.TrimSpace(p.s[p.i:p.i+n]), `"`, "")
+			tables = append(tables, tomlTable{Name: name, Values: map[string]interface{}{}})
+			p.i += n + len(end)
+			continue
+		}
+
+		n := strings.IndexByte(p.s[p.i:], '=')
+		if n < 0 || strings.ContainsRune(p.s[p.i:p.i+n], '\n') {
+			return nil, p.errorf("expected key = value")
+		}
+
+		key := strings.Trim(strings.TrimSpace(p.s[p.i:p.i+n]), `"'`)
+		p.i += n + 1
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		if v != nil {
+			tables[len(tables)-1].Values[key] = v
+		}
+	}
+}
+
+type tomlParser struct {
+	s string
+	i int
+}
+
+func (p *tomlParser) done() bool { return p.i >= len(p.s) }
+func (p *tomlParser) peek() byte { return p.s[p.i] }
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.s[:p.i], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace and comments, and newlines if asked.
+func (p *tomlParser) skipSpace(newlines bool) {
+	for !p.done() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.i++
+		case c == '\n' && newlines:
+			p.i++
+		case c == '#':
+			if n := strings.IndexByte(p.s[p.i:], '\n'); n >= 0 {
+				p.i += n
+			} else {
+				p.i = len(p.s)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// value reads the value of a key, or an element of an array.
+func (p *tomlParser) value() (interface{}, error) {
+	p.skipSpace(false)
+	if p.done() {
+		return nil, p.errorf("expected value")
+	}
+
+	for _, quote := range []string{`"""`, `'''`} {
+		if strings.HasPrefix(p.s[p.i:], quote) {
+			p.i += len(quote)
+
+			n := strings.Index(p.s[p.i:], quote)
+			if n < 0 {
+				return nil, p.errorf("unterminated string")
+			}
+
+			s := strings.TrimPrefix(p.s[p.i:p.i+n], "\n")
+			p.i += n + len(quote)
+			return s, nil
+		}
+	}
+
+	switch p.peek() {
+	case '"':
+		for n := p.i + 1; n < len(p.s) && p.s[n] != '\n'; n++ {
+			if p.s[n] == '\\' {
+				n++
+			} else if p.s[n] == '"' {
+				raw := p.s[p.i : n+1]
+				p.i = n + 1
+
+				if s, err := strconv.Unquote(raw); err == nil {
+					return s, nil
+				}
+				return raw[1 : len(raw)-1], nil
+			}
+		}
+		return nil, p.errorf("unterminated string")
+
+	case '\'':
+		n := strings.IndexAny(p.s[p.i+1:], "'\n")
+		if n < 0 || p.s[p.i+1+n] != '\'' {
+			return nil, p.errorf("unterminated string")
+		}
+
+		s := p.s[p.i+1 : p.i+1+n]
+		p.i += n + 2
+		return s, nil
+
+	case '[':
+		p.i++
+
+		values := []string{}
+		for {
+			p.skipSpace(true)
+			if p.done() {
+				return nil, p.errorf("unterminated array")
+			}
+
+			switch p.peek() {
+			case ']':
+				p.i++
+				return values, nil
+			case ',':
+				p.i++
+				continue
+			}
+
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+	case '{':
+		start, depth := p.i, 0
+		for ; !p.done(); p.i++ {
+			switch c := p.peek(); c {
+			case '"', '\'':
+				// skip strings, which may contain braces
+				for p.i++; !p.done() && p.peek() != c; p.i++ {
+					if c == '"' && p.peek() == '\\' {
+						p.i++
+					}
+				}
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					p.i++
+					return nil, nil
+				}
+			}
+		}
+
+		p.i = start
+		return nil, p.errorf("unterminated inline table")
+	}
+
+	// bare values: booleans, numbers & dates
+	n := strings.IndexAny(p.s[p.i:], ",]#\n")
+	if n < 0 {
+		n = len(p.s) - p.i
+	}
+
+	s := strings.TrimSpace(p.s[p.i : p.i+n])
+	if s == "" {
+		return nil, p.errorf("expected value")
+	}
+	p.i += n
+
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+
+	return s, nil
+}
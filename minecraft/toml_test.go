@@ -0,0 +1,111 @@
+package minecraft
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTOML(t *testing.T) {
+	type values = map[string]interface{}
+
+	root := func(v values) []tomlTable {
+		return []tomlTable{{Values: v}}
+	}
+
+	tests := []struct {
+		name     string
+		doc      string
+		expected []tomlTable
+	}{
+		{
+			name:     "empty",
+			doc:      "",
+			expected: root(values{}),
+		},
+		{
+			name:     "comments",
+			doc:      "# leading comment\nname = \"x\" # trailing comment\n\n   # indented comment\n",
+			expected: root(values{"name": "x"}),
+		},
+		{
+			name:     "bare values",
+			doc:      "n = 42\nf = 1.5 # fraction\nyes = true\nno = false\ndate = 1979-05-27\n",
+			expected: root(values{"n": "42", "f": "1.5", "yes": true, "no": false, "date": "1979-05-27"}),
+		},
+		{
+			name:     "basic strings",
+			doc:      `escaped = "tab\there \"quoted\" \u00e9"` + "\n" + `hash = "not # a comment"`,
+			expected: root(values{"escaped": "tab\there \"quoted\" é", "hash": "not # a comment"}),
+		},
+		{
+			name:     "literal strings",
+			doc:      `path = 'C:\Users\steve'` + "\n" + `hash = 'not # a comment'`,
+			expected: root(values{"path": `C:\Users\steve`, "hash": "not # a comment"}),
+		},
+		{
+			name:     "multi-line strings",
+			doc:      "basic = \"\"\"\nfirst\nsecond\"\"\"\nliteral = '''\n\\n is not escaped\n'''\n",
+			expected: root(values{"basic": "first\nsecond", "literal": "\\n is not escaped\n"}),
+		},
+		{
+			name:     "quoted keys",
+			doc:      "\"key with spaces\" = 1\n'literal.key' = 2\n",
+			expected: root(values{"key with spaces": "1", "literal.key": "2"}),
+		},
+		{
+			name:     "arrays",
+			doc:      "empty = []\nmixed = [ \"a\", 'b', 3 ]\nmultiline = [\n  \"a\", # first\n  \"b\",\n]\nbools = [true]\n",
+			expected: root(values{"empty": []string{}, "mixed": []string{"a", "b", "3"}, "multiline": []string{"a", "b"}, "bools": []string{}}),
+		},
+		{
+			name:     "inline tables are skipped",
+			doc:      "meta = {x = 1, nested = {y = \"}\"}}\nafter = \"kept\"\n",
+			expected: root(values{"after": "kept"}),
+		},
+		{
+			name: "tables",
+			doc:  "modLoader = \"javafml\"\n[[mods]]\nmodId = \"a\"\n[[mods]]\nmodId = \"b\"\n[dependencies.a]\nmandatory = true\n[ \"quoted\" ]\n",
+			expected: []tomlTable{
+				{Values: values{"modLoader": "javafml"}},
+				{Name: "mods", Values: values{"modId": "a"}},
+				{Name: "mods", Values: values{"modId": "b"}},
+				{Name: "dependencies.a", Values: values{"mandatory": true}},
+				{Name: "quoted", Values: values{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables, err := parseTOML(tt.doc)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tables)
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		doc string
+		err string
+	}{
+		{"name = \"unterminated\n", "line 1: unterminated string"},
+		{"a = 1\nname = 'unterminated\n", "line 2: unterminated string"},
+		{"text = \"\"\"\nnever closed\n", "line 1: unterminated string"},
+		{"list = [\"a\", \"b\"\n", "line 2: unterminated array"},
+		{"meta = {x = 1\n", "line 1: unterminated inline table"},
+		{"[[mods]\nmodId = \"a\"\n", "line 1: unterminated table header"},
+		{"just a line\n", "line 1: expected key = value"},
+		{"empty =\n", "line 1: expected value"},
+		{"empty = # comment\n", "line 1: expected value"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseTOML(tt.doc)
+		if assert.Error(t, err, tt.doc) {
+			assert.Equal(t, tt.err, err.Error(), tt.doc)
+		}
+	}
+}
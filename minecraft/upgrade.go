@@ -0,0 +1,345 @@
+package minecraft
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+)
+
+var _ pickaxx.Upgrader = &server{}
+
+const (
+	// upgradeFile records the last upgrade, for rollback (within gameserver.DataDir).
+	upgradeFile = "upgrade.json"
+
+	// previousJarSuffix is added to the name of the jar replaced by an upgrade.
+	previousJarSuffix = ".previous"
+
+	// upgradeTimeout is how long an upgraded server has to finish loading.
+	upgradeTimeout = time.Minute * 5
+
+	// upgradePollInterval is how often an upgraded server is checked while loading.
+	upgradePollInterval = time.Millisecond * 500
+)
+
+// ErrInvalidJar is returned when upgrading to a file which is not a jar.
+var ErrInvalidJar = errors.New("not a valid jar")
+
+// upgradeRecord describes the last upgrade.
+type upgradeRecord struct {
+	From   *pickaxx.JarInfo `json:"from"`
+	To     *pickaxx.JarInfo `json:"to"`
+	Backup string           `json:"backup"`
+	Time   time.Time        `json:"time"`
+}
+
+// Upgrade replaces the server jar with the jar at path, after backing up the
+// working directory. The replaced jar is kept for Rollback. Downgrades are
+// refused unless forced. A running server is restarted, blocking until it
+// has loaded (or failed to). This waits for any maintenance in progress (e.g.
+// a backup).
+func (s *server) Upgrade(path string, force bool) (res *pickaxx.UpgradeResult, err error) {
+	err = s.m.Maintain(func() error {
+		res, err = s.upgrade(path, force)
+		return err
+	})
+	return res, err
+}
+
+func (s *server) upgrade(path string, force bool) (*pickaxx.UpgradeResult, error) {
+	current, err := s.jarPath()
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := readJarInfo(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJar, err)
+	}
+	to.Path = current
+
+	from, _ := readJarInfo(current) // nil if there is no jar yet
+
+	if from != nil && !force && compareVersions(to.Version, from.Version) < 0 {
+		return nil, fmt.Errorf("%w (%s is older than %s); force to downgrade", pickaxx.ErrDowngrade, to.Version, from.Version)
+	}
+
+	// taken before restarting, so that a large backup does not hold up the server
+	backup, err := s.m.BackupAs(fmt.Sprintf("pre-upgrade-%s.tar.gz", time.Now().Format("20060102-150405")))
+	if err != nil {
+		return nil, fmt.Errorf("backup failed, server jar not upgraded: %w", err)
+	}
+
+	res := &pickaxx.UpgradeResult{From: from, To: to, Backup: backup}
+
+	upgrade := func() error {
+		if err := replaceJar(current, path); err != nil {
+			return fmt.Errorf("unable to replace server jar: %w", err)
+		}
+
+		return writeUpgradeRecord(s.m.WorkingDir, upgradeRecord{From: from, To: to, Backup: backup, Time: time.Now()})
+	}
+
+	return res, s.whileStopped(upgrade, res, "upgrading server")
+}
+
+// Rollback restores the jar from before the last upgrade, with the server
+// software (e.g. its libraries) from the backup taken then. The world, and
+// all other files, are only restored if restoreWorld is set, as this loses
+// everything played since the upgrade (see gameserver.RestoreBackup). A
+// running server is restarted, as for Upgrade.
+func (s *server) Rollback(restoreWorld bool) (res *pickaxx.UpgradeResult, err error) {
+	err = s.m.Maintain(func() error {
+		res, err = s.rollback(restoreWorld)
+		return err
+	})
+	return res, err
+}
+
+func (s *server) rollback(restoreWorld bool) (*pickaxx.UpgradeResult, error) {
+	current, err := s.jarPath()
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := readUpgradeRecord(s.m.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pickaxx.UpgradeResult{From: rec.To, To: rec.From, Backup: rec.Backup, WorldRestored: restoreWorld}
+
+	include := isServerSoftware
+	if restoreWorld {
+		include = nil
+	}
+
+	rollback := func() error {
+		if err := os.Rename(current+previousJarSuffix, current); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("unable to restore server jar: %w", err)
+		}
+
+		if err := gameserver.RestoreBackup(rec.Backup, s.m.WorkingDir, include); err != nil {
+			return fmt.Errorf("unable to restore backup '%s': %w", rec.Backup, err)
+		}
+
+		return os.Remove(filepath.Join(s.m.WorkingDir, gameserver.DataDir, upgradeFile))
+	}
+
+	return res, s.whileStopped(rollback, res, "rolling back upgrade")
+}
+
+// jarPath returns the path of the server jar.
+func (s *server) jarPath() (string, error) {
+	command, err := s.m.CommandLine()
+	if err != nil {
+		return "", err
+	}
+
+	jar := jarArg(command)
+	if jar == "" {
+		return "", ErrNotJar
+	}
+
+	return filepath.Join(s.m.WorkingDir, jar), nil
+}
+
+// whileStopped runs change with the server stopped: a running server is
+// restarted around it, and the result records whether it loaded again.
+func (s *server) whileStopped(change func() error, res *pickaxx.UpgradeResult, reason string) error {
+	if !s.m.Running() {
+		return change()
+	}
+
+	var changeErr error
+
+	res.Restarted = true
+	err := s.m.RestartWith(func() error {
+		changeErr = change()
+		return changeErr
+	}, pickaxx.WithReason(reason))
+
+	if changeErr != nil {
+		return changeErr
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		return nil
+	}
+
+	res.Running, res.Error = s.waitLoaded(upgradeTimeout)
+	return nil
+}
+
+// waitLoaded waits for a running server to finish loading, returning false
+// (and why) if it stops, or does not load in time.
+func (s *server) waitLoaded(timeout time.Duration) (bool, string) {
+	ticker := time.NewTicker(upgradePollInterval)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		switch s.m.State() {
+		case gameserver.Failed:
+			if f := s.m.Failure(); f != nil {
+				return false, fmt.Sprintf("server failed to start: %s", f.Message)
+			}
+			return false, "server failed to start"
+		case gameserver.Stopping, gameserver.Stopped, gameserver.Unknown:
+			return false, "server stopped"
+		case gameserver.Running:
+			if s.m.Loaded() {
+				return true, ""
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return false, fmt.Sprintf("server did not finish loading within %v", timeout)
+		}
+
+		<-ticker.C
+	}
+}
+
+// serverSoftware holds the server software unpacked from its jar (e.g. by
+// Paper, Fabric & Forge), rather than world data or configuration.
+var serverSoftware = []string{"libraries", "versions", "cache", ".fabric"}
+
+// isServerSoftware returns true if name (within a backup) is part of the
+// server software.
+func isServerSoftware(name string) bool {
+	for _, dir := range serverSoftware {
+		if name == dir || strings.HasPrefix(name, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceJar moves the jar at path to replace current, which is kept with
+// previousJarSuffix (if it exists).
+func replaceJar(current, path string) error {
+	previous := current + previousJarSuffix
+
+	if err := os.Rename(current, previous); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// staged jars may be on another filesystem, so are copied
+	if err := copyFile(path, current); err != nil {
+		os.Rename(previous, current)
+		return err
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+
+	return out.Close()
+}
+
+func writeUpgradeRecord(dir string, rec upgradeRecord) error {
+	if err := os.MkdirAll(filepath.Join(dir, gameserver.DataDir), 0755); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, gameserver.DataDir, upgradeFile), b, 0644)
+}
+
+func readUpgradeRecord(dir string) (*upgradeRecord, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, gameserver.DataDir, upgradeFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, pickaxx.ErrNoUpgrade
+	} else if err != nil {
+		return nil, err
+	}
+
+	var rec upgradeRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, err
+	}
+
+	return &rec, nil
+}
+
+// compareVersions compares game versions (e.g. "1.20.1"), returning -1 if a
+// is older than b, or 1 if newer. Versions which can not be compared (e.g.
+// snapshots such as "23w31a") are treated as equal.
+func compareVersions(a, b string) int {
+	pa, oka := versionParts(a)
+	pb, okb := versionParts(b)
+
+	if !oka || !okb {
+		return 0
+	}
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func versionParts(v string) ([]int, bool) {
+	if v == "" {
+		return nil, false
+	}
+
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+
+	return parts, true
+}
@@ -0,0 +1,165 @@
+package minecraft
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ivan3bx/pickaxx"
+	"github.com/ivan3bx/pickaxx/gameserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeVersionJar writes a vanilla server jar of the given game version.
+func writeVersionJar(t *testing.T, path, version string) {
+	writeJarFiles(t, path, map[string]string{
+		"META-INF/MANIFEST.MF":             "Main-Class: net.minecraft.bundler.Main\n",
+		"net/minecraft/bundler/Main.class": class(61),
+		"version.json":                     `{"id": "` + version + `", "java_version": 17}`,
+	})
+}
+
+func jarVersion(t *testing.T, path string) string {
+	info, err := readJarInfo(path)
+	require.NoError(t, err)
+	return info.Version
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, -1, compareVersions("1.19.4", "1.20.1"))
+	assert.Equal(t, 1, compareVersions("1.20", "1.19.4"))
+	assert.Equal(t, 0, compareVersions("1.20", "1.20.0"))
+	assert.Equal(t, 0, compareVersions("23w31a", "1.20.1"), "snapshots can not be compared")
+	assert.Equal(t, 0, compareVersions("", "1.20.1"))
+}
+
+func TestIsServerSoftware(t *testing.T) {
+	assert.True(t, isServerSoftware("libraries"))
+	assert.True(t, isServerSoftware("libraries/com/google/gson.jar"))
+	assert.True(t, isServerSoftware("versions/1.20.1/paper-1.20.1.jar"))
+	assert.True(t, isServerSoftware(".fabric/remappedJars"))
+	assert.False(t, isServerSoftware("world/level.dat"))
+	assert.False(t, isServerSoftware("libraries-old"))
+	assert.False(t, isServerSoftware("server.properties"))
+}
+
+func TestUpgradeStopped(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		staged = t.TempDir()
+		jar    = filepath.Join(dir, JarFile)
+	)
+
+	writeVersionJar(t, jar, "1.19.4")
+	writeFile := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	readFile := func(name string) string {
+		b, _ := ioutil.ReadFile(filepath.Join(dir, name))
+		return string(b)
+	}
+
+	writeFile("libraries/lib.jar", "old library")
+	writeFile("world/level.dat", "old world")
+
+	m := New(DefaultPort, gameserver.WithWorkingDir(dir))
+	m.Command = []string{"java", "-jar", JarFile, "nogui"}
+
+	// downgrades are refused, unless forced
+	writeVersionJar(t, filepath.Join(staged, "old.jar"), "1.18.2")
+	_, err := m.Upgrade(filepath.Join(staged, "old.jar"), false)
+	assert.True(t, errors.Is(err, pickaxx.ErrDowngrade))
+
+	_, err = m.Upgrade(filepath.Join(staged, "missing.jar"), false)
+	assert.True(t, errors.Is(err, ErrInvalidJar))
+
+	writeVersionJar(t, filepath.Join(staged, "new.jar"), "1.20.1")
+	res, err := m.Upgrade(filepath.Join(staged, "new.jar"), false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.19.4", res.From.Version)
+	assert.Equal(t, "1.20.1", res.To.Version)
+	assert.False(t, res.Restarted)
+	assert.FileExists(t, res.Backup)
+
+	assert.Equal(t, "1.20.1", jarVersion(t, jar))
+	assert.Equal(t, "1.19.4", jarVersion(t, jar+previousJarSuffix))
+
+	writeFile("libraries/lib.jar", "new library")
+	writeFile("world/level.dat", "new world")
+
+	res, err = m.Rollback(false)
+	require.NoError(t, err)
+	assert.Equal(t, "1.20.1", res.From.Version)
+	assert.Equal(t, "1.19.4", res.To.Version)
+	assert.False(t, res.WorldRestored)
+	assert.Equal(t, "1.19.4", jarVersion(t, jar))
+	assert.Equal(t, "old library", readFile("libraries/lib.jar"))
+	assert.Equal(t, "new world", readFile("world/level.dat"), "the world is kept, unless asked to restore it")
+
+	_, err = m.Rollback(false)
+	assert.Equal(t, pickaxx.ErrNoUpgrade, err)
+
+	// upgrade again, rolling back the world too
+	_, err = m.Upgrade(filepath.Join(staged, "new.jar"), false)
+	require.NoError(t, err)
+	writeFile("world/level.dat", "newer world")
+	writeFile("world/region/r.1.0.mca", "new chunk")
+	writeFile("libraries/extra.jar", "new library")
+
+	res, err = m.Rollback(true)
+	require.NoError(t, err)
+	assert.True(t, res.WorldRestored)
+	assert.Equal(t, "new world", readFile("world/level.dat"))
+	assert.NoFileExists(t, filepath.Join(dir, "world/region/r.1.0.mca"), "the world is as it was before the upgrade")
+	assert.NoFileExists(t, filepath.Join(dir, "libraries/extra.jar"))
+	assert.FileExists(t, res.Backup, "backups are kept")
+}
+
+func TestUpgradeRunning(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		staged = t.TempDir()
+	)
+
+	// reports a version to preflight checks, and otherwise echoes its console
+	server := filepath.Join(dir, "server.sh")
+	script := "#!/bin/sh\nif [ \"$1\" = \"-version\" ]; then echo 'openjdk version \"17.0.2\"' >&2; exit 0; fi\nexec cat\n"
+	require.NoError(t, ioutil.WriteFile(server, []byte(script), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, EULAFile), []byte("eula=true\n"), 0644))
+
+	writeVersionJar(t, filepath.Join(dir, JarFile), "1.19.4")
+	writeVersionJar(t, filepath.Join(staged, "new.jar"), "1.20.1")
+
+	m := New(DefaultPort, gameserver.WithType(gameserver.ServerType{
+		Name:    "echo",
+		Command: []string{"true"},
+		Probe:   gameserver.ProbeNone,
+		Hooks:   Edition{}.Hooks,
+	}), gameserver.WithWorkingDir(dir))
+	m.Command = []string{server, "-jar", JarFile}
+
+	isRunning := m.Notifier().Register(gameserver.Running)
+	defer m.Notifier().Unregister(isRunning)
+
+	activity, err := m.Start()
+	require.NoError(t, err)
+	<-isRunning
+
+	done := drain(activity)
+
+	res, err := m.Upgrade(filepath.Join(staged, "new.jar"), false)
+	require.NoError(t, err)
+
+	assert.True(t, res.Restarted)
+	assert.True(t, res.Running, res.Error)
+	assert.Equal(t, "1.20.1", jarVersion(t, filepath.Join(dir, JarFile)))
+	assert.True(t, m.Running())
+
+	require.NoError(t, m.Stop())
+	<-done
+}
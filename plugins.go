@@ -0,0 +1,53 @@
+package pickaxx
+
+import "errors"
+
+// ErrPluginNotFound is returned when a plugin (or mod) is not installed.
+var ErrPluginNotFound = errors.New("plugin not found")
+
+// Plugin describes a plugin (or mod) installed on the server, as read from
+// its jar.
+type Plugin struct {
+	File         string   `json:"file"`                   // Path within the working directory, e.g. "plugins/EssentialsX.jar".
+	ID           string   `json:"id"`                     // Name (or mod ID) other plugins depend on it by.
+	Name         string   `json:"name"`                   // Display name.
+	Version      string   `json:"version,omitempty"`      // e.g. "2.20.1".
+	Authors      []string `json:"authors,omitempty"`      // As listed by the plugin.
+	Dependencies []string `json:"dependencies,omitempty"` // IDs of plugins required to load.
+	Missing      []string `json:"missing,omitempty"`      // Dependencies not installed, or disabled.
+	Enabled      bool     `json:"enabled"`                // Disabled plugins are moved aside, and not loaded.
+	Error        string   `json:"error,omitempty"`        // Why the jar could not be read, if not.
+}
+
+// PluginList lists the plugins (and mods) installed on the server.
+type PluginList struct {
+	Plugins         []Plugin `json:"plugins"`
+	Warnings        []string `json:"warnings"`        // e.g. missing dependencies.
+	RestartRequired bool     `json:"restartRequired"` // Plugins changed since the server started.
+}
+
+// PluginChange reports a change to the plugins installed.
+type PluginChange struct {
+	Plugin          *Plugin  `json:"plugin,omitempty"` // The plugin changed, unless removed.
+	Warnings        []string `json:"warnings"`         // Of all plugins, after the change.
+	RestartRequired bool     `json:"restartRequired"`  // The server must be restarted for the change to take effect.
+}
+
+// PluginManager is implemented by process managers able to manage the
+// plugins (e.g. of Paper) and mods (e.g. of Fabric or Forge) of a server.
+// Plugins are identified by their File.
+type PluginManager interface {
+
+	// Plugins returns the plugins installed.
+	Plugins() (*PluginList, error)
+
+	// InstallPlugin installs the jar at path as name, replacing any
+	// installed version of the same plugin.
+	InstallPlugin(path, name string) (*PluginChange, error)
+
+	// EnablePlugin enables (or disables) a plugin.
+	EnablePlugin(file string, enabled bool) (*PluginChange, error)
+
+	// RemovePlugin deletes a plugin.
+	RemovePlugin(file string) (*PluginChange, error)
+}
@@ -0,0 +1,47 @@
+package pickaxx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Check is the result of a check made before starting a server.
+type Check struct {
+	Name    string `json:"name"`          // Identifies the check (e.g. "eula").
+	Passed  bool   `json:"passed"`        // The server can be started, as far as this check is concerned.
+	Message string `json:"message"`       // What was found.
+	Fix     string `json:"fix,omitempty"` // How to fix a failed check.
+}
+
+// Preflighter is implemented by process managers able to check that a
+// server can be started.
+type Preflighter interface {
+
+	// Preflight runs all checks, returning their results.
+	Preflight() []Check
+}
+
+// PreflightError is returned when a server can not be started, because
+// checks failed.
+type PreflightError struct {
+	Failed []Check
+}
+
+func (e *PreflightError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, c := range e.Failed {
+		msgs[i] = c.Message
+	}
+	return fmt.Sprintf("preflight checks failed: %s", strings.Join(msgs, "; "))
+}
+
+// FailedChecks returns only the checks which did not pass.
+func FailedChecks(checks []Check) []Check {
+	failed := []Check{}
+	for _, c := range checks {
+		if !c.Passed {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
@@ -3,11 +3,18 @@ package pickaxx
 import (
 	"encoding/json"
 	"errors"
+	"time"
 )
 
 // ErrProcessExists exists when a new server process can not be started.
 var ErrProcessExists = errors.New("unable to start new process")
 
+// ErrNotSupported is returned when a server does not support an action.
+var ErrNotSupported = errors.New("not supported by this server")
+
+// DefaultKillTimeout is how long a process has to exit cleanly before it is killed.
+const DefaultKillTimeout = time.Second * 10
+
 // Data is anything that is emitted by a process manager.
 type Data json.Marshaler
 
@@ -17,6 +24,15 @@ type ConsoleData interface {
 	String() string
 }
 
+// Streams of output from a process.
+const (
+	Stdout = "stdout"
+	Stderr = "stderr"
+)
+
+// StderrPrefix marks lines written to stderr, in console output as persisted.
+const StderrPrefix = "[stderr] "
+
 // ProcessManager can manage and interact with a process.
 type ProcessManager interface {
 
@@ -24,7 +40,11 @@ type ProcessManager interface {
 	Start() (<-chan Data, error)
 
 	// Stop will halt the process and release any resources.
-	Stop() error
+	Stop(opts ...StopOption) error
+
+	// Restart will stop and then start the process, continuing to send
+	// activity to the channel returned by Start.
+	Restart(opts ...StopOption) error
 
 	// Running returns true if the underlying process is active, false otherwise.
 	Running() bool
@@ -32,3 +52,65 @@ type ProcessManager interface {
 	// Submit will send a command to the underlying process.
 	Submit(command string) error
 }
+
+// StopOptions describe how a process should be halted.
+type StopOptions struct {
+	Countdown   time.Duration // Total time to warn users before stopping.
+	Interval    time.Duration // Time between each warning during countdown.
+	Save        bool          // Save state before stopping.
+	KillTimeout time.Duration // Time allowed for a clean exit before the process is killed.
+	Reason      string        // Shown to users, and recorded with the stop event.
+}
+
+// StopOption configures a call to Stop.
+type StopOption func(*StopOptions)
+
+// NewStopOptions returns options with defaults applied, followed by any provided options.
+func NewStopOptions(opts ...StopOption) StopOptions {
+	o := StopOptions{KillTimeout: DefaultKillTimeout}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.KillTimeout <= 0 {
+		o.KillTimeout = DefaultKillTimeout
+	}
+
+	if o.Interval <= 0 || o.Interval > o.Countdown {
+		o.Interval = o.Countdown
+	}
+
+	return o
+}
+
+// WithCountdown will warn users every 'interval' for a total duration before stopping.
+func WithCountdown(total, interval time.Duration) StopOption {
+	return func(o *StopOptions) {
+		o.Countdown = total
+		o.Interval = interval
+	}
+}
+
+// WithSave will save state before stopping.
+func WithSave() StopOption {
+	return func(o *StopOptions) { o.Save = true }
+}
+
+// WithKillTimeout sets the time allowed for a clean exit.
+func WithKillTimeout(d time.Duration) StopOption {
+	return func(o *StopOptions) { o.KillTimeout = d }
+}
+
+// WithReason sets the reason shown to users for stopping.
+func WithReason(reason string) StopOption {
+	return func(o *StopOptions) { o.Reason = reason }
+}
+
+// CommandReporter is implemented by process managers which can report the
+// command line used to start a server.
+type CommandReporter interface {
+
+	// CommandLine returns the command, and its arguments, used to start the server.
+	CommandLine() ([]string, error)
+}
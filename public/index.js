@@ -5,6 +5,7 @@ let inputForm = null;
 let inputBox = null;
 let startButton = null;
 let stopButton = null;
+let restartButton = null;
 
 // Creates a new AJAX POST request
 function ajaxRequest(method, url) {
@@ -30,6 +31,30 @@ function sendCommand(event) {
   xhr.send(JSON.stringify({ command: inputBox.value }));
 }
 
+// Starts the server, showing any checks which prevented it from starting.
+function startServer() {
+  const xhr = ajaxRequest('POST', '/start');
+
+  xhr.onload = () => {
+    if (xhr.status < 400) {
+      return;
+    }
+
+    const body = JSON.parse(xhr.responseText);
+
+    if (body.checks === undefined) {
+      messageBox.showAlert(body.err);
+      return;
+    }
+
+    body.checks.forEach((check) => {
+      messageBox.showAlert(`Unable to start: ${check.message}. ${check.fix}`);
+    });
+  };
+
+  xhr.send();
+}
+
 const app = {
   init: () => {
     // DOM fields
@@ -37,15 +62,17 @@ const app = {
     inputBox = document.querySelector('#input-box');
     startButton = document.getElementById('startButton');
     stopButton = document.getElementById('stopButton');
+    restartButton = document.getElementById('restartButton');
 
     // setup initial state
     inputForm.addEventListener('submit', sendCommand);
-    startButton.addEventListener('click', () => { ajaxRequest('POST', '/start').send(); });
+    startButton.addEventListener('click', startServer);
     stopButton.addEventListener('click', () => { ajaxRequest('POST', '/stop').send(); });
+    restartButton.addEventListener('click', () => { ajaxRequest('POST', '/restart').send(); });
 
     fileDrop.init();
 
-    messageBox.init(startButton, stopButton);
+    messageBox.init(startButton, stopButton, restartButton);
 
     // check for WebSocket support (required).
     if (window.WebSocket === undefined) {
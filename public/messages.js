@@ -1,15 +1,30 @@
-const websocketURL = `ws://${document.location.host}/ws`;
+import * as stats from './stats.js';
+
+const websocketScheme = document.location.protocol === 'https:' ? 'wss' : 'ws';
+const websocketURL = `${websocketScheme}://${document.location.host}/ws`;
 
 let messages = null;
 let messageList = null;
 let conn = null;
 let startBtn = null;
 let stopBtn = null;
+let restartBtn = null;
 
 function resetScroll() {
   messages.scrollTop = messages.scrollHeight;
 }
 
+// Appends a highlighted line to the messages-list.
+function showAlert(text) {
+  const li = document.createElement('li');
+
+  li.classList.add('alert-line');
+  li.appendChild(document.createTextNode(text));
+  messageList.appendChild(li);
+
+  resetScroll();
+}
+
 function removeAllChildNodes(parent) {
   while (parent.firstChild) {
     parent.removeChild(parent.firstChild);
@@ -19,11 +34,22 @@ function removeAllChildNodes(parent) {
 // Handle Websocket messages.
 // Two types of responses are returned:
 //
-// 1. Server output:
-//      { "output" : "text that will appear in the messages-list" }
+// 1. Server output, and the stream it was written to (if from the server):
+//      { "output" : "text that will appear in the messages-list", "stream": "stdout | stderr" }
 //
 // 2. Process status changes:
 //      { "status" : "Starting | Stopping | etc.." }
+//    A server which failed to start also reports why, and how to fix it:
+//      { "status" : "Failed", "reason": "...", "failure": { "code": "eula", "message": "...", "fix": "..." } }
+//
+// 3. Process resource usage:
+//      { "stats" : { "cpuPercent": 12.5, "rss": 1073741824, ... } }
+//
+// 4. In-game performance:
+//      { "tick" : { "tps": 19.8, "mspt": 12.1, ... } }
+//
+// 5. Alerts:
+//      { "alert" : { "kind": "lowTPS", "message": "...", "value": 9.5 } }
 //
 function handleMessage(event) {
   const data = JSON.parse(event.data);
@@ -32,16 +58,28 @@ function handleMessage(event) {
     if (data.status === 'Starting' || data.status === 'Running') {
       startBtn.disabled = true;
       stopBtn.disabled = false;
+      restartBtn.disabled = data.status !== 'Running';
     } else if (data.status === 'Stopping') {
       startBtn.disabled = true;
       stopBtn.disabled = true;
+      restartBtn.disabled = true;
     } else {
       startBtn.disabled = false;
       stopBtn.disabled = true;
+      restartBtn.disabled = true;
     }
+  } else if (data.stats !== undefined) {
+    stats.update(data.stats);
+  } else if (data.tick !== undefined) {
+    stats.updateTick(data.tick);
+  } else if (data.alert !== undefined) {
+    showAlert(data.alert.message);
   } else if (data.output !== undefined) {
     const li = document.createElement('li');
 
+    if (data.stream === 'stderr') {
+      li.classList.add('stderr-line');
+    }
     li.appendChild(document.createTextNode(data.output));
     messageList.appendChild(li);
 
@@ -52,15 +90,19 @@ function handleMessage(event) {
 function handleClose() {
   startBtn.disabled = false;
   stopBtn.disabled = false;
+  restartBtn.disabled = false;
 }
 
 function clear() {
   removeAllChildNodes(messageList);
 }
 
-function init(startButton, stopButton) {
+function init(startButton, stopButton, restartButton) {
   startBtn = startButton;
   stopBtn = stopButton;
+  restartBtn = restartButton;
+
+  stats.init();
 
   messages = document.querySelector('.messages');
   messageList = document.querySelector('.message-list');
@@ -87,4 +129,4 @@ function onScriptLoad(websocketURL) {
   };
 }
 
-export { init, clear };
+export { init, clear, showAlert };
@@ -0,0 +1,109 @@
+// Live chart of process resource usage.
+//
+// Samples are received over the websocket as:
+//      { "stats" : { "cpuPercent": 12.5, "rss": 1073741824, ... } }
+//
+const maxSamples = 120;
+
+let chart = null;
+let label = null;
+let tickLabel = null;
+let samples = [];
+
+function formatBytes(bytes) {
+  const units = ['B', 'KB', 'MB', 'GB'];
+  let value = bytes;
+  let i = 0;
+
+  while (value >= 1024 && i < units.length - 1) {
+    value /= 1024;
+    i += 1;
+  }
+
+  return `${value.toFixed(1)} ${units[i]}`;
+}
+
+// draws a line for the given field, scaled to the canvas height.
+function drawLine(ctx, field, max, color) {
+  const { width, height } = ctx.canvas;
+  const step = width / (maxSamples - 1);
+
+  ctx.strokeStyle = color;
+  ctx.beginPath();
+
+  samples.forEach((s, i) => {
+    const x = width - (samples.length - 1 - i) * step;
+    const y = height - (s[field] / max) * (height - 2) - 1;
+
+    if (i === 0) {
+      ctx.moveTo(x, y);
+    } else {
+      ctx.lineTo(x, y);
+    }
+  });
+
+  ctx.stroke();
+}
+
+function draw() {
+  const ctx = chart.getContext('2d');
+  ctx.clearRect(0, 0, chart.width, chart.height);
+
+  if (samples.length === 0) {
+    return;
+  }
+
+  const maxCPU = Math.max(100, ...samples.map((s) => s.cpuPercent));
+  const maxRSS = Math.max(1, ...samples.map((s) => s.rss));
+
+  drawLine(ctx, 'cpuPercent', maxCPU, '#5bc0de');
+  drawLine(ctx, 'rss', maxRSS, '#5cb85c');
+}
+
+function update(stats) {
+  samples.push(stats);
+  samples = samples.slice(-maxSamples);
+
+  label.textContent = `CPU ${stats.cpuPercent.toFixed(0)}% · ${formatBytes(stats.rss)}`;
+  draw();
+}
+
+function updateTick(tick) {
+  tickLabel.textContent = `${tick.tps.toFixed(1)} TPS`;
+}
+
+function init() {
+  chart = document.getElementById('stats-chart');
+  label = document.getElementById('stats-label');
+  tickLabel = document.getElementById('tick-label');
+
+  // load recent history
+  const xhr = new XMLHttpRequest();
+  xhr.open('GET', '/stats', true);
+  xhr.onload = () => {
+    if (xhr.status !== 200) {
+      return;
+    }
+
+    const { history } = JSON.parse(xhr.responseText);
+    (history || []).forEach(update);
+  };
+  xhr.send();
+
+  // load latest in-game performance
+  const tickXHR = new XMLHttpRequest();
+  tickXHR.open('GET', '/performance', true);
+  tickXHR.onload = () => {
+    if (tickXHR.status !== 200) {
+      return;
+    }
+
+    const { latest } = JSON.parse(tickXHR.responseText);
+    if (latest) {
+      updateTick(latest);
+    }
+  };
+  tickXHR.send();
+}
+
+export { init, update, updateTick };
@@ -0,0 +1,43 @@
+package pickaxx
+
+import "time"
+
+// ProcessStats is a sample of resource usage for a running process.
+type ProcessStats struct {
+	Time       time.Time `json:"time"`
+	CPUPercent float64   `json:"cpuPercent"` // Percent of a single CPU used since the previous sample.
+	RSS        uint64    `json:"rss"`        // Resident memory, in bytes.
+	Threads    int       `json:"threads"`
+	OpenFDs    int       `json:"openFDs"`
+	ReadBytes  uint64    `json:"readBytes"`  // Total bytes read from storage.
+	WriteBytes uint64    `json:"writeBytes"` // Total bytes written to storage.
+}
+
+// StatsReporter is implemented by process managers that sample resource usage.
+type StatsReporter interface {
+
+	// Stats returns recent samples, oldest first. The last sample is the most recent.
+	Stats() []ProcessStats
+}
+
+// TickStats is a sample of in-game performance.
+type TickStats struct {
+	Time   time.Time `json:"time"`
+	TPS    float64   `json:"tps"`            // Ticks per second (20 is ideal).
+	MSPT   float64   `json:"mspt,omitempty"` // Milliseconds per tick, if known.
+	Source string    `json:"source"`         // What produced this sample (e.g. a command, or a lag warning).
+}
+
+// PerformanceReporter is implemented by process managers that track in-game performance.
+type PerformanceReporter interface {
+
+	// Performance returns recent samples, oldest first. The last sample is the most recent.
+	Performance() []TickStats
+}
+
+// PlayerLister is implemented by process managers that track players online.
+type PlayerLister interface {
+
+	// Players returns names of players currently online.
+	Players() []string
+}
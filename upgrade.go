@@ -0,0 +1,37 @@
+package pickaxx
+
+import "errors"
+
+// ErrDowngrade is returned when upgrading to an older version, unless forced.
+var ErrDowngrade = errors.New("jar is an older version than the server")
+
+// ErrNoUpgrade is returned when there is no upgrade to roll back.
+var ErrNoUpgrade = errors.New("no upgrade to roll back")
+
+// UpgradeResult reports the upgrade (or rollback) of a server jar.
+type UpgradeResult struct {
+	From      *JarInfo `json:"from,omitempty"`   // The jar replaced, if known.
+	To        *JarInfo `json:"to"`               // The jar now in place.
+	Backup    string   `json:"backup,omitempty"` // Backup of the working directory, taken before upgrading.
+	Restarted bool     `json:"restarted"`        // The server was running, and was restarted.
+	Running   bool     `json:"running"`          // The server restarted, and finished loading.
+	Error     string   `json:"error,omitempty"`  // Why the server is not running, if restarted.
+
+	WorldRestored bool `json:"worldRestored,omitempty"` // The world was restored from the backup, by a rollback.
+}
+
+// Upgrader is implemented by process managers able to replace the jar a
+// server is launched from.
+type Upgrader interface {
+
+	// Upgrade replaces the server jar with the jar at path, after backing up
+	// the working directory. Downgrades are refused unless forced. A running
+	// server is restarted, blocking until it has loaded (or failed to).
+	Upgrade(path string, force bool) (*UpgradeResult, error)
+
+	// Rollback restores the jar, and server software, from before the last
+	// upgrade. The world is only restored from the backup if restoreWorld is
+	// set, losing all play since. A running server is restarted, as for
+	// Upgrade.
+	Rollback(restoreWorld bool) (*UpgradeResult, error)
+}
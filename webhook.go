@@ -0,0 +1,315 @@
+package pickaxx
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+const (
+	// SignatureHeader holds the HMAC-SHA256 signature of a webhook body.
+	SignatureHeader = "X-Pickaxx-Signature"
+
+	// EventHeader holds the event type of a webhook body.
+	EventHeader = "X-Pickaxx-Event"
+
+	webhookQueueSize   = 100
+	webhookLogSize     = 100
+	webhookTimeout     = time.Second * 10
+	defaultMaxAttempts = 3
+	defaultBackoff     = time.Second
+)
+
+// Event is Data which can be delivered to webhooks.
+type Event interface {
+	Data
+
+	// EventType identifies this kind of event (e.g. "state", "player").
+	EventType() string
+}
+
+// WebhookConfig configures delivery of events to a single URL.
+type WebhookConfig struct {
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret"`      // Signs each body, if set.
+	Events      []string `json:"events"`      // Event types to deliver. All events, if empty.
+	MaxAttempts int      `json:"maxAttempts"` // Defaults to 3 if not set.
+	Backoff     Duration `json:"backoff"`     // Initial delay between attempts, doubling each retry. Defaults to 1s.
+}
+
+// accepts returns true if the given event type should be delivered.
+func (w WebhookConfig) accepts(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Delivery is a record of an attempt to deliver an event to a webhook.
+type Delivery struct {
+	URL        string    `json:"url"`
+	Event      string    `json:"event"`
+	Time       time.Time `json:"time"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// webhookPayload is the JSON body sent to webhooks.
+type webhookPayload struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	Data  Data      `json:"data"`
+}
+
+type webhookJob struct {
+	hook    WebhookConfig
+	event   string
+	payload []byte
+}
+
+// WebhookDispatcher delivers events to configured webhooks in the background.
+// Each URL has its own queue, so that retries to an endpoint which is down do
+// not delay deliveries to others. This implementation can be accessed
+// concurrently by multiple goroutines.
+type WebhookDispatcher struct {
+	Client *http.Client // Defaults to a client with a 10 second timeout.
+
+	mutex  sync.Mutex
+	hooks  []WebhookConfig
+	queues map[string]chan webhookJob // by URL
+	log    []Delivery
+	closed bool
+	done   chan struct{} // closed by Close, ending retries in progress
+}
+
+// NewWebhookDispatcher creates a dispatcher for the given webhooks, and starts
+// delivering events in the background.
+func NewWebhookDispatcher(hooks []WebhookConfig) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		Client: &http.Client{Timeout: webhookTimeout},
+		queues: map[string]chan webhookJob{},
+		done:   make(chan struct{}),
+	}
+
+	d.Configure(hooks)
+	return d
+}
+
+// Configure replaces the set of webhooks. Queued deliveries are not affected.
+func (d *WebhookDispatcher) Configure(hooks []WebhookConfig) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed {
+		return
+	}
+
+	d.hooks = append([]WebhookConfig{}, hooks...)
+
+	urls := map[string]bool{}
+	for _, hook := range hooks {
+		urls[hook.URL] = true
+
+		if _, ok := d.queues[hook.URL]; !ok {
+			queue := make(chan webhookJob, webhookQueueSize)
+			d.queues[hook.URL] = queue
+			go d.deliveryLoop(queue)
+		}
+	}
+
+	// queues of removed webhooks are delivered, then stop
+	for url, queue := range d.queues {
+		if !urls[url] {
+			close(queue)
+			delete(d.queues, url)
+		}
+	}
+}
+
+// Dispatch queues delivery of data to each webhook accepting it. Data which
+// is not an Event is ignored. If the queue is full, the event is dropped.
+func (d *WebhookDispatcher) Dispatch(data Data) {
+	event, ok := data.(Event)
+	if !ok {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed {
+		return
+	}
+
+	var payload []byte
+
+	for _, hook := range d.hooks {
+		if !hook.accepts(event.EventType()) {
+			continue
+		}
+
+		if payload == nil {
+			var err error
+			payload, err = json.Marshal(webhookPayload{event.EventType(), time.Now(), event})
+
+			if err != nil {
+				log.WithError(err).Error("unable to encode webhook payload")
+				return
+			}
+		}
+
+		select {
+		case d.queues[hook.URL] <- webhookJob{hook, event.EventType(), payload}:
+		default:
+			d.appendLog(Delivery{URL: hook.URL, Event: event.EventType(), Time: time.Now(), Error: "queue full"})
+		}
+	}
+}
+
+// Deliveries returns recent deliveries, oldest first.
+func (d *WebhookDispatcher) Deliveries() []Delivery {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]Delivery{}, d.log...)
+}
+
+// Close stops delivering events. Queued events are discarded, and a
+// delivery in progress is not retried.
+func (d *WebhookDispatcher) Close() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.closed {
+		d.closed = true
+		close(d.done)
+		for url, queue := range d.queues {
+			close(queue)
+			delete(d.queues, url)
+		}
+	}
+
+	return nil
+}
+
+func (d *WebhookDispatcher) record(delivery Delivery) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.appendLog(delivery)
+}
+
+// appendLog adds to the delivery log. Callers must hold the mutex.
+func (d *WebhookDispatcher) appendLog(delivery Delivery) {
+	d.log = append(d.log, delivery)
+
+	if len(d.log) > webhookLogSize {
+		d.log = d.log[len(d.log)-webhookLogSize:]
+	}
+}
+
+// deliveryLoop delivers the jobs on a queue, in order, until it is closed.
+// Jobs still queued once the dispatcher is closed are discarded.
+func (d *WebhookDispatcher) deliveryLoop(queue <-chan webhookJob) {
+	for job := range queue {
+		select {
+		case <-d.done:
+			continue
+		default:
+		}
+
+		d.record(deliver(d.Client, job, d.done))
+	}
+}
+
+// deliver sends a payload to a webhook, retrying with backoff on failure,
+// until done is closed.
+func deliver(client *http.Client, job webhookJob, done <-chan struct{}) Delivery {
+	var (
+		hook     = job.hook
+		attempts = hook.MaxAttempts
+		backoff  = time.Duration(hook.Backoff)
+		result   = Delivery{URL: hook.URL, Event: job.event, Time: time.Now()}
+	)
+
+	if attempts <= 0 {
+		attempts = defaultMaxAttempts
+	}
+
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+
+	for result.Attempts < attempts {
+		if result.Attempts > 0 {
+			select {
+			case <-done:
+				return result
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		result.Attempts++
+		result.StatusCode, result.Error = 0, ""
+
+		status, err := post(client, hook, job)
+		result.StatusCode = status
+
+		if err == nil {
+			return result
+		}
+
+		result.Error = err.Error()
+		log.WithError(err).WithField("url", hook.URL).Warn("webhook delivery failed")
+	}
+
+	return result
+}
+
+func post(client *http.Client, hook WebhookConfig, job webhookJob) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(job.payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, job.event)
+
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, job.payload))
+	}
+
+	rsp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return rsp.StatusCode, fmt.Errorf("unexpected status: %s", rsp.Status)
+	}
+
+	return rsp.StatusCode, nil
+}
+
+// Sign returns the signature of a body, as sent in the signature header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
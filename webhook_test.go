@@ -0,0 +1,191 @@
+package pickaxx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEvent struct {
+	kind string
+}
+
+func (e testEvent) EventType() string { return e.kind }
+
+func (e testEvent) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"kind":"%s"}`, e.kind)), nil
+}
+
+// nonEvent is Data which is not an Event.
+type nonEvent struct{}
+
+func (nonEvent) MarshalJSON() ([]byte, error) { return []byte(`{}`), nil }
+
+func TestWebhookDispatcher(t *testing.T) {
+	var (
+		received = make(chan *http.Request, 10)
+		bodies   = make(chan []byte, 10)
+		failures int32
+	)
+
+	// local stand-in for a webhook receiver
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer ts.Close()
+
+	d := NewWebhookDispatcher([]WebhookConfig{{
+		URL:     ts.URL,
+		Secret:  "s3cret",
+		Events:  []string{"state"},
+		Backoff: Duration(time.Millisecond),
+	}})
+	defer d.Close()
+
+	t.Run("signed delivery", func(t *testing.T) {
+		d.Dispatch(testEvent{"state"})
+
+		r, body := <-received, <-bodies
+		assert.Equal(t, "state", r.Header.Get(EventHeader))
+		assert.Equal(t, Sign("s3cret", body), r.Header.Get(SignatureHeader))
+
+		payload := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, "state", payload["event"])
+		assert.Equal(t, map[string]interface{}{"kind": "state"}, payload["data"])
+	})
+
+	t.Run("filters events", func(t *testing.T) {
+		d.Dispatch(testEvent{"player"})
+		d.Dispatch(nonEvent{})
+
+		select {
+		case <-received:
+			assert.Fail(t, "unexpected delivery")
+		case <-time.After(time.Millisecond * 20):
+		}
+	})
+
+	t.Run("retries with backoff", func(t *testing.T) {
+		atomic.StoreInt32(&failures, 2)
+		d.Dispatch(testEvent{"state"})
+		<-received
+		<-bodies
+
+		assert.Eventually(t, func() bool {
+			log := d.Deliveries()
+			last := log[len(log)-1]
+			return last.Attempts == 3 && last.StatusCode == http.StatusOK && last.Error == ""
+		}, time.Millisecond*200, time.Millisecond*5)
+	})
+
+	t.Run("records failures", func(t *testing.T) {
+		atomic.StoreInt32(&failures, 5)
+		d.Dispatch(testEvent{"state"})
+
+		assert.Eventually(t, func() bool {
+			log := d.Deliveries()
+			last := log[len(log)-1]
+			return last.Attempts == defaultMaxAttempts && last.StatusCode == http.StatusInternalServerError && last.Error != ""
+		}, time.Millisecond*200, time.Millisecond*5)
+	})
+}
+
+func TestWebhookDispatcherQueuesByURL(t *testing.T) {
+	received := make(chan string, 10)
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(EventHeader)
+	}))
+	defer up.Close()
+
+	d := NewWebhookDispatcher([]WebhookConfig{
+		{URL: down.URL, Backoff: Duration(time.Minute)},
+		{URL: up.URL},
+	})
+	defer d.Close()
+
+	d.Dispatch(testEvent{"state"})
+	d.Dispatch(testEvent{"player"})
+
+	// retries to the endpoint which is down do not delay the other
+	for _, expected := range []string{"state", "player"} {
+		select {
+		case event := <-received:
+			assert.Equal(t, expected, event)
+		case <-time.After(time.Second * 5):
+			assert.Fail(t, "delivery delayed by another webhook")
+		}
+	}
+
+	// removing a webhook stops its queue; new webhooks get their own
+	d.Configure([]WebhookConfig{{URL: up.URL}})
+	d.mutex.Lock()
+	assert.Len(t, d.queues, 1)
+	d.mutex.Unlock()
+}
+
+func TestWebhookDispatcherClose(t *testing.T) {
+	var requests int32
+	received, release := make(chan bool, 10), make(chan bool)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		received <- true
+		<-release
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	d := NewWebhookDispatcher([]WebhookConfig{{URL: srv.URL, Backoff: Duration(time.Minute)}})
+
+	d.Dispatch(testEvent{"state"})
+	d.Dispatch(testEvent{"player"})
+	d.Dispatch(testEvent{"alert"})
+
+	// close while the first event is being delivered
+	<-received
+	d.Close()
+	close(release)
+
+	// the failed delivery is not retried, and queued events are discarded
+	assert.Eventually(t, func() bool {
+		log := d.Deliveries()
+		return len(log) == 1 && log[0].Attempts == 1
+	}, time.Second, time.Millisecond*5)
+
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Len(t, d.Deliveries(), 1)
+}
+
+func TestDuration(t *testing.T) {
+	var d struct {
+		A Duration `json:"a"`
+		B Duration `json:"b"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"a":"1m30s","b":2}`), &d))
+	assert.Equal(t, Duration(time.Second*90), d.A)
+	assert.Equal(t, Duration(time.Second*2), d.B)
+	assert.Error(t, json.Unmarshal([]byte(`{"a":true}`), &d))
+}